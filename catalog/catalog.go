@@ -0,0 +1,208 @@
+// Package catalog keeps a local copy of YTS.LT movies which can be used without network access.
+package catalog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/qopher/ytsgo"
+	"github.com/qopher/ytsgo/internal/fsutil"
+)
+
+const (
+	// DefaultPageSize is a number of movies requested per page during Sync.
+	DefaultPageSize = 50
+)
+
+// Lister lists movies. It is implemented by *ytsgo.Client.
+type Lister interface {
+	ListMovies(opts ...ytsgo.ListMoviesOption) (*ytsgo.Movies, error)
+}
+
+// Catalog is a set of movies indexed by ID.
+type Catalog struct {
+	movies map[uint]*ytsgo.Movie
+	// Synced is the time of the last successful Sync.
+	Synced time.Time
+}
+
+// New returns an empty Catalog.
+func New() *Catalog {
+	return &Catalog{
+		movies: make(map[uint]*ytsgo.Movie),
+	}
+}
+
+// Add adds movies to the catalog replacing movies with the same ID. Nil
+// movies are skipped.
+func (c *Catalog) Add(movies ...*ytsgo.Movie) {
+	for _, m := range movies {
+		if m != nil {
+			c.movies[m.ID] = m
+		}
+	}
+}
+
+// Movie returns the movie with given ID or nil if it is not in the catalog.
+func (c *Catalog) Movie(id uint) *ytsgo.Movie {
+	return c.movies[id]
+}
+
+// Movies returns all movies sorted by ID.
+func (c *Catalog) Movies() []*ytsgo.Movie {
+	ret := make([]*ytsgo.Movie, 0, len(c.movies))
+	for _, m := range c.movies {
+		ret = append(ret, m)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].ID < ret[j].ID })
+	return ret
+}
+
+// Len returns a number of movies in the catalog.
+func (c *Catalog) Len() int {
+	return len(c.movies)
+}
+
+// SyncOption changes the default behavior of Sync.
+type SyncOption func(s *syncer)
+
+// SyncFull if true makes Sync fetch all pages instead of stopping at the
+// first page without new or updated movies.
+func SyncFull(b bool) SyncOption {
+	return func(s *syncer) {
+		s.full = b
+	}
+}
+
+// SyncMaxPages limits a number of pages fetched by Sync. Zero means no limit.
+func SyncMaxPages(n uint) SyncOption {
+	return func(s *syncer) {
+		s.maxPages = n
+	}
+}
+
+// SyncQuery adds ListMovies options, eg. genre or quality, to every query made by Sync.
+func SyncQuery(opts ...ytsgo.ListMoviesOption) SyncOption {
+	return func(s *syncer) {
+		s.query = append(s.query, opts...)
+	}
+}
+
+type syncer struct {
+	full     bool
+	maxPages uint
+	query    []ytsgo.ListMoviesOption
+}
+
+// Sync fetches movies from newest to oldest and adds them to the catalog.
+// Unless SyncFull is set it stops at the first page which contains no new or
+// updated movies. It returns a number of new or updated movies.
+func (c *Catalog) Sync(l Lister, opts ...SyncOption) (int, error) {
+	s := &syncer{}
+	for _, o := range opts {
+		o(s)
+	}
+	updated := 0
+	for page := uint(1); s.maxPages == 0 || page <= s.maxPages; page++ {
+		q := append([]ytsgo.ListMoviesOption{
+			ytsgo.LMLimit(DefaultPageSize),
+			ytsgo.LMPage(page),
+			ytsgo.LMSortBy("date_added"),
+			ytsgo.LMOrderBy("desc"),
+		}, s.query...)
+		mvs, err := l.ListMovies(q...)
+		if err != nil {
+			return updated, fmt.Errorf("failed to list page %d: %v", page, err)
+		}
+		if mvs == nil || len(mvs.Movies) == 0 {
+			break
+		}
+		changed := 0
+		for _, m := range mvs.Movies {
+			if m == nil {
+				continue
+			}
+			if old, ok := c.movies[m.ID]; ok && !changedMovie(old, m) {
+				continue
+			}
+			c.movies[m.ID] = m
+			changed++
+		}
+		updated += changed
+		if changed == 0 && !s.full {
+			break
+		}
+		if uint(len(mvs.Movies)) < DefaultPageSize || page*DefaultPageSize >= mvs.MovieCount {
+			break
+		}
+	}
+	c.Synced = time.Now()
+	return updated, nil
+}
+
+// changedMovie reports whether movie b differs from a in the parts updated by YTS.
+func changedMovie(a, b *ytsgo.Movie) bool {
+	if a.DateUploadedUnix != b.DateUploadedUnix || len(a.Torrents) != len(b.Torrents) {
+		return true
+	}
+	for i := range a.Torrents {
+		if hash(a.Torrents[i]) != hash(b.Torrents[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+func hash(t *ytsgo.Torrent) string {
+	if t == nil {
+		return ""
+	}
+	return t.Hash
+}
+
+type catalogJSON struct {
+	Synced time.Time      `json:"synced"`
+	Movies []*ytsgo.Movie `json:"movies"`
+}
+
+// Save writes the catalog as JSON.
+func (c *Catalog) Save(w io.Writer) error {
+	return json.NewEncoder(w).Encode(catalogJSON{
+		Synced: c.Synced,
+		Movies: c.Movies(),
+	})
+}
+
+// Load reads the catalog written by Save.
+func Load(r io.Reader) (*Catalog, error) {
+	var aux catalogJSON
+	if err := json.NewDecoder(r).Decode(&aux); err != nil {
+		return nil, err
+	}
+	c := New()
+	c.Add(aux.Movies...)
+	c.Synced = aux.Synced
+	return c, nil
+}
+
+// SaveFile writes the catalog to the file. The file is replaced atomically.
+func (c *Catalog) SaveFile(path string) error {
+	return fsutil.Write(path, c.Save)
+}
+
+// LoadFile reads the catalog from the file. A missing file results in an empty catalog.
+func LoadFile(path string) (*Catalog, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return New(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Load(f)
+}
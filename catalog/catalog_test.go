@@ -0,0 +1,171 @@
+package catalog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qopher/ytsgo"
+)
+
+// fakeLister serves movies newest first, DefaultPageSize per page.
+type fakeLister struct {
+	movies []*ytsgo.Movie
+	pages  []string
+	err    error
+}
+
+func (f *fakeLister) ListMovies(opts ...ytsgo.ListMoviesOption) (*ytsgo.Movies, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	v := url.Values{}
+	for _, o := range opts {
+		o(v)
+	}
+	f.pages = append(f.pages, v.Get("page"))
+	page, _ := strconv.Atoi(v.Get("page"))
+	limit, _ := strconv.Atoi(v.Get("limit"))
+	from := (page - 1) * limit
+	to := from + limit
+	if from > len(f.movies) {
+		from = len(f.movies)
+	}
+	if to > len(f.movies) {
+		to = len(f.movies)
+	}
+	return &ytsgo.Movies{
+		MovieCount: uint(len(f.movies)),
+		Page:       uint(page),
+		Limit:      uint(limit),
+		Movies:     f.movies[from:to],
+	}, nil
+}
+
+func testMovies(n int) []*ytsgo.Movie {
+	var ret []*ytsgo.Movie
+	for i := n; i > 0; i-- {
+		ret = append(ret, &ytsgo.Movie{
+			ID:               uint(i),
+			Title:            fmt.Sprintf("Movie %d", i),
+			DateUploadedUnix: int64(i),
+			Torrents:         []*ytsgo.Torrent{{Hash: fmt.Sprintf("HASH%d", i)}},
+		})
+	}
+	return ret
+}
+
+func TestSync(t *testing.T) {
+	l := &fakeLister{movies: testMovies(120)}
+	c := New()
+	n, err := c.Sync(l)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if n != 120 || c.Len() != 120 {
+		t.Errorf("Unexpected number of movies, got %d updated and %d in catalog, want 120", n, c.Len())
+	}
+	if diff := cmp.Diff([]string{"1", "2", "3"}, l.pages); diff != "" {
+		t.Errorf("Unexpected pages, diff -want +got\n%s", diff)
+	}
+	if c.Synced.IsZero() {
+		t.Error("Synced time was not set")
+	}
+
+	// Incremental sync stops at the first page without changes.
+	l.movies = append(testMovies(125)[:5], l.movies...)
+	l.movies[1].Torrents = append(l.movies[1].Torrents, &ytsgo.Torrent{Hash: "NEW"})
+	l.pages = nil
+	n, err = c.Sync(l)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if n != 5 || c.Len() != 125 {
+		t.Errorf("Unexpected number of movies, got %d updated and %d in catalog, want 5 and 125", n, c.Len())
+	}
+	if diff := cmp.Diff([]string{"1", "2"}, l.pages); diff != "" {
+		t.Errorf("Unexpected pages, diff -want +got\n%s", diff)
+	}
+	if got := len(c.Movie(124).Torrents); got != 2 {
+		t.Errorf("Updated movie was not replaced, got %d torrents want 2", got)
+	}
+
+	l.pages = nil
+	if _, err := c.Sync(l, SyncFull(true), SyncMaxPages(2)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]string{"1", "2"}, l.pages); diff != "" {
+		t.Errorf("Unexpected pages, diff -want +got\n%s", diff)
+	}
+
+	l.err = errors.New("some error")
+	if _, err := c.Sync(l); err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	c := New()
+	c.Add(testMovies(3)...)
+	c.Movie(2).URL = &url.URL{Scheme: "https", Host: "yts.lt", Path: "/movie/2"}
+	if _, err := c.Sync(&fakeLister{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := c.Save(&buf); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	got, err := Load(&buf)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !got.Synced.Equal(c.Synced) {
+		t.Errorf("Unexpected synced time, got %v want %v", got.Synced, c.Synced)
+	}
+	if got.Len() != 3 || got.Movie(2).URL.String() != "https://yts.lt/movie/2" || got.Movie(3).Torrents[0].Hash != "HASH3" {
+		t.Errorf("Unexpected catalog after load: %+v", got.Movies())
+	}
+
+	path := filepath.Join(t.TempDir(), "catalog.json")
+	empty, err := LoadFile(path)
+	if err != nil || empty.Len() != 0 {
+		t.Fatalf("Unexpected result for missing file, got %v movies: %v", empty.Len(), err)
+	}
+	if err := c.SaveFile(path); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	got, err = LoadFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got.Len() != 3 {
+		t.Errorf("Unexpected number of movies, got %d want 3", got.Len())
+	}
+}
+
+func TestNilMovies(t *testing.T) {
+	c, err := Load(bytes.NewBufferString(`{"movies": [null, {"id": 1, "torrents": [null, {"hash": "A"}]}]}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if c.Len() != 1 {
+		t.Errorf("Unexpected number of movies, got %d want 1", c.Len())
+	}
+	l := &fakeLister{movies: []*ytsgo.Movie{
+		nil,
+		{ID: 1, Torrents: []*ytsgo.Torrent{{Hash: "A"}, nil}},
+		{ID: 2, Torrents: []*ytsgo.Torrent{nil}},
+	}}
+	n, err := c.Sync(l)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if n != 2 || c.Len() != 2 {
+		t.Errorf("Unexpected sync result, got %d updated and %d movies want 2 and 2", n, c.Len())
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/qopher/ytsgo"
+	"github.com/qopher/ytsgo/qbittorrent"
+	"github.com/qopher/ytsgo/transmission"
+)
+
+// add enqueues a torrent of the movie, or a magnet link, in Transmission or qBittorrent.
+func add(c *ytsgo.Client, fs *flag.FlagSet, args []string) error {
+	rpc := fs.String("rpc", transmission.DefaultURL, "Transmission RPC URL")
+	qbt := fs.String("qbittorrent", "", "qBittorrent Web UI URL, the torrent is added to qBittorrent instead of Transmission if set")
+	user := fs.String("user", "", "Transmission or qBittorrent user name")
+	password := fs.String("password", os.Getenv("TORRENT_PASSWORD"), "Transmission or qBittorrent password, defaults to $TORRENT_PASSWORD")
+	dir := fs.String("dir", "", "Download directory")
+	labels := fs.String("labels", "", "Comma separated list of labels (qBittorrent tags)")
+	category := fs.String("category", "", "qBittorrent category")
+	upload := fs.Bool("upload", false, "Upload the .torrent file to qBittorrent instead of the magnet link")
+	quality := fs.String("quality", *defaultQuality, "Wanted quality, the best one is added if empty")
+	paused := fs.Bool("paused", false, "Add the torrent without starting it")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return usageError("want one movie ID, IMDb code, slug or magnet link")
+	}
+
+	var e enqueuer
+	if *qbt != "" {
+		e = &qbtEnqueuer{
+			c:      &qbittorrent.Client{URL: *qbt, Username: *user, Password: *password},
+			opts:   qbittorrent.AddOptions{SavePath: *dir, Category: *category, Tags: splitList(*labels), Paused: *paused},
+			upload: *upload,
+		}
+	} else {
+		e = &transmissionEnqueuer{
+			c:    &transmission.Client{URL: *rpc, Username: *user, Password: *password},
+			opts: transmission.AddOptions{DownloadDir: *dir, Labels: splitList(*labels), Paused: *paused},
+		}
+	}
+	ctx := context.Background()
+	arg := fs.Arg(0)
+	if strings.HasPrefix(arg, "magnet:") {
+		if err := e.addMagnet(ctx, arg); err != nil {
+			return fmt.Errorf("failed to add magnet: %w", err)
+		}
+		return nil
+	}
+	m, err := lookupMovie(c, arg)
+	if err != nil {
+		return err
+	}
+	trt := pickTorrent(m, *quality)
+	if trt == nil {
+		return fmt.Errorf("no matching torrent of %q (%v)", m.Title, m.Year)
+	}
+	if err := e.add(ctx, trt); err != nil {
+		return fmt.Errorf("failed to add %q (%v) [%s]: %w", m.Title, m.Year, trt.Quality, err)
+	}
+	return nil
+}
+
+// enqueuer adds torrents to a torrent client.
+type enqueuer interface {
+	add(ctx context.Context, t *ytsgo.Torrent) error
+	addMagnet(ctx context.Context, magnet string) error
+}
+
+type transmissionEnqueuer struct {
+	c    *transmission.Client
+	opts transmission.AddOptions
+}
+
+func (e *transmissionEnqueuer) add(ctx context.Context, t *ytsgo.Torrent) error {
+	return e.addMagnet(ctx, t.Magnet())
+}
+
+func (e *transmissionEnqueuer) addMagnet(ctx context.Context, magnet string) error {
+	t, err := e.c.AddMagnet(ctx, magnet, e.opts)
+	if err != nil {
+		return err
+	}
+	if t.Duplicate {
+		fmt.Printf("Already added: %s (id %d)\n", t.Name, t.ID)
+		return nil
+	}
+	fmt.Printf("Added: %s (id %d)\n", t.Name, t.ID)
+	return nil
+}
+
+type qbtEnqueuer struct {
+	c      *qbittorrent.Client
+	opts   qbittorrent.AddOptions
+	upload bool
+}
+
+func (e *qbtEnqueuer) add(ctx context.Context, t *ytsgo.Torrent) error {
+	if !e.upload {
+		return e.addMagnet(ctx, t.Magnet())
+	}
+	if err := e.c.Upload(ctx, t, e.opts); err != nil {
+		return err
+	}
+	fmt.Printf("Added: %s\n", t.Hash)
+	return nil
+}
+
+func (e *qbtEnqueuer) addMagnet(ctx context.Context, magnet string) error {
+	if err := e.c.AddMagnet(ctx, magnet, e.opts); err != nil {
+		return err
+	}
+	fmt.Printf("Added: %s\n", magnet)
+	return nil
+}
+
+// pickTorrent returns the torrent of given quality, or the best one, which
+// matches the size filter.
+func pickTorrent(m *ytsgo.Movie, quality string) *ytsgo.Torrent {
+	torrents := sizeFlt.Filter(m.Torrents)
+	if quality == "" {
+		return ytsgo.BestTorrent(torrents)
+	}
+	for _, t := range torrents {
+		if strings.EqualFold(t.Quality, quality) {
+			return t
+		}
+	}
+	return nil
+}
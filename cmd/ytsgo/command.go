@@ -0,0 +1,179 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+
+	"github.com/qopher/ytsgo"
+)
+
+// Exit codes of ytsgo.
+const (
+	exitOK = iota
+	// exitFailure is used for errors without a more specific code.
+	exitFailure
+	// exitUsage is used for invalid arguments, flags and config files.
+	exitUsage
+	// exitNotFound is used if the movie does not exist.
+	exitNotFound
+	// exitNetwork is used if the API or a torrent client can't be reached
+	// or fails.
+	exitNetwork
+)
+
+// command is a ytsgo subcommand.
+type command struct {
+	name string
+	// args is a synopsis of positional arguments.
+	args string
+	// summary is a one line description of the command.
+	summary string
+	// run parses args with fs and runs the command. It returns flag.ErrHelp
+	// if help was requested.
+	run func(c *ytsgo.Client, fs *flag.FlagSet, args []string) error
+}
+
+var commands = []*command{
+	{name: "movie", args: "id|imdb_code|slug", summary: "Show details of a movie", run: movieCmd},
+	{name: "list", args: `["search term"]`, summary: "List and search movies", run: listCmd},
+	{name: "suggest", args: "id|imdb_code|slug", summary: "Show movies related to a movie", run: suggestCmd},
+	{name: "watch", summary: "Poll for new releases and deliver them to webhooks", run: watch},
+	{name: "feed", summary: "Write or serve RSS and Atom feeds of movies", run: feedCmd},
+	{name: "torznab", summary: "Serve a Torznab indexer, eg. for Radarr", run: torznabCmd},
+	{name: "serve", summary: "Serve a caching proxy of the API", run: serve},
+	{name: "add", args: "id|imdb_code|slug|magnet", summary: "Add a torrent to Transmission or qBittorrent", run: add},
+	{name: "export", args: "id|imdb_code|slug...", summary: "Write torrents to a watch directory", run: exportCmd},
+	{name: "library", args: "dir", summary: "Match a local media library with YTS movies", run: libraryCmd},
+	{name: "nfo", args: "id|imdb_code|slug...", summary: "Write Kodi and Jellyfin movie.nfo files and artwork", run: nfoCmd},
+}
+
+func findCommand(name string) *command {
+	for _, c := range commands {
+		if c.name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// exec runs the command and reports its error, returning the exit code.
+// Usage and usage errors are written to out.
+func (cmd *command) exec(c *ytsgo.Client, args []string, out io.Writer) int {
+	fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	fs.SetOutput(out)
+	fs.Usage = func() { cmd.usage(fs) }
+	err := cmd.run(c, fs, args)
+	code := exitCode(err)
+	switch e := err.(type) {
+	case nil:
+	case usageError:
+		if e != errUsageReported {
+			fmt.Fprintf(out, "ytsgo %s: %s\nRun 'ytsgo help %s' for usage.\n", cmd.name, e, cmd.name)
+		}
+	default:
+		if err != flag.ErrHelp {
+			log.Print(err)
+		}
+	}
+	return code
+}
+
+func (cmd *command) usage(fs *flag.FlagSet) {
+	w := fs.Output()
+	fmt.Fprintf(w, "Usage: ytsgo %s [flags] %s\n\n%s.\n\nFlags:\n", cmd.name, cmd.args, cmd.summary)
+	fs.PrintDefaults()
+}
+
+// usageError is returned by commands run with invalid arguments.
+type usageError string
+
+func (e usageError) Error() string { return string(e) }
+
+// errUsageReported is returned for invalid flags, which are already reported
+// by the flag set.
+const errUsageReported = usageError("")
+
+// parseFlags parses flags of a command.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return err
+		}
+		return errUsageReported
+	}
+	return nil
+}
+
+// exitCode returns the exit code of a command which failed with err.
+func exitCode(err error) int {
+	if err == nil || err == flag.ErrHelp {
+		return exitOK
+	}
+	var ue usageError
+	if errors.As(err, &ue) {
+		return exitUsage
+	}
+	if errors.Is(err, ytsgo.ErrNotFound) {
+		return exitNotFound
+	}
+	var se *ytsgo.StatusError
+	if errors.As(err, &se) {
+		// Other codes, eg. 404 of a wrong base URL, are not transient.
+		if se.StatusCode >= http.StatusInternalServerError {
+			return exitNetwork
+		}
+		return exitFailure
+	}
+	var ce *ytsgo.ContentTypeError
+	var ne net.Error
+	if errors.As(err, &ce) || errors.As(err, &ne) {
+		return exitNetwork
+	}
+	return exitFailure
+}
+
+// help prints the list of commands or help of a single command.
+func help(args []string) int {
+	if len(args) == 0 {
+		usage(os.Stdout)
+		return exitOK
+	}
+	cmd := findCommand(args[0])
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "ytsgo help: unknown command %q\n", args[0])
+		return exitUsage
+	}
+	// Commands print their help before using the client.
+	return cmd.exec(nil, []string{"-h"}, os.Stdout)
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: ytsgo [flags] command [command flags] [arguments]\n\nCommands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-8s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintf(w, `
+Run 'ytsgo help command' for flags and arguments of the command.
+
+Global flags are read from the config file %s,
+overridden by YTSGO_<FLAG> environment variables, eg. YTSGO_YTS_URL, and then
+by the command line. Global flags use underscores like their environment
+variables, command flags use dashes, eg. -min-rating:
+`, configPath())
+	flag.CommandLine.SetOutput(w)
+	flag.PrintDefaults()
+	fmt.Fprintf(w, `
+Exit codes:
+  %d  success
+  %d  failure
+  %d  invalid arguments, flags or config
+  %d  movie not found
+  %d  network or server failure
+`, exitOK, exitFailure, exitUsage, exitNotFound, exitNetwork)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// envPrefix is a prefix of environment variables overriding global flags.
+const envPrefix = "YTSGO_"
+
+// configPath returns the path of the config file: -config, $YTSGO_CONFIG or
+// ytsgo/config in the user config directory.
+func configPath() string {
+	if *config != "" {
+		return *config
+	}
+	if p := os.Getenv(envPrefix + "CONFIG"); p != "" {
+		return p
+	}
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "ytsgo", "config")
+}
+
+// readConfig reads "flag = value" lines of the config file. Empty lines and
+// lines starting with # are skipped. A missing file is not an error unless
+// it was set explicitly.
+func readConfig(path string, explicit bool) (map[string]string, error) {
+	ret := map[string]string{}
+	if path == "" {
+		return ret, nil
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) && !explicit {
+		return ret, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.Index(line, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("%s:%d: want flag = value", path, n)
+		}
+		name := strings.TrimSpace(line[:i])
+		if name == "config" || flag.Lookup(name) == nil {
+			return nil, fmt.Errorf("%s:%d: unknown setting %q", path, n, name)
+		}
+		ret[name] = strings.TrimSpace(line[i+1:])
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// configure sets global flags missing on the command line from environment
+// variables and the config file.
+func configure() error {
+	set := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	explicit := set["config"] || os.Getenv(envPrefix+"CONFIG") != ""
+	path := configPath()
+	settings, err := readConfig(path, explicit)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %v", err)
+	}
+	flag.VisitAll(func(f *flag.Flag) {
+		if v, ok := os.LookupEnv(envPrefix + strings.ToUpper(f.Name)); ok && f.Name != "config" {
+			settings[f.Name] = v
+		}
+	})
+	for name, v := range settings {
+		if set[name] {
+			continue
+		}
+		if err := flag.Set(name, v); err != nil {
+			return fmt.Errorf("invalid %s %q: %v", name, v, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/qopher/ytsgo"
+	"github.com/qopher/ytsgo/watchfolder"
+)
+
+// exportCmd writes torrents of the movies to a watch directory.
+func exportCmd(c *ytsgo.Client, fs *flag.FlagSet, args []string) error {
+	dir := fs.String("dir", ".", "Watch directory of the torrent client")
+	template := fs.String("template", watchfolder.DefaultTemplate, "Name of exported files")
+	magnet := fs.Bool("magnet", false, "Write .magnet files instead of downloading .torrent files")
+	quality := fs.String("quality", *defaultQuality, "Wanted quality, the best one is exported if empty")
+	all := fs.Bool("all", false, "Export torrents of all qualities")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return usageError("want at least one movie ID, IMDb code or slug")
+	}
+
+	e := &watchfolder.Exporter{Dir: *dir, Template: *template, Magnet: *magnet}
+	ctx := context.Background()
+	for _, arg := range fs.Args() {
+		m, err := lookupMovie(c, arg)
+		if err != nil {
+			return err
+		}
+		torrents := sizeFlt.Filter(m.Torrents)
+		if !*all {
+			torrents = nil
+			if t := pickTorrent(m, *quality); t != nil {
+				torrents = append(torrents, t)
+			}
+		}
+		if len(torrents) == 0 {
+			log.Printf("No matching torrent of %q (%v)", m.Title, m.Year)
+		}
+		for _, t := range torrents {
+			path, err := e.Export(ctx, m, t)
+			switch err {
+			case nil:
+				fmt.Println(path)
+			case watchfolder.ErrDuplicate:
+				log.Printf("Skipping %q (%v) [%s]: already exported", m.Title, m.Year, t.Quality)
+			default:
+				return fmt.Errorf("failed to export %q (%v) [%s]: %w", m.Title, m.Year, t.Quality, err)
+			}
+		}
+	}
+	return nil
+}
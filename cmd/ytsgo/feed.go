@@ -0,0 +1,73 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/qopher/ytsgo"
+	"github.com/qopher/ytsgo/feed"
+)
+
+// feedCmd writes an RSS or Atom feed of a movie list to stdout, or serves live
+// feeds if an address to listen on is given.
+func feedCmd(c *ytsgo.Client, fs *flag.FlagSet, args []string) error {
+	format := fs.String("format", "rss", "Feed format, rss or atom")
+	title := fs.String("title", "YTS movies", "Feed title")
+	link := fs.String("link", "", "Feed link")
+	query := fs.String("query", "", "Search term")
+	quality := fs.String("quality", "", "Wanted quality, eg. 1080p")
+	genre := fs.String("genre", "", "Wanted genre")
+	minRating := fs.Uint("min-rating", 0, "Minimal IMDb rating")
+	limit := fs.Uint("limit", 20, "Number of movies")
+	listen := fs.String("listen", "", "Serve live feeds on this address, eg. :8080")
+	ttl := fs.Duration("ttl", feed.DefaultTTL, "Time served feeds are cached")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	f, err := feed.ParseFormat(*format)
+	if err != nil {
+		return usageError(err.Error())
+	}
+	meta := feed.Meta{Title: *title, Link: *link, Description: "Movies from " + *ytsURL}
+	if *listen != "" {
+		// -format is the default of requests without the format parameter.
+		http.Handle("/", &feed.Handler{Client: c, Meta: meta, Format: f, TTL: *ttl})
+		return http.ListenAndServe(*listen, nil)
+	}
+	v := url.Values{}
+	set := func(k, val string) {
+		if val != "" {
+			v.Set(k, val)
+		}
+	}
+	set("query_term", *query)
+	set("quality", *quality)
+	set("genre", *genre)
+	if *minRating > 0 {
+		v.Set("minimum_rating", strconv.FormatUint(uint64(*minRating), 10))
+	}
+	v.Set("limit", strconv.FormatUint(uint64(*limit), 10))
+	opts, err := ytsgo.ListMoviesQuery(v)
+	if err != nil {
+		return usageError(err.Error())
+	}
+	mvs, err := c.ListMovies(opts...)
+	if err != nil {
+		return fmt.Errorf("failed to list movies: %w", err)
+	}
+	var movies []*ytsgo.Movie
+	if mvs != nil {
+		movies = mvs.Movies
+	}
+	meta.Updated = time.Now()
+	if err := feed.Write(os.Stdout, f, meta, movies); err != nil {
+		return fmt.Errorf("failed to write feed: %w", err)
+	}
+	return nil
+}
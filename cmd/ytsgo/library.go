@@ -0,0 +1,92 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/qopher/ytsgo"
+	"github.com/qopher/ytsgo/catalog"
+	"github.com/qopher/ytsgo/library"
+)
+
+// libraryCmd matches a local media library with YTS movies.
+func libraryCmd(c *ytsgo.Client, fs *flag.FlagSet, args []string) error {
+	owned := fs.Bool("owned", false, "List all owned movies, not only upgradeable ones")
+	unmatched := fs.Bool("unmatched", false, "List files not matching any movie")
+	wanted := fs.String("wanted", "", "Search term of wanted movies, eg. a title or IMDb code, to list if missing")
+	catalogPath := fs.String("catalog", "", "Catalog file of wanted movies to list if missing")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return usageError("want one library directory")
+	}
+	var want []*ytsgo.Movie
+	if *catalogPath != "" {
+		cat, err := catalog.LoadFile(*catalogPath)
+		if err != nil {
+			return fmt.Errorf("failed to load catalog %q: %w", *catalogPath, err)
+		}
+		want = append(want, cat.Movies()...)
+	}
+	if *wanted != "" {
+		mvs, err := searchAll(c, *wanted)
+		if err != nil {
+			return fmt.Errorf("failed to search wanted movies: %w", err)
+		}
+		want = append(want, mvs...)
+	}
+
+	files, err := library.Scan(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("failed to scan %q: %w", fs.Arg(0), err)
+	}
+	r, err := library.Match(c, files)
+	if err != nil {
+		return fmt.Errorf("failed to match files: %w", err)
+	}
+	for _, o := range r.Owned {
+		switch {
+		case o.Upgrade != nil:
+			fmt.Printf("%q (%v) [%s] can be upgraded to %s\n\tMagnet: %s\n", o.Movie.Title, o.Movie.Year, o.Quality, o.Upgrade.Quality, o.Upgrade.Magnet())
+		case *owned:
+			fmt.Printf("%q (%v) [%s]\n", o.Movie.Title, o.Movie.Year, o.Quality)
+		}
+	}
+	if *unmatched {
+		for _, f := range r.Unmatched {
+			fmt.Printf("Unmatched: %s\n", f.Path)
+		}
+	}
+	missing := r.Missing(want)
+	for _, m := range missing {
+		fmt.Printf("Missing: %q (%v)\n", m.Title, m.Year)
+		if t := ytsgo.BestTorrent(m.Torrents); t != nil {
+			fmt.Printf("\tMagnet: %s\n", t.Magnet())
+		}
+	}
+	fmt.Printf("%d files, %d movies owned, %d upgradeable, %d unmatched files", len(files), len(r.Owned), len(r.Upgradeable()), len(r.Unmatched))
+	if len(want) > 0 {
+		fmt.Printf(", %d of %d wanted movies missing", len(missing), len(want))
+	}
+	fmt.Println()
+	return nil
+}
+
+// searchAll returns all pages of movies matching the search term.
+func searchAll(c *ytsgo.Client, term string) ([]*ytsgo.Movie, error) {
+	var ret []*ytsgo.Movie
+	for p := uint(1); ; p++ {
+		mvs, err := c.ListMovies(ytsgo.LMSearch(term), ytsgo.LMLimit(50), ytsgo.LMPage(p))
+		if err != nil {
+			return nil, err
+		}
+		if mvs == nil || len(mvs.Movies) == 0 {
+			return ret, nil
+		}
+		ret = append(ret, mvs.Movies...)
+		if uint(len(ret)) >= mvs.MovieCount {
+			return ret, nil
+		}
+	}
+}
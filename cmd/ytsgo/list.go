@@ -0,0 +1,116 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/qopher/ytsgo"
+)
+
+// listCmd prints movies matching the search term and filters.
+func listCmd(c *ytsgo.Client, fs *flag.FlagSet, args []string) error {
+	limit := fs.Uint("limit", 0, "Number of movies per page, 1-50")
+	page := fs.Uint("page", 0, "Page of results")
+	quality := fs.String("quality", "", "Wanted quality, one of: "+strings.Join(ytsgo.Qualities, ", "))
+	minRating := fs.Uint("min-rating", 0, "Minimal IMDb rating, 0-9")
+	genre := fs.String("genre", "", "Wanted genre, eg. Sci-Fi")
+	sortBy := fs.String("sort-by", "", "Sort by one of: "+strings.Join(ytsgo.SortByValues, ", "))
+	order := fs.String("order", "", "Sort order, desc or asc")
+	all := fs.Bool("all", false, "Fetch all pages of results")
+	rtRatings := fs.Bool("with-rt-ratings", false, "Include Rotten Tomatoes ratings")
+	out := outputFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		return usageError("want at most one search term, quote it if it has spaces")
+	}
+
+	var opts []ytsgo.ListMoviesOption
+	if term := fs.Arg(0); term != "" {
+		opts = append(opts, ytsgo.LMSearch(term))
+	}
+	if *limit > 50 {
+		return usageError(fmt.Sprintf("invalid -limit %d, want 1-50", *limit))
+	}
+	if *all && *limit == 0 {
+		*limit = 50
+	}
+	if *limit > 0 {
+		opts = append(opts, ytsgo.LMLimit(*limit))
+	}
+	if *minRating > 9 {
+		return usageError(fmt.Sprintf("invalid -min-rating %d, want 0-9", *minRating))
+	}
+	if *minRating > 0 {
+		opts = append(opts, ytsgo.LMMinimumRating(*minRating))
+	}
+	strs := []struct {
+		flag  string
+		val   string
+		valid []string
+		opt   func(string) ytsgo.ListMoviesOption
+	}{
+		{flag: "quality", val: *quality, valid: ytsgo.Qualities, opt: ytsgo.LMQuality},
+		{flag: "genre", val: *genre, valid: ytsgo.Genres, opt: ytsgo.LMGenre},
+		{flag: "sort-by", val: *sortBy, valid: ytsgo.SortByValues, opt: ytsgo.LMSortBy},
+		{flag: "order", val: *order, valid: ytsgo.OrderByValues, opt: ytsgo.LMOrderBy},
+	}
+	for _, s := range strs {
+		if s.val == "" {
+			continue
+		}
+		v, err := oneOf(s.val, s.valid)
+		if err != nil {
+			return usageError(fmt.Sprintf("invalid -%s: %v", s.flag, err))
+		}
+		opts = append(opts, s.opt(v))
+	}
+	if *rtRatings {
+		opts = append(opts, ytsgo.LMWithRTRatings(true))
+	}
+
+	if !*all {
+		if *page > 0 {
+			opts = append(opts, ytsgo.LMPage(*page))
+		}
+		mvs, err := c.ListMovies(opts...)
+		if err != nil {
+			return fmt.Errorf("failed to list movies: %w", err)
+		}
+		if mvs == nil {
+			return nil
+		}
+		return out.print(mvs.Movies...)
+	}
+	var movies []*ytsgo.Movie
+	p := *page
+	if p == 0 {
+		p = 1
+	}
+	for ; ; p++ {
+		mvs, err := c.ListMovies(append(opts, ytsgo.LMPage(p))...)
+		if err != nil {
+			return fmt.Errorf("failed to list movies page %d: %w", p, err)
+		}
+		if mvs == nil || len(mvs.Movies) == 0 {
+			break
+		}
+		movies = append(movies, mvs.Movies...)
+		if uint(len(movies)) >= mvs.MovieCount || uint(len(mvs.Movies)) < *limit {
+			break
+		}
+	}
+	return out.print(movies...)
+}
+
+// oneOf returns the value from valid matching s ignoring case.
+func oneOf(s string, valid []string) (string, error) {
+	for _, v := range valid {
+		if strings.EqualFold(s, v) {
+			return v, nil
+		}
+	}
+	return "", fmt.Errorf("%q is not one of: %s", s, strings.Join(valid, ", "))
+}
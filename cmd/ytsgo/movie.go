@@ -0,0 +1,97 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/qopher/ytsgo"
+)
+
+// movieCmd prints details of a single movie.
+func movieCmd(c *ytsgo.Client, fs *flag.FlagSet, args []string) error {
+	cast := fs.Bool("cast", false, "Show cast with character names")
+	images := fs.Bool("images", false, "Show image URLs")
+	out := outputFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return usageError("want one movie ID, IMDb code or slug")
+	}
+	var opts []ytsgo.MovieOption
+	if *cast {
+		opts = append(opts, ytsgo.MovieWithCast(true))
+	}
+	if *images {
+		opts = append(opts, ytsgo.MovieWithImages(true))
+	}
+	m, err := lookupMovie(c, fs.Arg(0), opts...)
+	if err != nil {
+		return err
+	}
+	if err := out.print(m); err != nil || !out.text() {
+		return err
+	}
+	if *cast {
+		fmt.Println(castStr(m))
+	}
+	if *images {
+		fmt.Println(imagesStr(m))
+	}
+	return nil
+}
+
+// lookupMovie fetches the movie by numeric ID, IMDb code or slug.
+func lookupMovie(c *ytsgo.Client, arg string, opts ...ytsgo.MovieOption) (*ytsgo.Movie, error) {
+	var m *ytsgo.Movie
+	var err error
+	if id, perr := strconv.Atoi(arg); perr == nil {
+		m, err = c.Movie(id, opts...)
+	} else if strings.HasPrefix(arg, "tt") {
+		m, err = c.MovieByIMDBCode(arg, opts...)
+	} else {
+		m, err = c.MovieBySlug(arg, opts...)
+	}
+	// Unknown IDs are answered with an empty movie.
+	if err == nil && (m == nil || m.ID == 0) {
+		err = ytsgo.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch movie %v: %w", arg, err)
+	}
+	return m, nil
+}
+
+func castStr(m *ytsgo.Movie) string {
+	var lines []string
+	for _, c := range m.Cast {
+		if c == nil {
+			continue
+		}
+		line := "\t" + c.Name
+		if c.CharacterName != "" {
+			line += " as " + c.CharacterName
+		}
+		if c.URLSmallImage != nil && c.URLSmallImage.String() != "" {
+			line += "\n\t\t" + c.URLSmallImage.String()
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		return "Cast: unknown"
+	}
+	return "Cast:\n" + strings.Join(lines, "\n")
+}
+
+func imagesStr(m *ytsgo.Movie) string {
+	var lines []string
+	for _, u := range m.ImageURLs() {
+		lines = append(lines, "\t"+u.String())
+	}
+	if len(lines) == 0 {
+		return "Images: none"
+	}
+	return "Images:\n" + strings.Join(lines, "\n")
+}
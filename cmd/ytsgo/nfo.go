@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"path/filepath"
+
+	"github.com/qopher/ytsgo"
+	"github.com/qopher/ytsgo/nfo"
+)
+
+// nfoCmd writes movie.nfo files and artwork of the movies for Kodi and Jellyfin.
+func nfoCmd(c *ytsgo.Client, fs *flag.FlagSet, args []string) error {
+	root := fs.String("dir", ".", "Library directory, files are written to its \"Title (Year)\" subdirectory")
+	noArtwork := fs.Bool("no-artwork", false, "Do not download poster and fanart")
+	overwrite := fs.Bool("overwrite", false, "Replace existing files")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return usageError("want at least one movie ID, IMDb code or slug")
+	}
+
+	g := &nfo.Generator{NoArtwork: *noArtwork, Overwrite: *overwrite}
+	for _, arg := range fs.Args() {
+		m, err := lookupMovie(c, arg, ytsgo.MovieWithCast(true), ytsgo.MovieWithImages(true))
+		if err != nil {
+			return err
+		}
+		written, err := g.Generate(context.Background(), filepath.Join(*root, nfo.DirName(m)), m)
+		for _, p := range written {
+			fmt.Println(p)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to generate files of %q (%v): %w", m.Title, m.Year, err)
+		}
+	}
+	return nil
+}
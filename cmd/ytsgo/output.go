@@ -0,0 +1,108 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/qopher/ytsgo"
+	"github.com/qopher/ytsgo/export"
+)
+
+// output controls how the movie and list commands print movies.
+type output struct {
+	format       *string
+	columns      *string
+	template     *string
+	templateFile *string
+}
+
+func outputFlags(fs *flag.FlagSet) *output {
+	return &output{
+		format:       fs.String("format", "text", "Output format: text, csv, ndjson, markdown or html"),
+		columns:      fs.String("columns", "", "Comma separated columns of csv, markdown and html output, one of: "+strings.Join(export.ColumnNames, ", ")),
+		template:     fs.String("template", "", "Go template executed for each movie, or one of bundled templates: "+strings.Join(templateNames(), ", ")),
+		templateFile: fs.String("template-file", "", "File with Go template executed for each movie"),
+	}
+}
+
+func (o *output) print(movies ...*ytsgo.Movie) error {
+	// Filter torrents by -size in all formats.
+	filtered := make([]*ytsgo.Movie, 0, len(movies))
+	for _, m := range movies {
+		if m == nil {
+			continue
+		}
+		cp := *m
+		cp.Torrents = sizeFlt.Filter(m.Torrents)
+		filtered = append(filtered, &cp)
+	}
+	if *o.template != "" || *o.templateFile != "" {
+		return o.execute(filtered)
+	}
+	if *o.format == "text" {
+		for _, m := range filtered {
+			fmt.Println(movieStr(m))
+		}
+		return nil
+	}
+	f, err := export.ParseFormat(*o.format)
+	if err != nil {
+		return usageError(err.Error())
+	}
+	cols, err := export.ParseColumns(*o.columns)
+	if err != nil {
+		return usageError(err.Error())
+	}
+	if err := export.Write(os.Stdout, f, cols, filtered); err != nil {
+		return fmt.Errorf("failed to write movies: %w", err)
+	}
+	return nil
+}
+
+// text reports whether movies are printed in the default text format.
+func (o *output) text() bool {
+	return *o.format == "text" && *o.template == "" && *o.templateFile == ""
+}
+
+// execute prints movies using -template or -template-file.
+func (o *output) execute(movies []*ytsgo.Movie) error {
+	tmpl, err := parseTemplate(*o.template, *o.templateFile)
+	if _, ok := err.(usageError); ok {
+		return err
+	}
+	if err != nil {
+		return usageError("failed to parse template: " + err.Error())
+	}
+	for _, m := range movies {
+		var b strings.Builder
+		if err := tmpl.Execute(&b, m); err != nil {
+			return fmt.Errorf("failed to execute template: %w", err)
+		}
+		s := b.String()
+		if s != "" && !strings.HasSuffix(s, "\n") {
+			s += "\n"
+		}
+		fmt.Print(s)
+	}
+	return nil
+}
+
+// movieStr formats the movie and its torrents, which are already filtered by
+// print.
+func movieStr(m *ytsgo.Movie) string {
+	ret := fmt.Sprintf("%q (%v)\n", m.Title, m.Year)
+	var trts []string
+	torrents := append([]*ytsgo.Torrent(nil), m.Torrents...)
+	sort.Sort(sort.Reverse(ytsgo.TorrentsBySize(torrents)))
+	for _, t := range torrents {
+		size := t.Size
+		if b := t.Bytes(); b > 0 {
+			size = b.Format(sizeUnits)
+		}
+		trts = append(trts, fmt.Sprintf("\tSeeds: %v Peers: %v Size: %v\n\tMagnet: %s", t.Seeds, t.Peers, size, t.Magnet()))
+	}
+	return ret + strings.Join(trts, "\n")
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"github.com/qopher/ytsgo"
+	"github.com/qopher/ytsgo/proxy"
+)
+
+// serve runs a caching proxy of the API.
+func serve(c *ytsgo.Client, fs *flag.FlagSet, args []string) error {
+	listen := fs.String("listen", ":8080", "Address to listen on")
+	ttl := fs.Duration("ttl", proxy.DefaultTTL, "Time responses are cached")
+	maxEntries := fs.Int("max-entries", proxy.DefaultMaxEntries, "Maximal number of cached responses")
+	rate := fs.Float64("rate", 0, "Maximal number of upstream requests per second, 0 means no limit")
+	burst := fs.Int("burst", 1, "Number of upstream requests allowed at once")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	s := &proxy.Server{Backend: c, TTL: *ttl, MaxEntries: *maxEntries, RateLimit: *rate, Burst: *burst}
+	http.Handle("/api/v2/", s)
+	log.Printf("Serving API proxy of %s on %s/api/v2/", c.Mirror(), *listen)
+	return http.ListenAndServe(*listen, nil)
+}
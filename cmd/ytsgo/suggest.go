@@ -0,0 +1,28 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/qopher/ytsgo"
+)
+
+// suggestCmd prints movies related to the given one.
+func suggestCmd(c *ytsgo.Client, fs *flag.FlagSet, args []string) error {
+	out := outputFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return usageError("want one movie ID, IMDb code or slug")
+	}
+	m, err := lookupMovie(c, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	mvs, err := c.Suggestions(int(m.ID))
+	if err != nil {
+		return fmt.Errorf("failed to fetch suggestions for movie id:%v: %w", m.ID, err)
+	}
+	return out.print(mvs...)
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/qopher/ytsgo"
+)
+
+// templates are the bundled named templates, each executed once per movie.
+var templates = map[string]string{
+	"short": `{{.Title}} ({{.Year}}) {{stars .Rating}} {{.Rating}}/10 [{{range $i, $t := .Torrents}}{{if $i}}, {{end}}{{$t.Quality}}{{end}}]`,
+	"full": `{{.Title}} ({{.Year}}) {{stars .Rating}} {{.Rating}}/10
+	ID: {{.ID}} IMDb: {{.IMDBCode}} Runtime: {{.Runtime}} min
+	Genres: {{join ", " .Genres}}
+	{{truncate 200 .DescriptionFull}}
+{{range .Torrents}}	{{.Quality}} {{.Type}} {{humanSize .}} Seeds: {{.Seeds}} Peers: {{.Peers}}
+	Magnet: {{magnet .}}
+{{end}}`,
+	"magnets-only": `{{range .Torrents}}{{magnet .}}
+{{end}}`,
+}
+
+func templateNames() []string {
+	var ret []string
+	for n := range templates {
+		ret = append(ret, n)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+var templateFuncs = template.FuncMap{
+	"magnet":    func(t *ytsgo.Torrent) string { return t.Magnet() },
+	"humanSize": humanSize,
+	"join":      func(sep string, elems []string) string { return strings.Join(elems, sep) },
+	"stars":     stars,
+	"truncate":  truncate,
+}
+
+// parseTemplate returns the bundled template with given name, or parses text
+// as a template. The template is read from file if it's not empty. Setting
+// both text and file is a usage error.
+func parseTemplate(text, file string) (*template.Template, error) {
+	if text != "" && file != "" {
+		return nil, usageError("want either -template or -template-file, not both")
+	}
+	if file != "" {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		text = string(b)
+	} else if t, ok := templates[text]; ok {
+		text = t
+	}
+	return template.New("movie").Funcs(templateFuncs).Parse(text)
+}
+
+// humanSize formats a size of a torrent, ytsgo.ByteSize or a number of bytes
+// using -size_units.
+func humanSize(v interface{}) (string, error) {
+	var b ytsgo.ByteSize
+	switch v := v.(type) {
+	case *ytsgo.Torrent:
+		if b = v.Bytes(); b == 0 {
+			return v.Size, nil
+		}
+	case ytsgo.ByteSize:
+		b = v
+	case uint:
+		b = ytsgo.ByteSize(v)
+	case int:
+		b = ytsgo.ByteSize(v)
+	case uint64:
+		b = ytsgo.ByteSize(v)
+	case int64:
+		b = ytsgo.ByteSize(v)
+	default:
+		return "", fmt.Errorf("humanSize: unsupported type %T", v)
+	}
+	return b.Format(sizeUnits), nil
+}
+
+// stars returns IMDb rating out of 10 as five stars rounded to the nearest
+// one.
+func stars(rating float32) string {
+	n := int(math.Round(float64(rating) / 2))
+	if n < 0 {
+		n = 0
+	}
+	if n > 5 {
+		n = 5
+	}
+	return strings.Repeat("★", n) + strings.Repeat("☆", 5-n)
+}
+
+// truncate shortens s to at most n characters, ending it with an ellipsis if
+// it was cut.
+func truncate(n int, s string) string {
+	r := []rune(s)
+	if n <= 0 || len(r) <= n {
+		return s
+	}
+	return strings.TrimSpace(string(r[:n-1])) + "…"
+}
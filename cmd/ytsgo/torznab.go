@@ -0,0 +1,23 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"github.com/qopher/ytsgo"
+	"github.com/qopher/ytsgo/torznab"
+)
+
+// torznabCmd serves a Torznab indexer, eg. for Radarr.
+func torznabCmd(c *ytsgo.Client, fs *flag.FlagSet, args []string) error {
+	listen := fs.String("listen", ":9117", "Address to listen on")
+	apiKey := fs.String("apikey", "", "API key required from clients")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	http.Handle("/api", &torznab.Server{Client: c, APIKey: *apiKey})
+	log.Printf("Serving Torznab API on %s/api", *listen)
+	return http.ListenAndServe(*listen, nil)
+}
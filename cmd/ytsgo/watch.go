@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/qopher/ytsgo"
+	"github.com/qopher/ytsgo/poller"
+	"github.com/qopher/ytsgo/webhook"
+)
+
+// watch polls for new releases and delivers them to webhooks until interrupted.
+func watch(c *ytsgo.Client, fs *flag.FlagSet, args []string) error {
+	state := fs.String("state", "ytsgo-watch.json", "File with state persisted between runs")
+	hooks := fs.String("webhook", "", "Comma separated list of webhook URLs")
+	secret := fs.String("secret", "", "Secret used to sign webhook payloads with HMAC-SHA256")
+	retries := fs.Int("retries", webhook.DefaultRetries, "Number of webhook delivery retries, 0 disables retries")
+	interval := fs.Duration("interval", 15*time.Minute, "Polling interval")
+	genres := fs.String("genre", "", "Comma separated list of wanted genres")
+	minRating := fs.Float64("min-rating", 0, "Minimal IMDb rating")
+	qualities := fs.String("quality", "", "Comma separated list of wanted qualities, eg. 1080p,2160p")
+	languages := fs.String("language", "", "Comma separated list of wanted languages")
+	backfill := fs.Bool("backfill", false, "Deliver releases found on the first run")
+	once := fs.Bool("once", false, "Poll once and exit")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	p := &poller.Poller{
+		Client: c,
+		Rules: poller.Rules{
+			Genres:    splitList(*genres),
+			MinRating: float32(*minRating),
+			Qualities: splitList(*qualities),
+			Languages: splitList(*languages),
+		},
+		StatePath: *state,
+		Backfill:  *backfill,
+	}
+	for _, u := range splitList(*hooks) {
+		p.Senders = append(p.Senders, &webhook.Sender{URL: u, Secret: []byte(*secret), Retries: *retries})
+	}
+	var err error
+	if p.State, err = poller.LoadState(*state); err != nil {
+		return fmt.Errorf("failed to load state from %q: %w", *state, err)
+	}
+	onRelease := func(r *poller.Release) {
+		var qs []string
+		for _, t := range r.Torrents {
+			qs = append(qs, t.Quality)
+		}
+		fmt.Printf("%q (%v) [%s]\n", r.Movie.Title, r.Movie.Year, strings.Join(qs, ", "))
+	}
+	if *once {
+		rs, err := p.Poll(context.Background())
+		for _, r := range rs {
+			onRelease(r)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to poll: %w", err)
+		}
+		return nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt)
+	go func() {
+		<-sig
+		cancel()
+	}()
+	err = p.Run(ctx, *interval, onRelease, func(err error) {
+		log.Printf("Poll failed: %v", err)
+	})
+	if errors.Is(err, context.Canceled) {
+		// Interrupted.
+		return nil
+	}
+	return err
+}
+
+func splitList(s string) []string {
+	var ret []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			ret = append(ret, v)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/qopher/ytsgo"
+)
+
+func TestWatchOnceNetworkError(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+	c, err := ytsgo.New(ytsgo.BaseURL("http://" + addr + "/api/v2/"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := filepath.Join(t.TempDir(), "state.json")
+	if got := findCommand("watch").exec(c, []string{"-once", "-state", state}, ioutil.Discard); got != exitNetwork {
+		t.Errorf("watch -once exit code = %d want %d", got, exitNetwork)
+	}
+}
@@ -3,66 +3,60 @@ package main
 import (
 	"flag"
 	"fmt"
-	"log"
-	"sort"
-	"strconv"
-	"strings"
+	"os"
 
 	"github.com/qopher/ytsgo"
 )
 
 var (
-	ytsURL = flag.String("yts_url", ytsgo.DefaultBaseURL, "Base URL of yts.lt API")
+	ytsURL         = flag.String("yts_url", ytsgo.DefaultBaseURL, "Base URL of yts.lt API")
+	mirrors        = flag.String("mirrors", "", "Comma separated list of API mirrors used if yts_url fails")
+	timeout        = flag.Duration("timeout", ytsgo.DefaultTimeout, "Timeout of API requests")
+	userAgent      = flag.String("user_agent", "", "User agent sent to the API")
+	trackers       = flag.String("trackers", "", "Comma separated list of trackers used in magnet links instead of the default ones")
+	defaultQuality = flag.String("default_quality", "", "Quality picked by add and export if -quality is not set, the best one if empty")
+	config         = flag.String("config", "", "Config file, defaults to $YTSGO_CONFIG or ytsgo/config in the user config directory")
+	sizeFlt        ytsgo.SizeFilter
+	sizeUnits      ytsgo.SizeUnits
 )
 
 func main() {
+	flag.Var(&sizeFlt, "size", `Show only torrents of matching size, eg. "max 2 GB" or "min 700 MB, max 2 GB"`)
+	flag.Var(&sizeUnits, "size_units", "Units used to show torrent sizes (binary, decimal or iec)")
+	flag.Usage = func() { usage(os.Stderr) }
 	flag.Parse()
-	c, err := ytsgo.New(ytsgo.BaseURL(*ytsURL))
-	if err != nil {
-		log.Fatalf("Failed to create ytsgo client: %v", err)
+	if err := configure(); err != nil {
+		fmt.Fprintf(os.Stderr, "ytsgo: %v\n", err)
+		os.Exit(exitUsage)
 	}
-	if len(flag.CommandLine.Args()) != 2 {
-		usage()
-		return
+	args := flag.Args()
+	if len(args) == 0 {
+		usage(os.Stderr)
+		os.Exit(exitUsage)
 	}
-	switch flag.CommandLine.Arg(0) {
-	case "movie":
-		id, err := strconv.Atoi(flag.CommandLine.Arg(1))
-		if err != nil {
-			log.Fatalf("Failed to parse movie ID: %v", err)
-		}
-		m, err := c.Movie(id)
-		if err != nil {
-			log.Fatalf("Failed to fetch movie id:%v :%v", id, err)
-		}
-		fmt.Println(movieStr(m))
-	case "list":
-		mvs, err := c.ListMovies(ytsgo.LMSearch(flag.CommandLine.Arg(1)))
-		if err != nil {
-			log.Fatalf("Failed to search movies %q :%v", flag.CommandLine.Arg(1), err)
-		}
-		for _, m := range mvs.Movies {
-			fmt.Println(movieStr(m))
-		}
-	default:
-		usage()
-		return
+	if args[0] == "help" {
+		os.Exit(help(args[1:]))
 	}
-}
-
-func usage() {
-	fmt.Printf(`Usage:
-ytsgo movie [id]
-ytsgo list "search term"
-`)
-}
-
-func movieStr(m *ytsgo.Movie) string {
-	ret := fmt.Sprintf("%q (%v)\n", m.Title, m.Year)
-	var trts []string
-	sort.Sort(sort.Reverse(ytsgo.TorrentsBySize(m.Torrents)))
-	for _, t := range m.Torrents {
-		trts = append(trts, fmt.Sprintf("\tSeeds: %v Peers: %v Size: %v\n\tMagnet: %s", t.Seeds, t.Peers, t.Size, t.Magnet()))
+	cmd := findCommand(args[0])
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "ytsgo: unknown command %q\nRun 'ytsgo help' for usage.\n", args[0])
+		os.Exit(exitUsage)
+	}
+	if t := splitList(*trackers); len(t) > 0 {
+		ytsgo.DefaultTackers = t
+	}
+	opts := []ytsgo.ClientOption{
+		ytsgo.BaseURL(*ytsURL),
+		ytsgo.Mirrors(splitList(*mirrors)...),
+		ytsgo.HTTPTimeout(*timeout),
+	}
+	if *userAgent != "" {
+		opts = append(opts, ytsgo.UserAgent(*userAgent))
+	}
+	c, err := ytsgo.New(opts...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ytsgo: failed to create client: %v\n", err)
+		os.Exit(exitUsage)
 	}
-	return ret + strings.Join(trts, "\n")
+	os.Exit(cmd.exec(c, args[1:], os.Stderr))
 }
@@ -0,0 +1,79 @@
+package ytsgo
+
+// File coalesce.go contains deduplication of concurrent identical requests.
+
+import (
+	"errors"
+	"sync"
+)
+
+// errFlightPanicked is returned to callers waiting for a request which panicked.
+var errFlightPanicked = errors.New("coalesced request panicked")
+
+// CoalesceStats contains counters of coalesced requests.
+type CoalesceStats struct {
+	// Requests is a number of HTTP requests sent to the server.
+	Requests uint64
+	// Coalesced is a number of calls which shared a result of an in-flight request.
+	Coalesced uint64
+}
+
+// CoalesceStats returns counters of coalesced requests. All counters are zero
+// unless CoalesceRequests option is enabled.
+func (c *Client) CoalesceStats() CoalesceStats {
+	if c.flights == nil {
+		return CoalesceStats{}
+	}
+	c.flights.mu.Lock()
+	defer c.flights.mu.Unlock()
+	return c.flights.stats
+}
+
+// flight is a single in-flight request shared by all callers of the same key.
+type flight struct {
+	wg  sync.WaitGroup
+	res *result
+	err error
+}
+
+// flightGroup deduplicates concurrent calls with the same key.
+type flightGroup struct {
+	mu      sync.Mutex
+	flights map[string]*flight
+	stats   CoalesceStats
+}
+
+// do executes fn unless there is already an in-flight call for the key, in
+// which case it waits for that call and returns its result.
+func (g *flightGroup) do(key string, fn func() (*result, error)) (*result, error) {
+	g.mu.Lock()
+	if g.flights == nil {
+		g.flights = make(map[string]*flight)
+	}
+	if f, ok := g.flights[key]; ok {
+		g.stats.Coalesced++
+		g.mu.Unlock()
+		f.wg.Wait()
+		return f.res, f.err
+	}
+	f := &flight{}
+	f.wg.Add(1)
+	g.flights[key] = f
+	g.stats.Requests++
+	g.mu.Unlock()
+
+	done := false
+	defer func() {
+		if !done {
+			// fn panicked, waiters get an error while the panic propagates.
+			f.res, f.err = nil, errFlightPanicked
+		}
+		g.mu.Lock()
+		delete(g.flights, key)
+		g.mu.Unlock()
+		f.wg.Done()
+	}()
+	f.res, f.err = fn()
+	done = true
+	return f.res, f.err
+}
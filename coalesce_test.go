@@ -0,0 +1,135 @@
+package ytsgo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCoalesceRequests(t *testing.T) {
+	const callers = 10
+	data := loadTestData("matrix.json", t)
+	var hits int32
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}))
+	defer ts.Close()
+	c, err := New(BaseURL(ts.URL), HTTPTimeout(time.Second*5), CoalesceRequests(true))
+	if err != nil {
+		t.Fatalf("Failed to connect to test server: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	movies := make([]*Movie, callers)
+	errs := make([]error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			movies[i], errs[i] = c.Movie(1)
+		}(i)
+	}
+	deadline := time.Now().Add(time.Second * 5)
+	for c.CoalesceStats().Coalesced < callers-1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for calls to coalesce, stats: %+v", c.CoalesceStats())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	for i := 0; i < callers; i++ {
+		if errs[i] != nil {
+			t.Errorf("Unexpected error for caller %d: %v", i, errs[i])
+			continue
+		}
+		if got, want := movies[i].Title, "The Matrix"; got != want {
+			t.Errorf("Unexpected title for caller %d, got %q want %q", i, got, want)
+		}
+	}
+	if got, want := atomic.LoadInt32(&hits), int32(1); got != want {
+		t.Errorf("Unexpected number of server hits, got %d want %d", got, want)
+	}
+	want := CoalesceStats{Requests: 1, Coalesced: callers - 1}
+	if got := c.CoalesceStats(); got != want {
+		t.Errorf("Unexpected stats, got %+v want %+v", got, want)
+	}
+
+	// Different URLs are not coalesced.
+	if _, err := c.Movie(2); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got, want := c.CoalesceStats().Requests, uint64(2); got != want {
+		t.Errorf("Unexpected number of requests, got %d want %d", got, want)
+	}
+}
+
+func TestCoalescePanic(t *testing.T) {
+	g := &flightGroup{}
+	release := make(chan struct{})
+	panicked := make(chan interface{})
+	go func() {
+		defer func() { panicked <- recover() }()
+		g.do("key", func() (*result, error) {
+			<-release
+			panic("boom")
+		})
+	}()
+	waiter := make(chan error)
+	go func() {
+		deadline := time.Now().Add(time.Second * 5)
+		for {
+			g.mu.Lock()
+			_, ok := g.flights["key"]
+			g.mu.Unlock()
+			if ok || time.Now().After(deadline) {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+		_, err := g.do("key", func() (*result, error) {
+			t.Error("Waiter ran its own request")
+			return nil, nil
+		})
+		waiter <- err
+	}()
+	deadline := time.Now().Add(time.Second * 5)
+	for {
+		g.mu.Lock()
+		coalesced := g.stats.Coalesced
+		g.mu.Unlock()
+		if coalesced == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for calls to coalesce")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	if got := <-panicked; got != "boom" {
+		t.Errorf("Unexpected panic, got %v want boom", got)
+	}
+	select {
+	case err := <-waiter:
+		if err != errFlightPanicked {
+			t.Errorf("Unexpected waiter error, got %v want %v", err, errFlightPanicked)
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("Waiter blocked after the request panicked")
+	}
+	g.mu.Lock()
+	n := len(g.flights)
+	g.mu.Unlock()
+	if n != 0 {
+		t.Errorf("Key of the panicked request is still in flight")
+	}
+}
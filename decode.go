@@ -0,0 +1,152 @@
+package ytsgo
+
+// File decode.go contains helpers used to decode movies in strict and lenient mode.
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// decoder decodes fields of a movie. In strict mode values are decoded as-is
+// and type mismatches are errors. In lenient mode mismatched values are coerced
+// and a warning is recorded for every coercion.
+type decoder struct {
+	lenient bool
+	// prefix is prepended to field names in warnings.
+	prefix string
+	// root collects warnings of nested decoders, nil for the root decoder.
+	root     *decoder
+	warnings []string
+}
+
+// nested returns a decoder for an object stored in the field.
+func (d *decoder) nested(field string) *decoder {
+	root := d
+	if d.root != nil {
+		root = d.root
+	}
+	return &decoder{
+		lenient: d.lenient,
+		prefix:  d.prefix + field + ".",
+		root:    root,
+	}
+}
+
+func (d *decoder) warnf(field, format string, args ...interface{}) {
+	w := d.prefix + field + ": " + fmt.Sprintf(format, args...)
+	if d.root != nil {
+		d.root.warnings = append(d.root.warnings, w)
+		return
+	}
+	d.warnings = append(d.warnings, w)
+}
+
+// number decodes raw into dest which must be *uint or *float32.
+// Numeric strings and fractional values are accepted in lenient mode.
+func (d *decoder) number(field string, raw json.RawMessage, dest interface{}) error {
+	if len(raw) == 0 {
+		return nil
+	}
+	err := json.Unmarshal(raw, dest)
+	if err == nil || !d.lenient {
+		return err
+	}
+	str := string(raw)
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		str = strings.TrimSpace(s)
+		if str == "" {
+			d.warnf(field, "empty string, using 0")
+			setNumber(dest, 0)
+			return nil
+		}
+	}
+	f, err := strconv.ParseFloat(str, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		d.warnf(field, "invalid number %s, using 0", raw)
+		setNumber(dest, 0)
+		return nil
+	}
+	if _, ok := dest.(*uint); ok && (f < 0 || f != math.Trunc(f) || f > math.MaxUint32) {
+		d.warnf(field, "%s is not a valid unsigned integer, using 0", raw)
+		setNumber(dest, 0)
+		return nil
+	}
+	d.warnf(field, "converted %s to number", raw)
+	setNumber(dest, f)
+	return nil
+}
+
+func setNumber(dest interface{}, f float64) {
+	switch v := dest.(type) {
+	case *uint:
+		*v = uint(f)
+	case *float32:
+		*v = float32(f)
+	}
+}
+
+// url parses str into dest, str is nil if the field was not present. In
+// lenient mode a missing or empty str results in a nil URL and an unparsable
+// str is ignored.
+func (d *decoder) url(field string, dest **url.URL, str *string) error {
+	if !d.lenient {
+		s := ""
+		if str != nil {
+			s = *str
+		}
+		return parseURL(dest, s)
+	}
+	if str == nil {
+		*dest = nil
+		return nil
+	}
+	if strings.TrimSpace(*str) == "" {
+		d.warnf(field, "empty URL")
+		*dest = nil
+		return nil
+	}
+	if err := parseURL(dest, *str); err != nil {
+		d.warnf(field, "invalid URL %q: %v", *str, err)
+		*dest = nil
+	}
+	return nil
+}
+
+// array splits raw JSON array into elements. In lenient mode null and
+// non-array values result in an empty list.
+func (d *decoder) array(field string, raw json.RawMessage) ([]json.RawMessage, error) {
+	var elems []json.RawMessage
+	err := json.Unmarshal(raw, &elems)
+	if !d.lenient {
+		return elems, err
+	}
+	if err != nil {
+		d.warnf(field, "expected array, got %s, using empty list", raw)
+		return []json.RawMessage{}, nil
+	}
+	if elems == nil {
+		d.warnf(field, "null, using empty list")
+		return []json.RawMessage{}, nil
+	}
+	return elems, nil
+}
+
+// skipNull reports whether the null element stored in the field should be
+// dropped, which only happens in lenient mode.
+func (d *decoder) skipNull(field string, raw json.RawMessage) bool {
+	if !d.lenient || !isNull(raw) {
+		return false
+	}
+	d.warnf(field, "null, skipping")
+	return true
+}
+
+func isNull(raw json.RawMessage) bool {
+	return bytes.Equal(bytes.TrimSpace(raw), []byte("null"))
+}
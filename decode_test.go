@@ -0,0 +1,143 @@
+package ytsgo
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLenientDecoding(t *testing.T) {
+	testData := []struct {
+		desc         string
+		data         string
+		want         *Movie
+		wantWarnings []string
+		// wantStrictErr is true if the data fails to decode in strict mode.
+		wantStrictErr bool
+	}{
+		{
+			desc: "numeric strings",
+			data: `{"year": "2010", "rating": " 6.5 ", "runtime": "91"}`,
+			want: &Movie{
+				Year:    2010,
+				Rating:  6.5,
+				Runtime: 91,
+			},
+			wantWarnings: []string{
+				`year: converted "2010" to number`,
+				`rating: converted " 6.5 " to number`,
+				`runtime: converted "91" to number`,
+			},
+			wantStrictErr: true,
+		},
+		{
+			desc: "invalid numbers",
+			data: `{"year": "unknown", "rating": "", "runtime": -5, "torrents": [{"seeds": "12", "size_bytes": 1.5}]}`,
+			want: &Movie{
+				Torrents: []*Torrent{{
+					URL:          nil,
+					Seeds:        12,
+					DateUploaded: time.Unix(0, 0),
+				}},
+			},
+			wantWarnings: []string{
+				`year: invalid number "unknown", using 0`,
+				`rating: empty string, using 0`,
+				`runtime: -5 is not a valid unsigned integer, using 0`,
+				`torrents[0].seeds: converted "12" to number`,
+				`torrents[0].size_bytes: 1.5 is not a valid unsigned integer, using 0`,
+			},
+			wantStrictErr: true,
+		},
+		{
+			desc: "nulls and empty URLs",
+			data: `{"url": "", "torrents": null, "cast": [null, {"name": "A", "url_small_image": ""}]}`,
+			want: &Movie{
+				Torrents: []*Torrent{},
+				Cast:     []*Cast{{Name: "A"}},
+			},
+			wantWarnings: []string{
+				`url: empty URL`,
+				`torrents: null, using empty list`,
+				`cast[0]: null, skipping`,
+				`cast[1].url_small_image: empty URL`,
+			},
+		},
+		{
+			desc: "torrents not an array",
+			data: `{"url": "https://yts.lt/movie/13-2010", "torrents": {}}`,
+			want: &Movie{
+				Torrents: []*Torrent{},
+			},
+			wantWarnings: []string{
+				`torrents: expected array, got {}, using empty list`,
+			},
+			wantStrictErr: true,
+		},
+	}
+	ignoreURLs := cmp.FilterPath(func(p cmp.Path) bool {
+		return p.Last().Type() == reflect.TypeOf(&url.URL{})
+	}, cmp.Ignore())
+	for _, tc := range testData {
+		t.Run(tc.desc, func(t *testing.T) {
+			m := &Movie{}
+			if err := m.unmarshal([]byte(tc.data), &decoder{}); (err != nil) != tc.wantStrictErr {
+				t.Errorf("Unexpected error in strict mode, got %v want %v", err, tc.wantStrictErr)
+			}
+			m = &Movie{}
+			if err := m.unmarshal([]byte(tc.data), &decoder{lenient: true}); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.wantWarnings, m.Warnings); diff != "" {
+				t.Errorf("Unexpected warnings, diff -want +got\n%s", diff)
+			}
+			tc.want.Warnings = tc.wantWarnings
+			tc.want.DateUploaded = time.Unix(0, 0)
+			if diff := cmp.Diff(tc.want, m, cmp.AllowUnexported(Torrent{}), ignoreURLs); diff != "" {
+				t.Errorf("Unexpected movie, diff -want +got\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestClientLenientDecoding(t *testing.T) {
+	f := &fakeYTSServer{
+		data: loadTestData("inconsistent.json", t),
+	}
+	ts := httptest.NewServer(f)
+	defer ts.Close()
+	c, err := New(BaseURL(ts.URL), HTTPTimeout(time.Second*5))
+	if err != nil {
+		t.Fatalf("Failed to connect to test server: %v", err)
+	}
+	if _, err := c.Movie(10); err == nil {
+		t.Error("Expected an error without lenient decoding")
+	}
+	c, err = New(BaseURL(ts.URL), HTTPTimeout(time.Second*5), LenientDecoding(true))
+	if err != nil {
+		t.Fatalf("Failed to connect to test server: %v", err)
+	}
+	m, err := c.Movie(10)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if m.Year != 2010 || m.Rating != 6.1 || m.Runtime != 91 {
+		t.Errorf("Unexpected values, got year %v rating %v runtime %v", m.Year, m.Rating, m.Runtime)
+	}
+	if m.BackgroundImage != nil || m.BackgroundImageOriginal != nil {
+		t.Errorf("Expected empty images to be nil, got %v and %v", m.BackgroundImage, m.BackgroundImageOriginal)
+	}
+	if m.Torrents == nil || len(m.Torrents) != 0 {
+		t.Errorf("Expected empty torrents, got %v", m.Torrents)
+	}
+	if len(m.Cast) != 1 || m.Cast[0].URLSmallImage != nil {
+		t.Errorf("Unexpected cast: %+v", m.Cast)
+	}
+	if got, want := len(m.Warnings), 7; got != want {
+		t.Errorf("Unexpected number of warnings, got %d want %d: %q", got, want, m.Warnings)
+	}
+}
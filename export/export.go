@@ -0,0 +1,302 @@
+// Package export writes YTS.LT movie lists as CSV, newline-delimited JSON,
+// Markdown and HTML tables.
+//
+// Table formats are made of columns. Movie columns produce one row per movie,
+// while selecting any torrent column flattens the table to one row per
+// torrent with the movie fields repeated.
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/qopher/ytsgo"
+	"github.com/qopher/ytsgo/internal/urlutil"
+)
+
+// Format is an export format.
+type Format string
+
+const (
+	// CSV is comma separated values with a header row.
+	CSV Format = "csv"
+	// NDJSON is one movie per line encoded as in the YTS.LT API. Columns are
+	// ignored.
+	NDJSON Format = "ndjson"
+	// Markdown is a GitHub flavored Markdown table.
+	Markdown Format = "markdown"
+	// HTML is an HTML table.
+	HTML Format = "html"
+)
+
+// Formats lists all supported formats.
+var Formats = []Format{CSV, NDJSON, Markdown, HTML}
+
+// ParseFormat returns the format with given name. "md" and "jsonl" are
+// accepted as aliases.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case CSV, NDJSON, Markdown, HTML:
+		return f, nil
+	case "md":
+		return Markdown, nil
+	case "jsonl":
+		return NDJSON, nil
+	}
+	return "", fmt.Errorf("unknown export format %q, want csv, ndjson, markdown or html", s)
+}
+
+// Column is a single column of a table.
+type Column struct {
+	// Name is used in the header row.
+	Name string
+	// Torrent is set for columns showing torrent fields.
+	Torrent bool
+	// Value returns the cell of the column. t is nil for movie rows.
+	Value func(m *ytsgo.Movie, t *ytsgo.Torrent) string
+}
+
+func movieColumn(name string, f func(m *ytsgo.Movie) string) Column {
+	return Column{Name: name, Value: func(m *ytsgo.Movie, _ *ytsgo.Torrent) string { return f(m) }}
+}
+
+func torrentColumn(name string, f func(t *ytsgo.Torrent) string) Column {
+	return Column{Name: name, Torrent: true, Value: func(_ *ytsgo.Movie, t *ytsgo.Torrent) string {
+		if t == nil {
+			return ""
+		}
+		return f(t)
+	}}
+}
+
+func uitoa(n uint) string {
+	return strconv.FormatUint(uint64(n), 10)
+}
+
+func date(t time.Time, unix int64) string {
+	if unix == 0 {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// columns are all the known columns by name.
+var columns = map[string]Column{}
+
+// ColumnNames lists names of all known columns.
+var ColumnNames []string
+
+func init() {
+	for _, c := range []Column{
+		movieColumn("id", func(m *ytsgo.Movie) string { return uitoa(m.ID) }),
+		movieColumn("imdb_code", func(m *ytsgo.Movie) string { return m.IMDBCode }),
+		movieColumn("title", func(m *ytsgo.Movie) string { return m.Title }),
+		movieColumn("title_english", func(m *ytsgo.Movie) string { return m.TitleEnglish }),
+		movieColumn("slug", func(m *ytsgo.Movie) string { return m.Slug }),
+		movieColumn("year", func(m *ytsgo.Movie) string { return uitoa(m.Year) }),
+		movieColumn("rating", func(m *ytsgo.Movie) string {
+			return strconv.FormatFloat(float64(m.Rating), 'f', -1, 32)
+		}),
+		movieColumn("runtime", func(m *ytsgo.Movie) string { return uitoa(m.Runtime) }),
+		movieColumn("genres", func(m *ytsgo.Movie) string { return strings.Join(m.Genres, ", ") }),
+		movieColumn("language", func(m *ytsgo.Movie) string { return m.Language }),
+		movieColumn("mpa_rating", func(m *ytsgo.Movie) string { return m.MPARating }),
+		movieColumn("download_count", func(m *ytsgo.Movie) string { return uitoa(m.DownloadCount) }),
+		movieColumn("like_count", func(m *ytsgo.Movie) string { return uitoa(m.LikeCound) }),
+		movieColumn("summary", func(m *ytsgo.Movie) string { return m.DescriptionIntro }),
+		movieColumn("url", func(m *ytsgo.Movie) string { return urlutil.String(m.URL) }),
+		movieColumn("cover", func(m *ytsgo.Movie) string { return urlutil.String(m.LargeCoverImage) }),
+		movieColumn("date_uploaded", func(m *ytsgo.Movie) string { return date(m.DateUploaded, m.DateUploadedUnix) }),
+		torrentColumn("torrent_quality", func(t *ytsgo.Torrent) string { return t.Quality }),
+		torrentColumn("torrent_type", func(t *ytsgo.Torrent) string { return t.Type }),
+		torrentColumn("torrent_size", func(t *ytsgo.Torrent) string { return t.Size }),
+		torrentColumn("torrent_size_bytes", func(t *ytsgo.Torrent) string {
+			return strconv.FormatUint(uint64(t.Bytes()), 10)
+		}),
+		torrentColumn("torrent_seeds", func(t *ytsgo.Torrent) string { return uitoa(t.Seeds) }),
+		torrentColumn("torrent_peers", func(t *ytsgo.Torrent) string { return uitoa(t.Peers) }),
+		torrentColumn("torrent_hash", func(t *ytsgo.Torrent) string { return t.Hash }),
+		torrentColumn("torrent_url", func(t *ytsgo.Torrent) string { return urlutil.String(t.URL) }),
+		torrentColumn("torrent_magnet", func(t *ytsgo.Torrent) string { return t.Magnet() }),
+		torrentColumn("torrent_date_uploaded", func(t *ytsgo.Torrent) string { return date(t.DateUploaded, t.DateUploadedUnix) }),
+	} {
+		columns[c.Name] = c
+		ColumnNames = append(ColumnNames, c.Name)
+	}
+}
+
+// DefaultColumns are the columns used if none are given.
+var DefaultColumns = []string{"id", "title", "year", "rating", "genres", "torrent_quality", "torrent_size", "torrent_seeds", "torrent_magnet"}
+
+// Columns returns the columns with given names.
+func Columns(names ...string) ([]Column, error) {
+	var ret []Column
+	for _, n := range names {
+		c, ok := columns[strings.ToLower(strings.TrimSpace(n))]
+		if !ok {
+			return nil, fmt.Errorf("unknown column %q", n)
+		}
+		ret = append(ret, c)
+	}
+	return ret, nil
+}
+
+// ParseColumns returns the columns from a comma separated list of names.
+// DefaultColumns are returned for an empty string.
+func ParseColumns(s string) ([]Column, error) {
+	if strings.TrimSpace(s) == "" {
+		return Columns(DefaultColumns...)
+	}
+	return Columns(strings.Split(s, ",")...)
+}
+
+// Rows returns the table cells of movies. Movies are flattened to one row per
+// torrent if any of the columns is a torrent column; movies without torrents
+// still get a single row with empty torrent cells.
+func Rows(cols []Column, movies []*ytsgo.Movie) [][]string {
+	flatten := false
+	for _, c := range cols {
+		flatten = flatten || c.Torrent
+	}
+	var ret [][]string
+	row := func(m *ytsgo.Movie, t *ytsgo.Torrent) {
+		r := make([]string, len(cols))
+		for i, c := range cols {
+			r[i] = c.Value(m, t)
+		}
+		ret = append(ret, r)
+	}
+	for _, m := range movies {
+		if m == nil {
+			continue
+		}
+		n := 0
+		if flatten {
+			for _, t := range m.Torrents {
+				if t != nil {
+					row(m, t)
+					n++
+				}
+			}
+		}
+		if n == 0 {
+			row(m, nil)
+		}
+	}
+	return ret
+}
+
+func header(cols []Column) []string {
+	ret := make([]string, len(cols))
+	for i, c := range cols {
+		ret[i] = c.Name
+	}
+	return ret
+}
+
+// Write writes movies in given format. DefaultColumns are used if cols is
+// empty.
+func Write(w io.Writer, f Format, cols []Column, movies []*ytsgo.Movie) error {
+	if len(cols) == 0 && f != NDJSON {
+		var err error
+		if cols, err = Columns(DefaultColumns...); err != nil {
+			return err
+		}
+	}
+	switch f {
+	case CSV:
+		return WriteCSV(w, cols, movies)
+	case NDJSON:
+		return WriteNDJSON(w, movies)
+	case Markdown:
+		return WriteMarkdown(w, cols, movies)
+	case HTML:
+		return WriteHTML(w, cols, movies)
+	}
+	return fmt.Errorf("unknown export format %q", f)
+}
+
+// WriteCSV writes movies as CSV with a header row.
+func WriteCSV(w io.Writer, cols []Column, movies []*ytsgo.Movie) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header(cols)); err != nil {
+		return err
+	}
+	if err := cw.WriteAll(Rows(cols, movies)); err != nil {
+		return fmt.Errorf("failed to write CSV: %v", err)
+	}
+	return nil
+}
+
+// WriteNDJSON writes one JSON encoded movie per line.
+func WriteNDJSON(w io.Writer, movies []*ytsgo.Movie) error {
+	enc := json.NewEncoder(w)
+	for _, m := range movies {
+		if m == nil {
+			continue
+		}
+		if err := enc.Encode(m); err != nil {
+			return fmt.Errorf("failed to encode movie %d: %v", m.ID, err)
+		}
+	}
+	return nil
+}
+
+var mdEscaper = strings.NewReplacer(`\`, `\\`, "|", `\|`, "\r\n", " ", "\n", " ", "\r", " ")
+
+// WriteMarkdown writes movies as a Markdown table.
+func WriteMarkdown(w io.Writer, cols []Column, movies []*ytsgo.Movie) error {
+	line := func(cells []string) string {
+		for i, c := range cells {
+			cells[i] = mdEscaper.Replace(c)
+		}
+		return "| " + strings.Join(cells, " | ") + " |\n"
+	}
+	sep := make([]string, len(cols))
+	for i := range sep {
+		sep[i] = "---"
+	}
+	var b strings.Builder
+	b.WriteString(line(header(cols)))
+	b.WriteString("|" + strings.Join(sep, "|") + "|\n")
+	for _, r := range Rows(cols, movies) {
+		b.WriteString(line(r))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteHTML writes movies as an HTML table. Links are rendered as anchors.
+func WriteHTML(w io.Writer, cols []Column, movies []*ytsgo.Movie) error {
+	var b strings.Builder
+	b.WriteString("<table>\n<thead>\n<tr>")
+	for _, h := range header(cols) {
+		b.WriteString("<th>" + html.EscapeString(h) + "</th>")
+	}
+	b.WriteString("</tr>\n</thead>\n<tbody>\n")
+	for _, r := range Rows(cols, movies) {
+		b.WriteString("<tr>")
+		for _, c := range r {
+			b.WriteString("<td>" + htmlCell(c) + "</td>")
+		}
+		b.WriteString("</tr>\n")
+	}
+	b.WriteString("</tbody>\n</table>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func htmlCell(s string) string {
+	for _, p := range []string{"http://", "https://", "magnet:"} {
+		if strings.HasPrefix(s, p) {
+			return fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(s), html.EscapeString(s))
+		}
+	}
+	return html.EscapeString(s)
+}
@@ -0,0 +1,233 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qopher/ytsgo"
+)
+
+func mustURL(s string) *url.URL {
+	u, err := url.Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return u
+}
+
+func testMovies() []*ytsgo.Movie {
+	return []*ytsgo.Movie{
+		{
+			ID:               10,
+			IMDBCode:         "tt0133093",
+			Title:            "The Matrix",
+			Year:             1999,
+			Rating:           8.7,
+			Genres:           []string{"Action", "Sci-Fi"},
+			DescriptionIntro: "Neo | friends,\nand \"agents\".",
+			URL:              mustURL("https://yts.lt/movie/the-matrix-1999"),
+			DateUploaded:     time.Unix(1500000000, 0),
+			DateUploadedUnix: 1500000000,
+			Torrents: []*ytsgo.Torrent{
+				{
+					URL:       mustURL("https://yts.lt/torrent/download/AAAA"),
+					Hash:      "AAAA",
+					Quality:   "720p",
+					Size:      "946.49 MB",
+					SizeBytes: 992471654,
+					Seeds:     10,
+				},
+				nil,
+				{
+					Hash:      "BBBB",
+					Quality:   "1080p",
+					Size:      "1.60 GB",
+					SizeBytes: 1717986918,
+					Seeds:     3,
+				},
+			},
+		},
+		nil,
+		{ID: 11, Title: "No <Torrents>", Year: 2001},
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	testData := []struct {
+		in      string
+		want    Format
+		wantErr bool
+	}{
+		{in: "csv", want: CSV},
+		{in: "NDJSON", want: NDJSON},
+		{in: "jsonl", want: NDJSON},
+		{in: "md", want: Markdown},
+		{in: "markdown", want: Markdown},
+		{in: "html", want: HTML},
+		{in: "xml", wantErr: true},
+	}
+	for _, tc := range testData {
+		got, err := ParseFormat(tc.in)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("ParseFormat(%q) unexpected error, got %v want %v", tc.in, err, tc.wantErr)
+		}
+		if got != tc.want {
+			t.Errorf("ParseFormat(%q) = %q want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestRows(t *testing.T) {
+	testData := []struct {
+		desc    string
+		columns string
+		want    [][]string
+		wantErr bool
+	}{
+		{
+			desc:    "movie columns",
+			columns: "id, title,year,rating,genres,date_uploaded",
+			want: [][]string{
+				{"10", "The Matrix", "1999", "8.7", "Action, Sci-Fi", "2017-07-14T02:40:00Z"},
+				{"11", "No <Torrents>", "2001", "0", "", ""},
+			},
+		},
+		{
+			desc:    "flattened torrents",
+			columns: "title,torrent_quality,torrent_size_bytes,torrent_seeds,torrent_url",
+			want: [][]string{
+				{"The Matrix", "720p", "992471654", "10", "https://yts.lt/torrent/download/AAAA"},
+				{"The Matrix", "1080p", "1717986918", "3", ""},
+				{"No <Torrents>", "", "", "", ""},
+			},
+		},
+		{
+			desc:    "unknown column",
+			columns: "title,seeds",
+			wantErr: true,
+		},
+	}
+	for _, tc := range testData {
+		t.Run(tc.desc, func(t *testing.T) {
+			cols, err := ParseColumns(tc.columns)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("ParseColumns(%q) unexpected error, got %v want %v", tc.columns, err, tc.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if diff := cmp.Diff(tc.want, Rows(cols, testMovies())); diff != "" {
+				t.Errorf("Rows() returned diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDefaultColumns(t *testing.T) {
+	cols, err := ParseColumns("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cols) != len(DefaultColumns) {
+		t.Errorf("ParseColumns(\"\") returned %d columns want %d", len(cols), len(DefaultColumns))
+	}
+	if len(ColumnNames) != len(columns) {
+		t.Errorf("ColumnNames has %d names want %d", len(ColumnNames), len(columns))
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	cols, err := Columns("id", "summary", "torrent_quality")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, CSV, cols, testMovies()); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	got, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to read CSV: %v", err)
+	}
+	want := [][]string{
+		{"id", "summary", "torrent_quality"},
+		{"10", "Neo | friends,\nand \"agents\".", "720p"},
+		{"10", "Neo | friends,\nand \"agents\".", "1080p"},
+		{"11", "", ""},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Write() returned diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestWriteNDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, NDJSON, nil, testMovies()); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	var ids []uint
+	sc := bufio.NewScanner(&buf)
+	for sc.Scan() {
+		var m ytsgo.Movie
+		if err := json.Unmarshal(sc.Bytes(), &m); err != nil {
+			t.Fatalf("Failed to decode line %q: %v", sc.Text(), err)
+		}
+		ids = append(ids, m.ID)
+	}
+	if diff := cmp.Diff([]uint{10, 11}, ids); diff != "" {
+		t.Errorf("Write() returned diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	cols, err := Columns("title", "summary", "torrent_quality")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, Markdown, cols, testMovies()); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	want := strings.Join([]string{
+		"| title | summary | torrent_quality |",
+		"|---|---|---|",
+		`| The Matrix | Neo \| friends, and "agents". | 720p |`,
+		`| The Matrix | Neo \| friends, and "agents". | 1080p |`,
+		"| No <Torrents> |  |  |",
+		"",
+	}, "\n")
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("Write() returned diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	cols, err := Columns("title", "url")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, HTML, cols, testMovies()); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	want := `<table>
+<thead>
+<tr><th>title</th><th>url</th></tr>
+</thead>
+<tbody>
+<tr><td>The Matrix</td><td><a href="https://yts.lt/movie/the-matrix-1999">https://yts.lt/movie/the-matrix-1999</a></td></tr>
+<tr><td>No &lt;Torrents&gt;</td><td></td></tr>
+</tbody>
+</table>
+`
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("Write() returned diff (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,286 @@
+// Package feed renders YTS.LT movie lists as RSS 2.0 and Atom feeds with one
+// item per torrent.
+package feed
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/qopher/ytsgo"
+)
+
+// Format is a feed format.
+type Format string
+
+const (
+	// RSS is RSS 2.0.
+	RSS Format = "rss"
+	// Atom is Atom 1.0.
+	Atom Format = "atom"
+
+	torrentType = "application/x-bittorrent"
+)
+
+// ParseFormat returns the format with given name.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case RSS, Atom:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown feed format %q, want rss or atom", s)
+}
+
+// ContentType returns the MIME type of the format.
+func (f Format) ContentType() string {
+	if f == Atom {
+		return "application/atom+xml; charset=utf-8"
+	}
+	return "application/rss+xml; charset=utf-8"
+}
+
+// Meta describes the feed itself.
+type Meta struct {
+	Title       string
+	Link        string
+	Description string
+	// Updated is the feed update time, time.Now is used if zero.
+	Updated time.Time
+}
+
+// item is a single torrent of a movie.
+type item struct {
+	movie   *ytsgo.Movie
+	torrent *ytsgo.Torrent
+}
+
+func items(movies []*ytsgo.Movie) []item {
+	var ret []item
+	for _, m := range movies {
+		if m == nil {
+			continue
+		}
+		for _, t := range m.Torrents {
+			if t != nil {
+				ret = append(ret, item{movie: m, torrent: t})
+			}
+		}
+	}
+	return ret
+}
+
+func (it item) title() string {
+	ret := it.movie.Title
+	if it.movie.Year > 0 {
+		ret += fmt.Sprintf(" (%d)", it.movie.Year)
+	}
+	ret += " [" + it.torrent.Quality + "]"
+	if it.torrent.Type != "" {
+		ret += " [" + it.torrent.Type + "]"
+	}
+	return ret
+}
+
+// description returns HTML description with the cover image.
+func (it item) description() string {
+	var b strings.Builder
+	if it.movie.MediumCoverImage != nil && it.movie.MediumCoverImage.String() != "" {
+		fmt.Fprintf(&b, `<img src="%s" alt="%s"/>`, html.EscapeString(it.movie.MediumCoverImage.String()), html.EscapeString(it.movie.Title))
+	}
+	desc := it.movie.DescriptionFull
+	if desc == "" {
+		desc = it.movie.DescriptionIntro
+	}
+	if desc != "" {
+		fmt.Fprintf(&b, "<p>%s</p>", html.EscapeString(desc))
+	}
+	fmt.Fprintf(&b, "<p>Rating: %.1f | Size: %s | Seeds: %d | Peers: %d</p>", it.movie.Rating, html.EscapeString(it.torrent.Size), it.torrent.Seeds, it.torrent.Peers)
+	return b.String()
+}
+
+func (it item) torrentURL() string {
+	if it.torrent.URL == nil {
+		return ""
+	}
+	return it.torrent.URL.String()
+}
+
+func (it item) updated() time.Time {
+	if it.torrent.DateUploadedUnix > 0 {
+		return it.torrent.DateUploaded.UTC()
+	}
+	return it.movie.DateUploaded.UTC()
+}
+
+// Write writes movies as a feed in the format.
+func Write(w io.Writer, f Format, meta Meta, movies []*ytsgo.Movie) error {
+	if meta.Updated.IsZero() {
+		meta.Updated = time.Now()
+	}
+	var doc interface{}
+	switch f {
+	case RSS:
+		doc = rssDoc(meta, movies)
+	case Atom:
+		doc = atomDoc(meta, movies)
+	default:
+		return fmt.Errorf("unknown feed format %q", f)
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+type rss struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	MediaNS string     `xml:"xmlns:media,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Generator     string    `xml:"generator"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string        `xml:"title"`
+	Link        string        `xml:"link"`
+	Description string        `xml:"description"`
+	GUID        rssGUID       `xml:"guid"`
+	PubDate     string        `xml:"pubDate,omitempty"`
+	Categories  []string      `xml:"category"`
+	Enclosure   *rssEnclosure `xml:"enclosure,omitempty"`
+	Thumbnail   *mediaURL     `xml:"media:thumbnail,omitempty"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length uint64 `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+type mediaURL struct {
+	URL string `xml:"url,attr"`
+}
+
+func rssDoc(meta Meta, movies []*ytsgo.Movie) *rss {
+	doc := &rss{
+		Version: "2.0",
+		MediaNS: "http://search.yahoo.com/mrss/",
+		Channel: rssChannel{
+			Title:         meta.Title,
+			Link:          meta.Link,
+			Description:   meta.Description,
+			LastBuildDate: meta.Updated.UTC().Format(time.RFC1123Z),
+			Generator:     "ytsgo",
+		},
+	}
+	for _, it := range items(movies) {
+		ri := rssItem{
+			Title:       it.title(),
+			Link:        it.torrent.Magnet(),
+			Description: it.description(),
+			GUID:        rssGUID{Value: "urn:btih:" + it.torrent.Hash},
+			Categories:  it.movie.Genres,
+		}
+		if u := it.updated(); u.Unix() > 0 {
+			ri.PubDate = u.Format(time.RFC1123Z)
+		}
+		if u := it.torrentURL(); u != "" {
+			ri.Enclosure = &rssEnclosure{URL: u, Length: uint64(it.torrent.Bytes()), Type: torrentType}
+		}
+		if it.movie.MediumCoverImage != nil && it.movie.MediumCoverImage.String() != "" {
+			ri.Thumbnail = &mediaURL{URL: it.movie.MediumCoverImage.String()}
+		}
+		doc.Channel.Items = append(doc.Channel.Items, ri)
+	}
+	return doc
+}
+
+type atom struct {
+	XMLName   xml.Name    `xml:"feed"`
+	NS        string      `xml:"xmlns,attr"`
+	ID        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Subtitle  string      `xml:"subtitle,omitempty"`
+	Updated   string      `xml:"updated"`
+	Links     []atomLink  `xml:"link"`
+	Generator string      `xml:"generator"`
+	Entries   []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Rel    string `xml:"rel,attr,omitempty"`
+	Href   string `xml:"href,attr"`
+	Type   string `xml:"type,attr,omitempty"`
+	Length uint64 `xml:"length,attr,omitempty"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Updated    string         `xml:"updated"`
+	Links      []atomLink     `xml:"link"`
+	Categories []atomCategory `xml:"category"`
+	Content    atomContent    `xml:"content"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomContent struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+func atomDoc(meta Meta, movies []*ytsgo.Movie) *atom {
+	doc := &atom{
+		NS:        "http://www.w3.org/2005/Atom",
+		ID:        meta.Link,
+		Title:     meta.Title,
+		Subtitle:  meta.Description,
+		Updated:   meta.Updated.UTC().Format(time.RFC3339),
+		Generator: "ytsgo",
+	}
+	if meta.Link != "" {
+		doc.Links = append(doc.Links, atomLink{Rel: "alternate", Href: meta.Link})
+	}
+	for _, it := range items(movies) {
+		e := atomEntry{
+			ID:      "urn:btih:" + it.torrent.Hash,
+			Title:   it.title(),
+			Updated: it.updated().Format(time.RFC3339),
+			Links:   []atomLink{{Rel: "alternate", Href: it.torrent.Magnet()}},
+			Content: atomContent{Type: "html", Value: it.description()},
+		}
+		if u := it.torrentURL(); u != "" {
+			e.Links = append(e.Links, atomLink{Rel: "enclosure", Href: u, Type: torrentType, Length: uint64(it.torrent.Bytes())})
+		}
+		for _, g := range it.movie.Genres {
+			e.Categories = append(e.Categories, atomCategory{Term: g})
+		}
+		doc.Entries = append(doc.Entries, e)
+	}
+	return doc
+}
@@ -0,0 +1,312 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qopher/ytsgo"
+)
+
+func mustURL(s string) *url.URL {
+	u, err := url.Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return u
+}
+
+func testMovies() []*ytsgo.Movie {
+	return []*ytsgo.Movie{
+		{
+			ID:               10,
+			Title:            "The Matrix",
+			Year:             1999,
+			Rating:           8.7,
+			Genres:           []string{"Action", "Sci-Fi"},
+			DescriptionFull:  "Neo & friends.",
+			MediumCoverImage: mustURL("https://yts.lt/assets/matrix/medium-cover.jpg"),
+			Torrents: []*ytsgo.Torrent{
+				{
+					URL:              mustURL("https://yts.lt/torrent/download/AAAA"),
+					Hash:             "AAAA",
+					Quality:          "720p",
+					Type:             "bluray",
+					Size:             "946.49 MB",
+					SizeBytes:        992471654,
+					Seeds:            10,
+					Peers:            2,
+					DateUploaded:     time.Unix(1500000000, 0),
+					DateUploadedUnix: 1500000000,
+				},
+				{
+					URL:       mustURL("https://yts.lt/torrent/download/BBBB"),
+					Hash:      "BBBB",
+					Quality:   "1080p",
+					Size:      "1.60 GB",
+					SizeBytes: 1717986918,
+				},
+			},
+		},
+		nil,
+		{ID: 11, Title: "No Torrents"},
+	}
+}
+
+var updated = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func TestWriteRSS(t *testing.T) {
+	var buf bytes.Buffer
+	meta := Meta{Title: "YTS 1080p", Link: "http://localhost/feed", Description: "desc", Updated: updated}
+	if err := Write(&buf, RSS, meta, testMovies()); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	var got struct {
+		Channel struct {
+			Title         string `xml:"title"`
+			LastBuildDate string `xml:"lastBuildDate"`
+			Items         []struct {
+				Title       string   `xml:"title"`
+				Link        string   `xml:"link"`
+				Description string   `xml:"description"`
+				GUID        string   `xml:"guid"`
+				PubDate     string   `xml:"pubDate"`
+				Categories  []string `xml:"category"`
+				Enclosure   struct {
+					URL    string `xml:"url,attr"`
+					Length uint64 `xml:"length,attr"`
+					Type   string `xml:"type,attr"`
+				} `xml:"enclosure"`
+				Thumbnail struct {
+					URL string `xml:"url,attr"`
+				} `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	if err := xml.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("Invalid RSS: %v\n%s", err, buf.String())
+	}
+	if got, want := got.Channel.Title, "YTS 1080p"; got != want {
+		t.Errorf("Unexpected title, got %q want %q", got, want)
+	}
+	if got, want := got.Channel.LastBuildDate, "Thu, 02 Jan 2020 03:04:05 +0000"; got != want {
+		t.Errorf("Unexpected lastBuildDate, got %q want %q", got, want)
+	}
+	if len(got.Channel.Items) != 2 {
+		t.Fatalf("Unexpected number of items, got %d want 2", len(got.Channel.Items))
+	}
+	it := got.Channel.Items[0]
+	if got, want := it.Title, "The Matrix (1999) [720p] [bluray]"; got != want {
+		t.Errorf("Unexpected item title, got %q want %q", got, want)
+	}
+	if !strings.HasPrefix(it.Link, "magnet:?xt=urn:btih:AAAA") {
+		t.Errorf("Unexpected item link, got %q want magnet", it.Link)
+	}
+	if got, want := it.GUID, "urn:btih:AAAA"; got != want {
+		t.Errorf("Unexpected guid, got %q want %q", got, want)
+	}
+	if got, want := it.PubDate, time.Unix(1500000000, 0).UTC().Format(time.RFC1123Z); got != want {
+		t.Errorf("Unexpected pubDate, got %q want %q", got, want)
+	}
+	if diff := cmp.Diff([]string{"Action", "Sci-Fi"}, it.Categories); diff != "" {
+		t.Errorf("Unexpected categories, diff -want +got\n%s", diff)
+	}
+	if got, want := it.Enclosure.URL, "https://yts.lt/torrent/download/AAAA"; got != want {
+		t.Errorf("Unexpected enclosure url, got %q want %q", got, want)
+	}
+	if got, want := it.Enclosure.Length, uint64(992471654); got != want {
+		t.Errorf("Unexpected enclosure length, got %d want %d", got, want)
+	}
+	if got, want := it.Enclosure.Type, "application/x-bittorrent"; got != want {
+		t.Errorf("Unexpected enclosure type, got %q want %q", got, want)
+	}
+	if got, want := it.Thumbnail.URL, "https://yts.lt/assets/matrix/medium-cover.jpg"; got != want {
+		t.Errorf("Unexpected thumbnail, got %q want %q", got, want)
+	}
+	for _, s := range []string{`<img src="https://yts.lt/assets/matrix/medium-cover.jpg"`, "Neo &amp; friends.", "Size: 946.49 MB"} {
+		if !strings.Contains(it.Description, s) {
+			t.Errorf("Description %q does not contain %q", it.Description, s)
+		}
+	}
+	if got, want := got.Channel.Items[1].Title, "The Matrix (1999) [1080p]"; got != want {
+		t.Errorf("Unexpected item title, got %q want %q", got, want)
+	}
+}
+
+func TestWriteAtom(t *testing.T) {
+	var buf bytes.Buffer
+	meta := Meta{Title: "YTS", Link: "http://localhost/feed", Updated: updated}
+	if err := Write(&buf, Atom, meta, testMovies()); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	type link struct {
+		Rel  string `xml:"rel,attr"`
+		Href string `xml:"href,attr"`
+		Type string `xml:"type,attr"`
+	}
+	var got struct {
+		XMLName xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+		ID      string   `xml:"id"`
+		Updated string   `xml:"updated"`
+		Entries []struct {
+			ID      string `xml:"id"`
+			Title   string `xml:"title"`
+			Links   []link `xml:"link"`
+			Content struct {
+				Type  string `xml:"type,attr"`
+				Value string `xml:",chardata"`
+			} `xml:"content"`
+		} `xml:"entry"`
+	}
+	if err := xml.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("Invalid Atom: %v\n%s", err, buf.String())
+	}
+	if got, want := got.Updated, "2020-01-02T03:04:05Z"; got != want {
+		t.Errorf("Unexpected updated, got %q want %q", got, want)
+	}
+	if len(got.Entries) != 2 {
+		t.Fatalf("Unexpected number of entries, got %d want 2", len(got.Entries))
+	}
+	e := got.Entries[1]
+	if got, want := e.ID, "urn:btih:BBBB"; got != want {
+		t.Errorf("Unexpected id, got %q want %q", got, want)
+	}
+	if len(e.Links) != 2 {
+		t.Fatalf("Unexpected number of links, got %d want 2", len(e.Links))
+	}
+	if !strings.HasPrefix(e.Links[0].Href, "magnet:?xt=urn:btih:BBBB") || e.Links[0].Rel != "alternate" {
+		t.Errorf("Unexpected alternate link %+v", e.Links[0])
+	}
+	if diff := cmp.Diff(link{Rel: "enclosure", Href: "https://yts.lt/torrent/download/BBBB", Type: "application/x-bittorrent"}, e.Links[1]); diff != "" {
+		t.Errorf("Unexpected enclosure link, diff -want +got\n%s", diff)
+	}
+	if e.Content.Type != "html" || !strings.Contains(e.Content.Value, "<img") {
+		t.Errorf("Unexpected content %+v", e.Content)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, tc := range []struct {
+		in      string
+		want    Format
+		wantErr bool
+	}{
+		{in: "rss", want: RSS},
+		{in: "ATOM", want: Atom},
+		{in: "json", wantErr: true},
+	} {
+		got, err := ParseFormat(tc.in)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("ParseFormat(%q) unexpected error: %v", tc.in, err)
+		}
+		if got != tc.want {
+			t.Errorf("ParseFormat(%q) = %q want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+type fakeLister struct {
+	calls   int
+	queries []url.Values
+	err     error
+}
+
+func (f *fakeLister) ListMovies(opts ...ytsgo.ListMoviesOption) (*ytsgo.Movies, error) {
+	f.calls++
+	v := url.Values{}
+	for _, o := range opts {
+		o(v)
+	}
+	f.queries = append(f.queries, v)
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &ytsgo.Movies{Movies: testMovies()}, nil
+}
+
+func TestHandler(t *testing.T) {
+	l := &fakeLister{}
+	ts := httptest.NewServer(&Handler{Client: l, Meta: Meta{Title: "YTS"}})
+	defer ts.Close()
+	testData := []struct {
+		desc      string
+		query     string
+		wantCode  int
+		wantType  string
+		wantCalls int
+	}{
+		{desc: "rss", query: "quality=1080p&genre=action", wantCode: http.StatusOK, wantType: RSS.ContentType(), wantCalls: 1},
+		{desc: "cached", query: "genre=action&quality=1080p", wantCode: http.StatusOK, wantType: RSS.ContentType(), wantCalls: 1},
+		{desc: "unknown parameters", query: "genre=action&quality=1080p&foo=bar", wantCode: http.StatusOK, wantType: RSS.ContentType(), wantCalls: 1},
+		{desc: "atom", query: "quality=1080p&genre=action&format=atom", wantCode: http.StatusOK, wantType: Atom.ContentType(), wantCalls: 2},
+		{desc: "bad format", query: "format=json", wantCode: http.StatusBadRequest, wantCalls: 2},
+		{desc: "bad limit", query: "limit=abc", wantCode: http.StatusBadRequest, wantCalls: 2},
+	}
+	for _, tc := range testData {
+		t.Run(tc.desc, func(t *testing.T) {
+			rsp, err := http.Get(ts.URL + "/feed?" + tc.query)
+			if err != nil {
+				t.Fatalf("GET failed: %v", err)
+			}
+			rsp.Body.Close()
+			if rsp.StatusCode != tc.wantCode {
+				t.Errorf("Unexpected code, got %d want %d", rsp.StatusCode, tc.wantCode)
+			}
+			if tc.wantType != "" && rsp.Header.Get("Content-Type") != tc.wantType {
+				t.Errorf("Unexpected content type, got %q want %q", rsp.Header.Get("Content-Type"), tc.wantType)
+			}
+			if l.calls != tc.wantCalls {
+				t.Errorf("Unexpected number of ListMovies calls, got %d want %d", l.calls, tc.wantCalls)
+			}
+		})
+	}
+	want := url.Values{"quality": {"1080p"}, "genre": {"action"}}
+	if diff := cmp.Diff(want, l.queries[0]); diff != "" {
+		t.Errorf("Unexpected query, diff -want +got\n%s", diff)
+	}
+}
+
+func TestHandlerMaxEntries(t *testing.T) {
+	l := &fakeLister{}
+	h := &Handler{Client: l, Format: Atom, MaxEntries: 2}
+	ts := httptest.NewServer(h)
+	defer ts.Close()
+	for _, q := range []string{"genre=action", "genre=drama", "genre=comedy", "genre=comedy"} {
+		rsp, err := http.Get(ts.URL + "/feed?" + q)
+		if err != nil {
+			t.Fatalf("GET failed: %v", err)
+		}
+		rsp.Body.Close()
+		if got, want := rsp.Header.Get("Content-Type"), Atom.ContentType(); got != want {
+			t.Errorf("Unexpected content type, got %q want %q", got, want)
+		}
+	}
+	if l.calls != 3 {
+		t.Errorf("Unexpected number of ListMovies calls, got %d want 3", l.calls)
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.cache) != 2 {
+		t.Errorf("Unexpected number of cached feeds, got %d want 2", len(h.cache))
+	}
+}
+
+func TestHandlerError(t *testing.T) {
+	ts := httptest.NewServer(&Handler{Client: &fakeLister{err: errors.New("down")}})
+	defer ts.Close()
+	rsp, err := http.Get(ts.URL)
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	rsp.Body.Close()
+	if got, want := rsp.StatusCode, http.StatusBadGateway; got != want {
+		t.Errorf("Unexpected code, got %d want %d", got, want)
+	}
+}
@@ -0,0 +1,161 @@
+package feed
+
+// File handler.go contains an HTTP handler serving live feeds.
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/qopher/ytsgo"
+)
+
+const (
+	// DefaultTTL is a default time feeds are cached by the Handler.
+	DefaultTTL = 10 * time.Minute
+	// DefaultMaxEntries is a default maximal number of feeds cached by the
+	// Handler.
+	DefaultMaxEntries = 1000
+)
+
+// Lister lists movies. It is implemented by *ytsgo.Client.
+type Lister interface {
+	ListMovies(opts ...ytsgo.ListMoviesOption) (*ytsgo.Movies, error)
+}
+
+// Handler serves feeds for list_movies.json query parameters of the request,
+// eg. /feed?quality=1080p&genre=action&format=atom. Format is served unless
+// the format parameter is set. Other parameters are ignored.
+type Handler struct {
+	Client Lister
+	Meta   Meta
+	// Format is the default format, RSS if empty.
+	Format Format
+	// TTL overrides DefaultTTL if positive.
+	TTL time.Duration
+	// MaxEntries overrides DefaultMaxEntries if positive.
+	MaxEntries int
+
+	mu    sync.Mutex
+	cache map[string]*cached
+}
+
+type cached struct {
+	body    []byte
+	format  Format
+	expires time.Time
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	format := h.Format
+	if format == "" {
+		format = RSS
+	}
+	if f := q.Get("format"); f != "" {
+		var err error
+		if format, err = ParseFormat(f); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	opts, err := ytsgo.ListMoviesQuery(q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// Only the supported parameters make the key, so that arbitrary
+	// parameters don't create new entries.
+	params := url.Values{}
+	for _, o := range opts {
+		o(params)
+	}
+	key := string(format) + "?" + params.Encode()
+	if c := h.lookup(key); c != nil {
+		h.write(w, c)
+		return
+	}
+	mvs, err := h.Client.ListMovies(opts...)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	var movies []*ytsgo.Movie
+	if mvs != nil {
+		movies = mvs.Movies
+	}
+	meta := h.Meta
+	if meta.Link == "" {
+		meta.Link = "http://" + r.Host + r.URL.RequestURI()
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, format, meta, movies); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	c := &cached{body: buf.Bytes(), format: format, expires: time.Now().Add(h.ttl())}
+	h.store(key, c)
+	h.write(w, c)
+}
+
+func (h *Handler) ttl() time.Duration {
+	if h.TTL > 0 {
+		return h.TTL
+	}
+	return DefaultTTL
+}
+
+func (h *Handler) lookup(key string) *cached {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	c, ok := h.cache[key]
+	if !ok || time.Now().After(c.expires) {
+		return nil
+	}
+	return c
+}
+
+func (h *Handler) store(key string, c *cached) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.cache == nil {
+		h.cache = make(map[string]*cached)
+	}
+	now := time.Now()
+	for k, v := range h.cache {
+		if now.After(v.expires) {
+			delete(h.cache, k)
+		}
+	}
+	max := h.MaxEntries
+	if max <= 0 {
+		max = DefaultMaxEntries
+	}
+	if _, ok := h.cache[key]; !ok && len(h.cache) >= max {
+		h.evict()
+	}
+	h.cache[key] = c
+}
+
+// evict removes the entry which expires first. h.mu must be held.
+func (h *Handler) evict() {
+	var (
+		oldest string
+		exp    time.Time
+	)
+	for k, c := range h.cache {
+		if oldest == "" || c.expires.Before(exp) {
+			oldest, exp = k, c.expires
+		}
+	}
+	delete(h.cache, oldest)
+}
+
+func (h *Handler) write(w http.ResponseWriter, c *cached) {
+	w.Header().Set("Content-Type", c.format.ContentType())
+	w.Header().Set("Cache-Control", "max-age="+strconv.Itoa(int(time.Until(c.expires).Seconds())))
+	w.Write(c.body)
+}
@@ -0,0 +1,384 @@
+// Package graph crawls movie suggestions returned by YTS.LT API and provides
+// queries on the resulting directed graph.
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/qopher/ytsgo"
+)
+
+const (
+	// DefaultMaxDepth is a default number of suggestion hops from the seeds.
+	DefaultMaxDepth = 2
+	// DefaultMaxNodes is a default limit of movies in the graph.
+	DefaultMaxNodes = 200
+)
+
+// Suggester returns movies related to the movie with given ID. It is implemented by *ytsgo.Client.
+type Suggester interface {
+	Suggestions(id int) ([]*ytsgo.Movie, error)
+}
+
+// Node is a single movie in the graph.
+type Node struct {
+	ID       uint   `json:"id"`
+	IMDBCode string `json:"imdb_code"`
+	Title    string `json:"title"`
+	Year     uint   `json:"year"`
+	// Depth is a number of suggestion hops from the closest seed.
+	Depth int `json:"depth"`
+}
+
+// Edge means that From movie has To movie among its suggestions.
+type Edge struct {
+	From uint `json:"from"`
+	To   uint `json:"to"`
+}
+
+// Graph is a directed graph of movie suggestions.
+type Graph struct {
+	nodes map[uint]*Node
+	out   map[uint][]uint
+	in    map[uint][]uint
+}
+
+// New returns an empty Graph.
+func New() *Graph {
+	return &Graph{
+		nodes: make(map[uint]*Node),
+		out:   make(map[uint][]uint),
+		in:    make(map[uint][]uint),
+	}
+}
+
+// CrawlOption changes the default behavior of Crawl.
+type CrawlOption func(c *crawler)
+
+// MaxDepth overrides DefaultMaxDepth. Suggestions are not fetched for movies
+// which are d hops from the seeds.
+func MaxDepth(d int) CrawlOption {
+	return func(c *crawler) {
+		c.maxDepth = d
+	}
+}
+
+// MaxNodes overrides DefaultMaxNodes.
+func MaxNodes(n int) CrawlOption {
+	return func(c *crawler) {
+		c.maxNodes = n
+	}
+}
+
+type crawler struct {
+	maxDepth int
+	maxNodes int
+}
+
+// Crawl builds the graph breadth-first starting from seeds. If fetching
+// suggestions fails the graph crawled so far is returned with the error.
+func Crawl(s Suggester, seeds []*ytsgo.Movie, opts ...CrawlOption) (*Graph, error) {
+	c := &crawler{
+		maxDepth: DefaultMaxDepth,
+		maxNodes: DefaultMaxNodes,
+	}
+	for _, o := range opts {
+		o(c)
+	}
+	g := New()
+	var queue []*Node
+	for _, m := range seeds {
+		if m == nil {
+			continue
+		}
+		if len(g.nodes) >= c.maxNodes {
+			break
+		}
+		if n, ok := g.add(m, 0); ok {
+			queue = append(queue, n)
+		}
+	}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		if n.Depth >= c.maxDepth {
+			continue
+		}
+		movies, err := s.Suggestions(int(n.ID))
+		if err != nil {
+			return g, fmt.Errorf("failed to fetch suggestions for movie %d: %v", n.ID, err)
+		}
+		for _, m := range movies {
+			if m == nil {
+				continue
+			}
+			if _, ok := g.nodes[m.ID]; !ok {
+				if len(g.nodes) >= c.maxNodes {
+					continue
+				}
+				next, _ := g.add(m, n.Depth+1)
+				queue = append(queue, next)
+			}
+			g.AddEdge(n.ID, m.ID)
+		}
+	}
+	return g, nil
+}
+
+// add adds the movie to the graph unless it is already there.
+func (g *Graph) add(m *ytsgo.Movie, depth int) (*Node, bool) {
+	if n, ok := g.nodes[m.ID]; ok {
+		return n, false
+	}
+	n := &Node{
+		ID:       m.ID,
+		IMDBCode: m.IMDBCode,
+		Title:    m.Title,
+		Year:     m.Year,
+		Depth:    depth,
+	}
+	g.AddNode(n)
+	return n, true
+}
+
+// AddNode adds n to the graph replacing a node with the same ID.
+func (g *Graph) AddNode(n *Node) {
+	g.nodes[n.ID] = n
+}
+
+// AddEdge adds an edge between two movies already present in the graph.
+// Duplicate edges and edges with unknown ends are ignored.
+func (g *Graph) AddEdge(from, to uint) {
+	if _, ok := g.nodes[from]; !ok {
+		return
+	}
+	if _, ok := g.nodes[to]; !ok {
+		return
+	}
+	for _, id := range g.out[from] {
+		if id == to {
+			return
+		}
+	}
+	g.out[from] = append(g.out[from], to)
+	g.in[to] = append(g.in[to], from)
+}
+
+// Node returns the movie with given ID or nil if it is not in the graph.
+func (g *Graph) Node(id uint) *Node {
+	return g.nodes[id]
+}
+
+// Find returns the movie with given title, ignoring case. If there are
+// several movies with the same title the one with the lowest ID is returned.
+func (g *Graph) Find(title string) *Node {
+	for _, n := range g.Nodes() {
+		if strings.EqualFold(n.Title, title) {
+			return n
+		}
+	}
+	return nil
+}
+
+// Nodes returns all movies sorted by ID.
+func (g *Graph) Nodes() []*Node {
+	ret := make([]*Node, 0, len(g.nodes))
+	for _, n := range g.nodes {
+		ret = append(ret, n)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].ID < ret[j].ID })
+	return ret
+}
+
+// Edges returns all edges sorted by From and To.
+func (g *Graph) Edges() []Edge {
+	var ret []Edge
+	for from, tos := range g.out {
+		for _, to := range tos {
+			ret = append(ret, Edge{From: from, To: to})
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].From != ret[j].From {
+			return ret[i].From < ret[j].From
+		}
+		return ret[i].To < ret[j].To
+	})
+	return ret
+}
+
+// Suggestions returns movies suggested for the movie with given ID.
+func (g *Graph) Suggestions(id uint) []*Node {
+	return g.lookup(g.out[id])
+}
+
+// SuggestedBy returns movies which have the movie with given ID among their suggestions.
+func (g *Graph) SuggestedBy(id uint) []*Node {
+	return g.lookup(g.in[id])
+}
+
+func (g *Graph) lookup(ids []uint) []*Node {
+	ret := make([]*Node, 0, len(ids))
+	for _, id := range ids {
+		ret = append(ret, g.nodes[id])
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].ID < ret[j].ID })
+	return ret
+}
+
+// Ranked is a movie with a number of times it was suggested.
+type Ranked struct {
+	*Node
+	Count int
+}
+
+// MostSuggested returns up to n movies which are most often suggested by
+// other movies, all of them if n <= 0. Movies never suggested are skipped.
+func (g *Graph) MostSuggested(n int) []Ranked {
+	var ret []Ranked
+	for id, from := range g.in {
+		if len(from) > 0 {
+			ret = append(ret, Ranked{Node: g.nodes[id], Count: len(from)})
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Count != ret[j].Count {
+			return ret[i].Count > ret[j].Count
+		}
+		return ret[i].ID < ret[j].ID
+	})
+	if n > 0 && len(ret) > n {
+		ret = ret[:n]
+	}
+	return ret
+}
+
+// Clusters returns groups of movies connected by suggestions in any direction.
+// Clusters are sorted from the largest one, movies within a cluster by ID.
+func (g *Graph) Clusters() [][]*Node {
+	seen := make(map[uint]bool)
+	var ret [][]*Node
+	for _, n := range g.Nodes() {
+		if seen[n.ID] {
+			continue
+		}
+		seen[n.ID] = true
+		cluster := []*Node{n}
+		for i := 0; i < len(cluster); i++ {
+			id := cluster[i].ID
+			for _, next := range append(append([]uint{}, g.out[id]...), g.in[id]...) {
+				if !seen[next] {
+					seen[next] = true
+					cluster = append(cluster, g.nodes[next])
+				}
+			}
+		}
+		sort.Slice(cluster, func(i, j int) bool { return cluster[i].ID < cluster[j].ID })
+		ret = append(ret, cluster)
+	}
+	sort.SliceStable(ret, func(i, j int) bool { return len(ret[i]) > len(ret[j]) })
+	return ret
+}
+
+// ShortestPath returns the shortest chain of suggestions leading from one
+// movie to the other, including both ends. Nil is returned if there is no such chain.
+func (g *Graph) ShortestPath(from, to uint) []*Node {
+	if _, ok := g.nodes[from]; !ok {
+		return nil
+	}
+	if _, ok := g.nodes[to]; !ok {
+		return nil
+	}
+	prev := map[uint]uint{from: from}
+	queue := []uint{from}
+	for len(queue) > 0 && !hasKey(prev, to) {
+		id := queue[0]
+		queue = queue[1:]
+		next := append([]uint{}, g.out[id]...)
+		sort.Slice(next, func(i, j int) bool { return next[i] < next[j] })
+		for _, n := range next {
+			if !hasKey(prev, n) {
+				prev[n] = id
+				queue = append(queue, n)
+			}
+		}
+	}
+	if !hasKey(prev, to) {
+		return nil
+	}
+	var path []*Node
+	for id := to; ; id = prev[id] {
+		path = append([]*Node{g.nodes[id]}, path...)
+		if id == from {
+			break
+		}
+	}
+	return path
+}
+
+func hasKey(m map[uint]uint, k uint) bool {
+	_, ok := m[k]
+	return ok
+}
+
+// WriteDOT writes the graph in Graphviz DOT format.
+func (g *Graph) WriteDOT(w io.Writer) error {
+	if _, err := fmt.Fprintln(w, "digraph suggestions {"); err != nil {
+		return err
+	}
+	for _, n := range g.Nodes() {
+		label := n.Title
+		if n.Year > 0 {
+			label = fmt.Sprintf("%s (%d)", n.Title, n.Year)
+		}
+		if _, err := fmt.Fprintf(w, "\t%d [label=%q];\n", n.ID, label); err != nil {
+			return err
+		}
+	}
+	for _, e := range g.Edges() {
+		if _, err := fmt.Fprintf(w, "\t%d -> %d;\n", e.From, e.To); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintln(w, "}")
+	return err
+}
+
+type graphJSON struct {
+	Nodes []*Node `json:"nodes"`
+	Edges []Edge  `json:"edges"`
+}
+
+// MarshalJSON encodes the graph as lists of nodes and edges.
+func (g *Graph) MarshalJSON() ([]byte, error) {
+	return json.Marshal(graphJSON{
+		Nodes: g.Nodes(),
+		Edges: g.Edges(),
+	})
+}
+
+// UnmarshalJSON decodes the graph encoded by MarshalJSON. Null nodes are
+// skipped.
+func (g *Graph) UnmarshalJSON(data []byte) error {
+	var aux graphJSON
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	*g = *New()
+	for _, n := range aux.Nodes {
+		if n != nil {
+			g.AddNode(n)
+		}
+	}
+	for _, e := range aux.Edges {
+		if g.nodes[e.From] == nil || g.nodes[e.To] == nil {
+			return fmt.Errorf("edge %d -> %d refers to unknown movie", e.From, e.To)
+		}
+		g.AddEdge(e.From, e.To)
+	}
+	return nil
+}
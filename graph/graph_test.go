@@ -0,0 +1,260 @@
+package graph
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qopher/ytsgo"
+)
+
+type fakeSuggester struct {
+	suggestions map[int][]int
+	fail        int
+	calls       []int
+}
+
+func (f *fakeSuggester) Suggestions(id int) ([]*ytsgo.Movie, error) {
+	f.calls = append(f.calls, id)
+	if id == f.fail {
+		return nil, errors.New("some error")
+	}
+	var ret []*ytsgo.Movie
+	for _, s := range f.suggestions[id] {
+		// Zero stands for a null movie kept by strict decoding.
+		if s == 0 {
+			ret = append(ret, nil)
+			continue
+		}
+		ret = append(ret, movie(s))
+	}
+	return ret, nil
+}
+
+func movie(id int) *ytsgo.Movie {
+	return &ytsgo.Movie{
+		ID:    uint(id),
+		Title: fmt.Sprintf("Movie %d", id),
+		Year:  2000 + uint(id),
+	}
+}
+
+func ids(nodes []*Node) []uint {
+	var ret []uint
+	for _, n := range nodes {
+		ret = append(ret, n.ID)
+	}
+	return ret
+}
+
+var testSuggestions = map[int][]int{
+	1:  {2, 3},
+	2:  {3, 4},
+	3:  {1, 4},
+	4:  {5},
+	5:  {6},
+	10: {11, 4},
+	11: {10},
+}
+
+func TestCrawl(t *testing.T) {
+	testData := []struct {
+		desc      string
+		seeds     []int
+		opts      []CrawlOption
+		fail      int
+		wantNodes []uint
+		wantEdges []Edge
+		wantCalls []int
+		wantErr   bool
+	}{
+		{
+			desc:      "default",
+			seeds:     []int{1},
+			wantNodes: []uint{1, 2, 3, 4},
+			wantEdges: []Edge{{1, 2}, {1, 3}, {2, 3}, {2, 4}, {3, 1}, {3, 4}},
+			wantCalls: []int{1, 2, 3},
+		},
+		{
+			desc:      "depth",
+			seeds:     []int{1},
+			opts:      []CrawlOption{MaxDepth(4)},
+			wantNodes: []uint{1, 2, 3, 4, 5, 6},
+			wantEdges: []Edge{{1, 2}, {1, 3}, {2, 3}, {2, 4}, {3, 1}, {3, 4}, {4, 5}, {5, 6}},
+			wantCalls: []int{1, 2, 3, 4, 5},
+		},
+		{
+			desc:      "max nodes",
+			seeds:     []int{1},
+			opts:      []CrawlOption{MaxNodes(3)},
+			wantNodes: []uint{1, 2, 3},
+			wantEdges: []Edge{{1, 2}, {1, 3}, {2, 3}, {3, 1}},
+			wantCalls: []int{1, 2, 3},
+		},
+		{
+			desc:      "many seeds",
+			seeds:     []int{1, 10},
+			opts:      []CrawlOption{MaxDepth(1)},
+			wantNodes: []uint{1, 2, 3, 4, 10, 11},
+			wantEdges: []Edge{{1, 2}, {1, 3}, {10, 4}, {10, 11}},
+			wantCalls: []int{1, 10},
+		},
+		{
+			desc:      "error",
+			seeds:     []int{1},
+			fail:      2,
+			wantNodes: []uint{1, 2, 3},
+			wantEdges: []Edge{{1, 2}, {1, 3}},
+			wantCalls: []int{1, 2},
+			wantErr:   true,
+		},
+	}
+	for _, tc := range testData {
+		t.Run(tc.desc, func(t *testing.T) {
+			s := &fakeSuggester{suggestions: testSuggestions, fail: tc.fail}
+			var seeds []*ytsgo.Movie
+			for _, id := range tc.seeds {
+				seeds = append(seeds, movie(id))
+			}
+			g, err := Crawl(s, seeds, tc.opts...)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("Unexpected error, got %v want %v", err, tc.wantErr)
+			}
+			if diff := cmp.Diff(tc.wantNodes, ids(g.Nodes())); diff != "" {
+				t.Errorf("Unexpected nodes, diff -want +got\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantEdges, g.Edges()); diff != "" {
+				t.Errorf("Unexpected edges, diff -want +got\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantCalls, s.calls); diff != "" {
+				t.Errorf("Unexpected calls, diff -want +got\n%s", diff)
+			}
+		})
+	}
+}
+
+func crawlAll(t *testing.T) *Graph {
+	t.Helper()
+	g, err := Crawl(&fakeSuggester{suggestions: testSuggestions}, []*ytsgo.Movie{movie(1), movie(10)}, MaxDepth(10))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return g
+}
+
+func TestQueries(t *testing.T) {
+	g := crawlAll(t)
+
+	var most []string
+	for _, r := range g.MostSuggested(2) {
+		most = append(most, fmt.Sprintf("%d:%d", r.ID, r.Count))
+	}
+	if diff := cmp.Diff([]string{"4:3", "3:2"}, most); diff != "" {
+		t.Errorf("Unexpected most suggested, diff -want +got\n%s", diff)
+	}
+	for _, n := range []int{0, -1} {
+		if got, want := len(g.MostSuggested(n)), 8; got != want {
+			t.Errorf("MostSuggested(%d) returned %d movies, want all %d", n, got, want)
+		}
+	}
+
+	var clusters [][]uint
+	for _, c := range g.Clusters() {
+		clusters = append(clusters, ids(c))
+	}
+	if diff := cmp.Diff([][]uint{{1, 2, 3, 4, 5, 6, 10, 11}}, clusters); diff != "" {
+		t.Errorf("Unexpected clusters, diff -want +got\n%s", diff)
+	}
+
+	if diff := cmp.Diff([]uint{10, 4, 5, 6}, ids(g.ShortestPath(10, 6))); diff != "" {
+		t.Errorf("Unexpected path, diff -want +got\n%s", diff)
+	}
+	if diff := cmp.Diff([]uint{3, 1, 2}, ids(g.ShortestPath(3, 2))); diff != "" {
+		t.Errorf("Unexpected path, diff -want +got\n%s", diff)
+	}
+	if p := g.ShortestPath(6, 1); p != nil {
+		t.Errorf("Unexpected path from 6 to 1: %v", ids(p))
+	}
+	if p := g.ShortestPath(1, 1); len(p) != 1 {
+		t.Errorf("Unexpected path from 1 to itself: %v", ids(p))
+	}
+	if n := g.Find("movie 11"); n == nil || n.ID != 11 {
+		t.Errorf("Unexpected result of Find: %+v", n)
+	}
+	if diff := cmp.Diff([]uint{2, 3, 10}, ids(g.SuggestedBy(4))); diff != "" {
+		t.Errorf("Unexpected suggested by, diff -want +got\n%s", diff)
+	}
+}
+
+func TestClustersDisconnected(t *testing.T) {
+	g := New()
+	for _, id := range []uint{1, 2, 3, 4, 5} {
+		g.AddNode(&Node{ID: id})
+	}
+	g.AddEdge(1, 2)
+	g.AddEdge(4, 3)
+	g.AddEdge(5, 3)
+	var clusters [][]uint
+	for _, c := range g.Clusters() {
+		clusters = append(clusters, ids(c))
+	}
+	if diff := cmp.Diff([][]uint{{3, 4, 5}, {1, 2}}, clusters); diff != "" {
+		t.Errorf("Unexpected clusters, diff -want +got\n%s", diff)
+	}
+}
+
+func TestWriteDOT(t *testing.T) {
+	g := New()
+	g.AddNode(&Node{ID: 1, Title: "The Matrix", Year: 1999})
+	g.AddNode(&Node{ID: 2, Title: `Say "Hi"`})
+	g.AddEdge(1, 2)
+	var buf bytes.Buffer
+	if err := g.WriteDOT(&buf); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := "digraph suggestions {\n\t1 [label=\"The Matrix (1999)\"];\n\t2 [label=\"Say \\\"Hi\\\"\"];\n\t1 -> 2;\n}\n"
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("Unexpected DOT, diff -want +got\n%s", diff)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	g := crawlAll(t)
+	data, err := json.Marshal(g)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	got := New()
+	if err := json.Unmarshal(data, got); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(g.Nodes(), got.Nodes()); diff != "" {
+		t.Errorf("Unexpected nodes, diff -want +got\n%s", diff)
+	}
+	if diff := cmp.Diff(g.Edges(), got.Edges()); diff != "" {
+		t.Errorf("Unexpected edges, diff -want +got\n%s", diff)
+	}
+	if err := json.Unmarshal([]byte(`{"nodes": [{"id": 1}], "edges": [{"from": 1, "to": 2}]}`), got); err == nil {
+		t.Error("Expected an error for edge to unknown node")
+	}
+	if err := json.Unmarshal([]byte(`{"nodes": [null, {"id": 1}, {"id": 2}], "edges": [{"from": 1, "to": 2}]}`), got); err != nil {
+		t.Fatalf("Unexpected error for null node: %v", err)
+	}
+	if diff := cmp.Diff([]uint{1, 2}, ids(got.Nodes())); diff != "" {
+		t.Errorf("Unexpected nodes, diff -want +got\n%s", diff)
+	}
+}
+
+func TestCrawlNilMovies(t *testing.T) {
+	s := &fakeSuggester{suggestions: map[int][]int{1: {0, 2}, 2: {0}}}
+	g, err := Crawl(s, []*ytsgo.Movie{nil, movie(1)})
+	if err != nil {
+		t.Fatalf("Crawl() failed: %v", err)
+	}
+	if diff := cmp.Diff([]uint{1, 2}, ids(g.Nodes())); diff != "" {
+		t.Errorf("Unexpected nodes, diff -want +got\n%s", diff)
+	}
+}
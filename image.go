@@ -0,0 +1,303 @@
+package ytsgo
+
+// File image.go contains downloading, caching and resizing of movie and cast images.
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // Register the GIF decoder.
+	"image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/qopher/ytsgo/internal/fsutil"
+)
+
+// DefaultImageConcurrency is a default maximal number of concurrent image downloads.
+var DefaultImageConcurrency = 4
+
+// ErrNotImage is returned when the server responds with something else than
+// a JPEG, PNG or GIF image.
+var ErrNotImage = errors.New("response is not an image")
+
+// maxThumbnailPixels limits the size of images decoded by Thumbnail.
+const maxThumbnailPixels = 1 << 26
+
+// imageTypes are content types of supported images and extensions of cached files.
+var imageTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// ImageCacheDir makes the Client cache downloaded images and thumbnails in
+// dir, keyed by URL. By default images are not cached.
+func ImageCacheDir(dir string) ClientOption {
+	return func(c *Client) {
+		c.imageCacheDir = dir
+	}
+}
+
+// ImageConcurrency overrides DefaultImageConcurrency.
+func ImageConcurrency(n int) ClientOption {
+	return func(c *Client) {
+		c.imageConcurrency = n
+	}
+}
+
+// Image is a downloaded image.
+type Image struct {
+	URL *url.URL
+	// ContentType is one of image/jpeg, image/png or image/gif.
+	ContentType string
+	Data        []byte
+	// Path is the file of the cached image, empty if the Client has no cache.
+	Path string
+	// Cached is true if the image was read from the cache.
+	Cached bool
+}
+
+// Decode decodes the image.
+func (i *Image) Decode() (image.Image, error) {
+	img, _, err := image.Decode(bytes.NewReader(i.Data))
+	return img, err
+}
+
+// ImageURLs returns unique URLs of all images of the movie, including
+// screenshots and cast images.
+func (m *Movie) ImageURLs() []*url.URL {
+	urls := []*url.URL{m.SmallCoverImage, m.MediumCoverImage, m.LargeCoverImage, m.BackgroundImage, m.BackgroundImageOriginal}
+	urls = append(urls, m.MediumScreenshotImages...)
+	urls = append(urls, m.LargeScreenshotImages...)
+	for _, c := range m.Cast {
+		if c != nil {
+			urls = append(urls, c.URLSmallImage)
+		}
+	}
+	var ret []*url.URL
+	seen := map[string]bool{}
+	for _, u := range urls {
+		if u == nil || u.String() == "" || seen[u.String()] {
+			continue
+		}
+		seen[u.String()] = true
+		ret = append(ret, u)
+	}
+	return ret
+}
+
+// Image downloads the image at u, or reads it from the cache.
+func (c *Client) Image(u *url.URL) (*Image, error) {
+	if u == nil || u.String() == "" {
+		return nil, fmt.Errorf("no image URL")
+	}
+	if img := c.cachedImage(u.String()); img != nil {
+		img.URL = u
+		return img, nil
+	}
+	c.imageSem <- struct{}{}
+	img, err := c.fetchImage(u)
+	<-c.imageSem
+	if err != nil {
+		return nil, err
+	}
+	if err := c.cacheImage(u.String(), img); err != nil {
+		return nil, err
+	}
+	return img, nil
+}
+
+// Images downloads all images concurrently, at most ImageConcurrency at once.
+// Images are returned in the order of urls. The first error is returned if any download fails.
+func (c *Client) Images(urls ...*url.URL) ([]*Image, error) {
+	ret := make([]*Image, len(urls))
+	errs := make([]error, len(urls))
+	var wg sync.WaitGroup
+	for i, u := range urls {
+		wg.Add(1)
+		go func(i int, u *url.URL) {
+			defer wg.Done()
+			ret[i], errs[i] = c.Image(u)
+		}(i, u)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return ret, err
+		}
+	}
+	return ret, nil
+}
+
+// Thumbnail returns the image at u scaled down to fit in maxWidth x maxHeight,
+// keeping the aspect ratio. Images are never scaled up. Thumbnails are PNG
+// for PNG images and JPEG otherwise, and are cached like images.
+func (c *Client) Thumbnail(u *url.URL, maxWidth, maxHeight int) (*Image, error) {
+	if maxWidth <= 0 || maxHeight <= 0 {
+		return nil, fmt.Errorf("invalid thumbnail size %dx%d", maxWidth, maxHeight)
+	}
+	key := fmt.Sprintf("%s#%dx%d", u, maxWidth, maxHeight)
+	if img := c.cachedImage(key); img != nil {
+		img.URL = u
+		return img, nil
+	}
+	orig, err := c.Image(u)
+	if err != nil {
+		return nil, err
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(orig.Data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image %s: %v", u, err)
+	}
+	if cfg.Width*cfg.Height > maxThumbnailPixels {
+		return nil, fmt.Errorf("image %s is too large: %dx%d", u, cfg.Width, cfg.Height)
+	}
+	src, err := orig.Decode()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image %s: %v", u, err)
+	}
+	thumb := &Image{URL: u, ContentType: "image/jpeg"}
+	var buf bytes.Buffer
+	dst := resize(src, maxWidth, maxHeight)
+	if orig.ContentType == "image/png" {
+		thumb.ContentType = "image/png"
+		err = png.Encode(&buf, dst)
+	} else {
+		err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85})
+	}
+	if err != nil {
+		return nil, err
+	}
+	thumb.Data = buf.Bytes()
+	if err := c.cacheImage(key, thumb); err != nil {
+		return nil, err
+	}
+	return thumb, nil
+}
+
+func (c *Client) fetchImage(u *url.URL) (*Image, error) {
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "image/*")
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+	rsp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		return nil, &StatusError{StatusCode: rsp.StatusCode, Status: rsp.Status}
+	}
+	data, err := readBody(rsp, c.maxResponseSize)
+	if err != nil {
+		return nil, err
+	}
+	// The header has to agree with the content, so that error pages served
+	// as images and images served as octet streams are both handled.
+	ct, _, _ := mime.ParseMediaType(rsp.Header.Get("Content-Type"))
+	sniffed := http.DetectContentType(data)
+	if _, ok := imageTypes[sniffed]; !ok {
+		return nil, ErrNotImage
+	}
+	if ct != "" && ct != sniffed && ct != "application/octet-stream" && !(ct == "image/jpg" && sniffed == "image/jpeg") {
+		return nil, ErrNotImage
+	}
+	return &Image{URL: u, ContentType: sniffed, Data: data}, nil
+}
+
+// imagePath returns the cache file of key without extension.
+func (c *Client) imagePath(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.imageCacheDir, hex.EncodeToString(sum[:]))
+}
+
+func (c *Client) cachedImage(key string) *Image {
+	if c.imageCacheDir == "" {
+		return nil
+	}
+	p := c.imagePath(key)
+	for ct, ext := range imageTypes {
+		data, err := ioutil.ReadFile(p + ext)
+		if err == nil {
+			return &Image{ContentType: ct, Data: data, Path: p + ext, Cached: true}
+		}
+	}
+	return nil
+}
+
+func (c *Client) cacheImage(key string, img *Image) error {
+	if c.imageCacheDir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(c.imageCacheDir, 0755); err != nil {
+		return err
+	}
+	p := c.imagePath(key) + imageTypes[img.ContentType]
+	if err := fsutil.WriteFile(p, img.Data); err != nil {
+		return err
+	}
+	img.Path = p
+	return nil
+}
+
+// resize scales src down to fit in maxWidth x maxHeight averaging the source
+// pixels covered by every destination pixel (box filter).
+func resize(src image.Image, maxWidth, maxHeight int) image.Image {
+	b := src.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	if (sw <= maxWidth && sh <= maxHeight) || sw == 0 || sh == 0 {
+		return src
+	}
+	dw, dh := maxWidth, sh*maxWidth/sw
+	if dh > maxHeight {
+		dw, dh = sw*maxHeight/sh, maxHeight
+	}
+	if dw < 1 {
+		dw = 1
+	}
+	if dh < 1 {
+		dh = 1
+	}
+	dst := image.NewRGBA64(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		y0, y1 := b.Min.Y+y*sh/dh, b.Min.Y+(y+1)*sh/dh
+		if y1 == y0 {
+			y1++
+		}
+		for x := 0; x < dw; x++ {
+			x0, x1 := b.Min.X+x*sw/dw, b.Min.X+(x+1)*sw/dw
+			if x1 == x0 {
+				x1++
+			}
+			// Premultiplied values are averaged, so transparent pixels do not darken edges.
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r += uint64(cr)
+					g += uint64(cg)
+					bl += uint64(cb)
+					a += uint64(ca)
+					n++
+				}
+			}
+			dst.SetRGBA64(x, y, color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(bl / n), A: uint16(a / n)})
+		}
+	}
+	return dst
+}
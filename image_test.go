@@ -0,0 +1,295 @@
+package ytsgo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func encodedImage(t *testing.T, format string, w, h int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 100, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	var err error
+	if format == "png" {
+		err = png.Encode(&buf, img)
+	} else {
+		err = jpeg.Encode(&buf, img, nil)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+type imageServer struct {
+	mu          sync.Mutex
+	files       map[string][]byte
+	types       map[string]string
+	hits        int32
+	inFlight    int32
+	maxInFlight int32
+	delay       time.Duration
+}
+
+func (s *imageServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	atomic.AddInt32(&s.hits, 1)
+	n := atomic.AddInt32(&s.inFlight, 1)
+	defer atomic.AddInt32(&s.inFlight, -1)
+	s.mu.Lock()
+	if n > s.maxInFlight {
+		s.maxInFlight = n
+	}
+	s.mu.Unlock()
+	time.Sleep(s.delay)
+	data, ok := s.files[r.URL.Path]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", s.types[r.URL.Path])
+	w.Write(data)
+}
+
+// hugePNG returns a small PNG whose header claims 50000x50000 pixels.
+func hugePNG(t *testing.T) []byte {
+	t.Helper()
+	data := encodedImage(t, "png", 10, 10)
+	// The IHDR chunk follows the 8 byte signature: length, type, width,
+	// height, 5 more bytes of data and the CRC of type and data.
+	binary.BigEndian.PutUint32(data[16:], 50000)
+	binary.BigEndian.PutUint32(data[20:], 50000)
+	binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+	return data
+}
+
+func newImageServer(t *testing.T) *imageServer {
+	return &imageServer{
+		files: map[string][]byte{
+			"/cover.jpg":      encodedImage(t, "jpeg", 230, 345),
+			"/background.png": encodedImage(t, "png", 400, 200),
+			"/octet.png":      encodedImage(t, "png", 10, 10),
+			"/challenge.jpg":  []byte("<html><title>Just a moment...</title></html>"),
+			"/mislabeled.jpg": encodedImage(t, "png", 10, 10),
+			"/huge.png":       hugePNG(t),
+		},
+		types: map[string]string{
+			"/cover.jpg":      "image/jpeg",
+			"/background.png": "image/png",
+			"/octet.png":      "application/octet-stream",
+			"/challenge.jpg":  "image/jpeg",
+			"/mislabeled.jpg": "image/jpeg",
+			"/huge.png":       "image/png",
+		},
+	}
+}
+
+func TestImage(t *testing.T) {
+	s := newImageServer(t)
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+	c, err := New(HTTPTimeout(5 * time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testData := []struct {
+		path     string
+		wantType string
+		wantErr  bool
+	}{
+		{path: "/cover.jpg", wantType: "image/jpeg"},
+		{path: "/background.png", wantType: "image/png"},
+		{path: "/octet.png", wantType: "image/png"},
+		{path: "/challenge.jpg", wantErr: true},
+		{path: "/mislabeled.jpg", wantErr: true},
+		{path: "/missing.jpg", wantErr: true},
+	}
+	for _, tc := range testData {
+		t.Run(tc.path, func(t *testing.T) {
+			img, err := c.Image(mustURL(ts.URL+tc.path, t))
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Image() unexpected error, got %v want %v", err, tc.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if img.ContentType != tc.wantType {
+				t.Errorf("Unexpected content type, got %q want %q", img.ContentType, tc.wantType)
+			}
+			if !bytes.Equal(img.Data, s.files[tc.path]) {
+				t.Error("Unexpected image data")
+			}
+			if img.Path != "" || img.Cached {
+				t.Errorf("Image without cache has path %q, cached %v", img.Path, img.Cached)
+			}
+		})
+	}
+	if _, err := c.Image(mustURL(ts.URL+"/challenge.jpg", t)); err != ErrNotImage {
+		t.Errorf("Unexpected error, got %v want %v", err, ErrNotImage)
+	}
+}
+
+func TestImageCache(t *testing.T) {
+	s := newImageServer(t)
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+	dir := t.TempDir()
+	u := mustURL(ts.URL+"/cover.jpg", t)
+	for i, wantCached := range []bool{false, true} {
+		// A new Client reads images cached by the previous one.
+		c, err := New(ImageCacheDir(dir))
+		if err != nil {
+			t.Fatal(err)
+		}
+		img, err := c.Image(u)
+		if err != nil {
+			t.Fatalf("Image() failed: %v", err)
+		}
+		if img.Cached != wantCached || img.Path == "" || img.URL != u {
+			t.Errorf("Call %d: unexpected image cached %v path %q", i, img.Cached, img.Path)
+		}
+		if !bytes.Equal(img.Data, s.files["/cover.jpg"]) {
+			t.Errorf("Call %d: unexpected image data", i)
+		}
+	}
+	if got := atomic.LoadInt32(&s.hits); got != 1 {
+		t.Errorf("Unexpected number of downloads, got %d want 1", got)
+	}
+}
+
+func TestImages(t *testing.T) {
+	s := newImageServer(t)
+	s.delay = 20 * time.Millisecond
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+	c, err := New(ImageConcurrency(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var urls []*url.URL
+	for i := 0; i < 6; i++ {
+		urls = append(urls, mustURL(ts.URL+"/cover.jpg?i="+strconv.Itoa(i), t))
+	}
+	imgs, err := c.Images(urls...)
+	if err != nil {
+		t.Fatalf("Images() failed: %v", err)
+	}
+	for i, img := range imgs {
+		if img.URL != urls[i] {
+			t.Errorf("Image %d has URL %v want %v", i, img.URL, urls[i])
+		}
+	}
+	if s.maxInFlight > 2 {
+		t.Errorf("Unexpected number of concurrent downloads, got %d want at most 2", s.maxInFlight)
+	}
+	if _, err := c.Images(mustURL(ts.URL+"/cover.jpg", t), mustURL(ts.URL+"/missing.jpg", t)); err == nil {
+		t.Error("Images() with missing image succeeded, want error")
+	}
+}
+
+func TestThumbnail(t *testing.T) {
+	s := newImageServer(t)
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+	c, err := New(ImageCacheDir(t.TempDir()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testData := []struct {
+		path      string
+		w, h      int
+		wantType  string
+		wantW     int
+		wantH     int
+		wantError bool
+	}{
+		{path: "/cover.jpg", w: 100, h: 100, wantType: "image/jpeg", wantW: 66, wantH: 100},
+		{path: "/background.png", w: 100, h: 100, wantType: "image/png", wantW: 100, wantH: 50},
+		{path: "/octet.png", w: 100, h: 100, wantType: "image/png", wantW: 10, wantH: 10},
+		{path: "/cover.jpg", w: 0, h: 100, wantError: true},
+		{path: "/huge.png", w: 100, h: 100, wantError: true},
+	}
+	for _, tc := range testData {
+		thumb, err := c.Thumbnail(mustURL(ts.URL+tc.path, t), tc.w, tc.h)
+		if (err != nil) != tc.wantError {
+			t.Fatalf("Thumbnail(%s, %d, %d) unexpected error, got %v want %v", tc.path, tc.w, tc.h, err, tc.wantError)
+		}
+		if err != nil {
+			continue
+		}
+		if thumb.ContentType != tc.wantType {
+			t.Errorf("Thumbnail(%s) unexpected content type, got %q want %q", tc.path, thumb.ContentType, tc.wantType)
+		}
+		img, err := thumb.Decode()
+		if err != nil {
+			t.Fatalf("Failed to decode thumbnail: %v", err)
+		}
+		if b := img.Bounds(); b.Dx() != tc.wantW || b.Dy() != tc.wantH {
+			t.Errorf("Thumbnail(%s) unexpected size, got %dx%d want %dx%d", tc.path, b.Dx(), b.Dy(), tc.wantW, tc.wantH)
+		}
+	}
+	hits := atomic.LoadInt32(&s.hits)
+	thumb, err := c.Thumbnail(mustURL(ts.URL+"/cover.jpg", t), 100, 100)
+	if err != nil || !thumb.Cached {
+		t.Errorf("Thumbnail() was not cached, err %v", err)
+	}
+	if got := atomic.LoadInt32(&s.hits); got != hits {
+		t.Errorf("Cached thumbnail downloaded the image again")
+	}
+}
+
+func TestResize(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	for x := 0; x < 4; x++ {
+		src.Set(x, 0, color.RGBA{R: 255, A: 255})
+		src.Set(x, 1, color.RGBA{B: 255, A: 255})
+	}
+	dst := resize(src, 2, 2)
+	if b := dst.Bounds(); b.Dx() != 2 || b.Dy() != 1 {
+		t.Fatalf("Unexpected size %v", b)
+	}
+	r, g, b, a := dst.At(0, 0).RGBA()
+	if r>>8 != 127 || g != 0 || b>>8 != 127 || a>>8 != 255 {
+		t.Errorf("Unexpected averaged color %d %d %d %d", r>>8, g>>8, b>>8, a>>8)
+	}
+}
+
+func TestImageURLs(t *testing.T) {
+	m := &Movie{
+		SmallCoverImage:       mustURL("https://yts.lt/small.jpg", t),
+		LargeCoverImage:       mustURL("https://yts.lt/large.jpg", t),
+		BackgroundImage:       mustURL("https://yts.lt/large.jpg", t),
+		LargeScreenshotImages: []*url.URL{mustURL("https://yts.lt/screenshot.jpg", t)},
+		Cast:                  []*Cast{{Name: "A", URLSmallImage: mustURL("https://yts.lt/a.jpg", t)}, {Name: "B"}},
+	}
+	var got []string
+	for _, u := range m.ImageURLs() {
+		got = append(got, u.String())
+	}
+	want := []string{"https://yts.lt/small.jpg", "https://yts.lt/large.jpg", "https://yts.lt/screenshot.jpg", "https://yts.lt/a.jpg"}
+	if len(got) != len(want) {
+		t.Fatalf("Unexpected URLs %v want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Unexpected URL %d, got %q want %q", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,54 @@
+// Package fsutil contains file helpers shared by ytsgo packages.
+package fsutil
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Write atomically replaces the file at path with the content written by fn.
+// The content is written to a hidden temporary file in the same directory,
+// so that readers, eg. torrent clients watching the directory, never see a
+// partial file. The file is readable by everyone.
+func Write(path string, fn func(w io.Writer) error) error {
+	f, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if err := fn(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	// TempFile creates files readable only by the owner.
+	if err := os.Chmod(f.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// WriteFile atomically replaces the file at path with data.
+func WriteFile(path string, data []byte) error {
+	return Write(path, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// SanitizeName replaces characters not allowed in file names on common file
+// systems and strips leading dots, so that the name is not hidden.
+func SanitizeName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r < 32 || strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	return strings.TrimLeft(strings.TrimSpace(name), ".")
+}
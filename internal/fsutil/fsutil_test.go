@@ -0,0 +1,69 @@
+package fsutil
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+	for _, data := range []string{"first", "second"} {
+		if err := WriteFile(path, []byte(data)); err != nil {
+			t.Fatalf("WriteFile() failed: %v", err)
+		}
+		got, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != data {
+			t.Errorf("Unexpected content, got %q want %q", got, data)
+		}
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fi.Mode().Perm(); got != 0644 {
+		t.Errorf("Unexpected mode, got %v want %v", got, os.FileMode(0644))
+	}
+
+	want := errors.New("some error")
+	err = Write(path, func(w io.Writer) error {
+		io.WriteString(w, "partial")
+		return want
+	})
+	if err != want {
+		t.Errorf("Write() unexpected error, got %v want %v", err, want)
+	}
+	if got, _ := ioutil.ReadFile(path); string(got) != "second" {
+		t.Errorf("Failed write replaced the file with %q", got)
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Errorf("Temporary files were left in the directory: %d files", len(files))
+	}
+}
+
+func TestSanitizeName(t *testing.T) {
+	testData := []struct {
+		in, want string
+	}{
+		{in: "The Matrix (1999)", want: "The Matrix (1999)"},
+		{in: "Face/Off: Part 1?", want: "Face_Off_ Part 1_"},
+		{in: " ...Hidden ", want: "Hidden"},
+		{in: "a\nb", want: "a_b"},
+	}
+	for _, tc := range testData {
+		if got := SanitizeName(tc.in); got != tc.want {
+			t.Errorf("SanitizeName(%q) = %q want %q", tc.in, got, tc.want)
+		}
+	}
+}
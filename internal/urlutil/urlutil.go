@@ -0,0 +1,12 @@
+// Package urlutil contains URL helpers shared by ytsgo packages.
+package urlutil
+
+import "net/url"
+
+// String returns u as a string, or an empty string if u is nil.
+func String(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	return u.String()
+}
@@ -0,0 +1,236 @@
+// Package library matches a local media library with YTS movies. It scans a
+// directory tree for video files, parses their release-style names and
+// reports which movies are owned, missing or can be upgraded to a better quality.
+package library
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/qopher/ytsgo"
+	"github.com/qopher/ytsgo/release"
+)
+
+// File is a video file found by Scan.
+type File struct {
+	Path string
+	release.Release
+}
+
+// Scan walks the directory tree of root and returns video files. Missing
+// title or year is taken from the name of the parent folder and IMDb codes
+// are also looked up in .nfo files next to the video. Samples are skipped.
+func Scan(root string) ([]*File, error) {
+	var files []*File
+	err := filepath.Walk(root, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() || !release.IsVideo(path) {
+			return nil
+		}
+		if strings.Contains(strings.ToLower(fi.Name()), "sample") {
+			return nil
+		}
+		f := &File{Path: path, Release: release.Parse(fi.Name())}
+		dir := filepath.Dir(path)
+		if dir != filepath.Clean(root) {
+			parent := release.Parse(filepath.Base(dir))
+			if (f.Year == 0 && parent.Year != 0) || f.Title == "" {
+				f.Title, f.Year = parent.Title, parent.Year
+			}
+			if f.Quality == "" {
+				f.Quality = parent.Quality
+			}
+			if f.IMDBCode == "" {
+				f.IMDBCode = parent.IMDBCode
+			}
+			if f.Group == "" {
+				f.Group = parent.Group
+			}
+		}
+		if f.IMDBCode == "" {
+			f.IMDBCode = nfoIMDBCode(dir)
+		}
+		files = append(files, f)
+		return nil
+	})
+	return files, err
+}
+
+// nfoIMDBCode returns the IMDb code found in .nfo files of the directory.
+func nfoIMDBCode(dir string) string {
+	nfos, _ := filepath.Glob(filepath.Join(dir, "*.nfo"))
+	for _, p := range nfos {
+		data, err := ioutil.ReadFile(p)
+		if err != nil {
+			continue
+		}
+		if code := release.IMDBCode(string(data)); code != "" {
+			return code
+		}
+	}
+	return ""
+}
+
+// Searcher searches movies. It is implemented by *ytsgo.Client.
+type Searcher interface {
+	ListMovies(opts ...ytsgo.ListMoviesOption) (*ytsgo.Movies, error)
+}
+
+// Owned is a YTS movie found in the library.
+type Owned struct {
+	Movie *ytsgo.Movie
+	Files []*File
+	// Quality is the best quality of the Files.
+	Quality string
+	// Upgrade is the best torrent of the movie if its quality is better than
+	// Quality, nil otherwise.
+	Upgrade *ytsgo.Torrent
+}
+
+// Report is a result of matching files with YTS movies.
+type Report struct {
+	// Owned movies, sorted by title.
+	Owned []*Owned
+	// Unmatched are files which did not match any movie.
+	Unmatched []*File
+}
+
+// Match searches YTS for every file, by IMDb code if known or by title
+// otherwise, and groups files by matched movies. Identical searches are sent once.
+func Match(s Searcher, files []*File) (*Report, error) {
+	r := &Report{}
+	searches := make(map[string][]*ytsgo.Movie)
+	owned := make(map[uint]*Owned)
+	for _, f := range files {
+		query := f.IMDBCode
+		if query == "" {
+			query = f.Title
+		}
+		if query == "" {
+			r.Unmatched = append(r.Unmatched, f)
+			continue
+		}
+		movies, ok := searches[query]
+		if !ok {
+			mvs, err := s.ListMovies(ytsgo.LMSearch(query))
+			if err != nil {
+				return nil, err
+			}
+			if mvs != nil {
+				movies = mvs.Movies
+			}
+			searches[query] = movies
+		}
+		m := match(f, movies)
+		if m == nil {
+			r.Unmatched = append(r.Unmatched, f)
+			continue
+		}
+		o, ok := owned[m.ID]
+		if !ok {
+			o = &Owned{Movie: m}
+			owned[m.ID] = o
+			r.Owned = append(r.Owned, o)
+		}
+		o.Files = append(o.Files, f)
+		if o.Quality == "" || ytsgo.QualityRank(f.Quality) > ytsgo.QualityRank(o.Quality) {
+			o.Quality = f.Quality
+		}
+	}
+	for _, o := range r.Owned {
+		if rank := ytsgo.QualityRank(o.Quality); rank > 0 {
+			if best := ytsgo.BestTorrent(o.Movie.Torrents); best != nil && ytsgo.QualityRank(best.Quality) > rank {
+				o.Upgrade = best
+			}
+		}
+	}
+	sort.Slice(r.Owned, func(i, j int) bool { return r.Owned[i].Movie.Title < r.Owned[j].Movie.Title })
+	return r, nil
+}
+
+// match returns the movie matching the file. IMDb codes match exactly,
+// titles match after normalization if the years differ by at most one.
+func match(f *File, movies []*ytsgo.Movie) *ytsgo.Movie {
+	var best *ytsgo.Movie
+	title := normalizeTitle(f.Title)
+	for _, m := range movies {
+		if m == nil {
+			continue
+		}
+		if f.IMDBCode != "" {
+			if strings.EqualFold(m.IMDBCode, f.IMDBCode) {
+				return m
+			}
+			continue
+		}
+		if normalizeTitle(m.Title) != title && normalizeTitle(m.TitleEnglish) != title {
+			continue
+		}
+		if f.Year == 0 || m.Year == f.Year {
+			return m
+		}
+		if best == nil && (m.Year == f.Year+1 || m.Year+1 == f.Year) {
+			best = m
+		}
+	}
+	return best
+}
+
+// Upgradeable returns owned movies with a better quality available.
+func (r *Report) Upgradeable() []*Owned {
+	var ret []*Owned
+	for _, o := range r.Owned {
+		if o.Upgrade != nil {
+			ret = append(ret, o)
+		}
+	}
+	return ret
+}
+
+// Owns reports whether the movie with given ID is owned.
+func (r *Report) Owns(id uint) bool {
+	for _, o := range r.Owned {
+		if o.Movie.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Missing returns movies which are not owned, eg. of a list or a catalog.
+func (r *Report) Missing(movies []*ytsgo.Movie) []*ytsgo.Movie {
+	owned := make(map[uint]bool)
+	for _, o := range r.Owned {
+		owned[o.Movie.ID] = true
+	}
+	var ret []*ytsgo.Movie
+	for _, m := range movies {
+		if m != nil && !owned[m.ID] {
+			ret = append(ret, m)
+		}
+	}
+	return ret
+}
+
+// normalizeTitle returns the title used to compare names, lower case
+// without punctuation and leading articles.
+func normalizeTitle(title string) string {
+	title = strings.ToLower(title)
+	title = strings.Replace(title, "&", "and", -1)
+	title = strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r > 127 {
+			return r
+		}
+		return ' '
+	}, title)
+	fields := strings.Fields(title)
+	if len(fields) > 1 && (fields[0] == "the" || fields[0] == "a" || fields[0] == "an") {
+		fields = fields[1:]
+	}
+	return strings.Join(fields, " ")
+}
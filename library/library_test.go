@@ -0,0 +1,146 @@
+package library
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qopher/ytsgo"
+	"github.com/qopher/ytsgo/release"
+)
+
+type fakeSearcher struct {
+	movies  []*ytsgo.Movie
+	queries []string
+}
+
+func (f *fakeSearcher) ListMovies(opts ...ytsgo.ListMoviesOption) (*ytsgo.Movies, error) {
+	v := make(map[string][]string)
+	for _, o := range opts {
+		o(v)
+	}
+	q := v["query_term"][0]
+	f.queries = append(f.queries, q)
+	var ret []*ytsgo.Movie
+	for _, m := range f.movies {
+		if m.IMDBCode == q || normalizeTitle(m.Title) == normalizeTitle(q) {
+			ret = append(ret, m)
+		}
+	}
+	return &ytsgo.Movies{MovieCount: uint(len(ret)), Movies: ret}, nil
+}
+
+func movie(id uint, title string, year uint, imdb string, qualities ...string) *ytsgo.Movie {
+	m := &ytsgo.Movie{ID: id, Title: title, Year: year, IMDBCode: imdb}
+	for _, q := range qualities {
+		m.Torrents = append(m.Torrents, &ytsgo.Torrent{Hash: imdb + q, Quality: q})
+	}
+	return m
+}
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for p, content := range files {
+		p = filepath.Join(root, filepath.FromSlash(p))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestScan(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"The Matrix (1999) [1080p] [BluRay] [YTS.LT]/The.Matrix.1999.1080p.BluRay.x264-[YTS.LT].mp4": "",
+		"The Matrix (1999) [1080p] [BluRay] [YTS.LT]/sample.mp4":                                     "",
+		"Heat (1995)/movie.mkv":       "",
+		"Heat (1995)/movie.nfo":       "https://www.imdb.com/title/tt0113277/",
+		"Alien.1979.720p.avi":         "",
+		"Alien.1979.720p.srt":         "",
+		"Unknown/home video 720p.mkv": "",
+	})
+	files, err := Scan(root)
+	if err != nil {
+		t.Fatalf("Scan() failed: %v", err)
+	}
+	// scanned are the fields of File used by Match.
+	type scanned struct {
+		Title, Quality, IMDBCode, Group string
+		Year                            uint
+	}
+	got := make(map[string]scanned)
+	for _, f := range files {
+		rel, _ := filepath.Rel(root, f.Path)
+		got[filepath.ToSlash(rel)] = scanned{Title: f.Title, Quality: f.Quality, IMDBCode: f.IMDBCode, Group: f.Group, Year: f.Year}
+	}
+	want := map[string]scanned{
+		"Alien.1979.720p.avi":   {Title: "Alien", Year: 1979, Quality: "720p"},
+		"Heat (1995)/movie.mkv": {Title: "Heat", Year: 1995, IMDBCode: "tt0113277"},
+		"The Matrix (1999) [1080p] [BluRay] [YTS.LT]/The.Matrix.1999.1080p.BluRay.x264-[YTS.LT].mp4": {Title: "The Matrix", Year: 1999, Quality: "1080p", Group: "YTS.LT"},
+		"Unknown/home video 720p.mkv": {Title: "home video", Quality: "720p"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Unexpected files, diff -want +got\n%s", diff)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	matrix := movie(1, "The Matrix", 1999, "tt0133093", "720p", "1080p", "2160p")
+	heat := movie(2, "Heat", 1995, "tt0113277", "720p", "1080p")
+	alien := movie(3, "Alien", 1979, "tt0078748", "720p", "1080p")
+	alien2 := movie(4, "Alien", 2019, "tt9999999", "1080p")
+	s := &fakeSearcher{movies: []*ytsgo.Movie{matrix, heat, alien, alien2}}
+	files := []*File{
+		{Path: "a", Release: release.Release{Title: "The Matrix", Year: 1999, Quality: "1080p"}},
+		{Path: "b", Release: release.Release{Title: "Matrix", Year: 1999, Quality: "720p"}},
+		{Path: "c", Release: release.Release{Title: "Heat", IMDBCode: "tt0113277", Quality: "1080p"}},
+		{Path: "d", Release: release.Release{Title: "Alien", Year: 1980, Quality: "720p"}},
+		{Path: "e", Release: release.Release{Title: "Home video", Quality: "720p"}},
+		{Path: "f", Release: release.Release{}},
+	}
+	r, err := Match(s, files)
+	if err != nil {
+		t.Fatalf("Match() failed: %v", err)
+	}
+	want := &Report{
+		Owned: []*Owned{
+			{Movie: alien, Files: files[3:4], Quality: "720p", Upgrade: alien.Torrents[1]},
+			{Movie: heat, Files: files[2:3], Quality: "1080p"},
+			{Movie: matrix, Files: files[0:2], Quality: "1080p", Upgrade: matrix.Torrents[2]},
+		},
+		Unmatched: []*File{files[4], files[5]},
+	}
+	if diff := cmp.Diff(want, r, cmp.AllowUnexported(ytsgo.Torrent{})); diff != "" {
+		t.Errorf("Unexpected report, diff -want +got\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"The Matrix", "Matrix", "tt0113277", "Alien", "Home video"}, s.queries); diff != "" {
+		t.Errorf("Unexpected searches, diff -want +got\n%s", diff)
+	}
+	if got := len(r.Upgradeable()); got != 2 {
+		t.Errorf("Unexpected number of upgradeable movies, got %d want 2", got)
+	}
+	if !r.Owns(2) || r.Owns(4) {
+		t.Error("Unexpected Owns() result")
+	}
+	if diff := cmp.Diff([]*ytsgo.Movie{alien2}, r.Missing(s.movies), cmp.AllowUnexported(ytsgo.Torrent{})); diff != "" {
+		t.Errorf("Unexpected missing movies, diff -want +got\n%s", diff)
+	}
+}
+
+func TestNormalizeTitle(t *testing.T) {
+	for in, want := range map[string]string{
+		"The Matrix":             "matrix",
+		"Fast & Furious":         "fast and furious",
+		"Léon: The Professional": "léon the professional",
+		"The":                    "the",
+	} {
+		if got := normalizeTitle(in); got != want {
+			t.Errorf("normalizeTitle(%q) = %q want %q", in, got, want)
+		}
+	}
+}
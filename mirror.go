@@ -0,0 +1,70 @@
+package ytsgo
+
+// File mirror.go contains failover between mirrors of the API.
+
+import (
+	"net/http"
+	"net/url"
+	"sync/atomic"
+)
+
+// Mirrors sets base URLs of API mirrors, eg. "https://yts.mx/api/v2/". If a
+// request to the base URL fails with a network error, a server error or a
+// challenge page, the mirrors are tried in order. The mirror which succeeded
+// is tried first by subsequent calls.
+func Mirrors(urls ...string) ClientOption {
+	return func(c *Client) {
+		c.mirrorStrs = urls
+	}
+}
+
+// mirrorSet is an ordered list of base URLs with the one which succeeded last.
+type mirrorSet struct {
+	urls   []*url.URL
+	active uint32
+}
+
+func newMirrorSet(base *url.URL, mirrors []string) (*mirrorSet, error) {
+	m := &mirrorSet{urls: []*url.URL{base}}
+	for _, s := range mirrors {
+		u, err := url.Parse(s)
+		if err != nil {
+			return nil, err
+		}
+		m.urls = append(m.urls, u)
+	}
+	return m, nil
+}
+
+// order returns base URLs starting with the active one.
+func (m *mirrorSet) order() []int {
+	n := len(m.urls)
+	start := int(atomic.LoadUint32(&m.active)) % n
+	ret := make([]int, 0, n)
+	for i := 0; i < n; i++ {
+		ret = append(ret, (start+i)%n)
+	}
+	return ret
+}
+
+func (m *mirrorSet) use(i int) {
+	atomic.StoreUint32(&m.active, uint32(i))
+}
+
+// Mirror returns the base URL the Client currently sends requests to.
+func (c *Client) Mirror() string {
+	return c.mirrors.urls[c.mirrors.order()[0]].String()
+}
+
+// failover reports whether a request which ended with res and err should be
+// retried on the next mirror.
+func failover(res *result, err error) bool {
+	if err != nil {
+		if _, ok := err.(*ContentTypeError); ok {
+			return true
+		}
+		// Network errors have no result, body read errors are not retried.
+		return res == nil
+	}
+	return res.code >= http.StatusInternalServerError
+}
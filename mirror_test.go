@@ -0,0 +1,131 @@
+package ytsgo
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingServer counts requests served by the wrapped handler.
+type countingServer struct {
+	h    http.Handler
+	hits int32
+}
+
+func (s *countingServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	atomic.AddInt32(&s.hits, 1)
+	s.h.ServeHTTP(w, r)
+}
+
+func challengeHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+	w.Header().Set("Server", "cloudflare")
+	w.WriteHeader(http.StatusForbidden)
+	w.Write([]byte("<html>Just a moment...</html>"))
+}
+
+func closedURL(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u := "http://" + l.Addr().String()
+	l.Close()
+	return u
+}
+
+func TestMirrors(t *testing.T) {
+	testData := []struct {
+		desc        string
+		primary     http.Handler
+		closed      bool
+		wantPrimary []int32
+		wantMirror  []int32
+		wantErr     bool
+	}{
+		{
+			desc:        "primary ok",
+			primary:     &fakeYTSServer{data: loadTestData("matrix.json", t)},
+			wantPrimary: []int32{1, 2},
+			wantMirror:  []int32{0, 0},
+		},
+		{
+			desc:        "server error",
+			primary:     &fakeYTSServer{err: errors.New("down")},
+			wantPrimary: []int32{1, 1},
+			wantMirror:  []int32{1, 2},
+		},
+		{
+			desc:        "challenge",
+			primary:     http.HandlerFunc(challengeHandler),
+			wantPrimary: []int32{1, 1},
+			wantMirror:  []int32{1, 2},
+		},
+		{
+			desc:       "network error",
+			closed:     true,
+			wantMirror: []int32{1, 2},
+		},
+		{
+			desc:        "not found is not retried",
+			primary:     http.NotFoundHandler(),
+			wantPrimary: []int32{1, 2},
+			wantMirror:  []int32{0, 0},
+			wantErr:     true,
+		},
+	}
+	for _, tc := range testData {
+		t.Run(tc.desc, func(t *testing.T) {
+			mirror := &countingServer{h: &fakeYTSServer{data: loadTestData("matrix.json", t)}}
+			ms := httptest.NewServer(mirror)
+			defer ms.Close()
+			primary := &countingServer{h: tc.primary}
+			base := closedURL(t)
+			if !tc.closed {
+				ps := httptest.NewServer(primary)
+				defer ps.Close()
+				base = ps.URL
+			}
+			c, err := New(BaseURL(base), Mirrors(ms.URL), HTTPTimeout(time.Second*5))
+			if err != nil {
+				t.Fatalf("Failed to create client: %v", err)
+			}
+			for i := 0; i < 2; i++ {
+				m, err := c.Movie(10)
+				if (err != nil) != tc.wantErr {
+					t.Fatalf("Movie() unexpected error, got %v want %v", err, tc.wantErr)
+				}
+				if !tc.wantErr && m.Title != "The Matrix" {
+					t.Errorf("Unexpected movie %q", m.Title)
+				}
+				if got := atomic.LoadInt32(&mirror.hits); got != tc.wantMirror[i] {
+					t.Errorf("Call %d: unexpected mirror hits, got %d want %d", i, got, tc.wantMirror[i])
+				}
+				if tc.wantPrimary == nil {
+					continue
+				}
+				if got := atomic.LoadInt32(&primary.hits); got != tc.wantPrimary[i] {
+					t.Errorf("Call %d: unexpected primary hits, got %d want %d", i, got, tc.wantPrimary[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMirrorsAllFail(t *testing.T) {
+	c, err := New(BaseURL(closedURL(t)), Mirrors(closedURL(t)), HTTPTimeout(time.Second*5))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if _, err := c.Movie(10); err == nil {
+		t.Error("Movie() succeeded, want error")
+	}
+	if _, err := New(Mirrors("http://[::1")); err == nil {
+		t.Error("New() with invalid mirror succeeded, want error")
+	}
+}
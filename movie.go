@@ -7,48 +7,71 @@ import (
 	"fmt"
 	"net/url"
 	"time"
+
+	"github.com/qopher/ytsgo/internal/urlutil"
 )
 
 // Movie contains information about a single movie from YTS.LT.
 type Movie struct {
-	ID                      uint       `json:"id"`
-	URL                     *url.URL   `json:"-"`
-	IMDBCode                string     `json:"imdb_code"`
-	Title                   string     `json:"title"`
-	TitleEnglish            string     `json:"title_english"`
-	Slug                    string     `json:"slug"`
-	Year                    uint       `json:"year"`
-	Rating                  float32    `json:"rating"`
-	Runtime                 uint       `json:"runtime"`
-	Genres                  []string   `json:"genres"`
-	DownloadCount           uint       `json:"download_count"`
-	LikeCound               uint       `json:"like_count"`
-	DescriptionIntro        string     `json:"description_intro"`
-	DescriptionFull         string     `json:"description_full"`
-	YouTubeTrailerCode      string     `json:"yt_trailer_code"`
-	Language                string     `json:"language"`
-	MPARating               string     `json:"mpa_rating"`
-	BackgroundImage         *url.URL   `json:"-"`
-	BackgroundImageOriginal *url.URL   `json:"-"`
-	SmallCoverImage         *url.URL   `json:"-"`
-	MediumCoverImage        *url.URL   `json:"-"`
-	LargeCoverImage         *url.URL   `json:"-"`
-	DateUploaded            time.Time  `json:"-"`
-	DateUploadedUnix        int64      `json:"date_uploaded_unix"`
-	Torrents                []*Torrent `json:"torrents"`
-	Cast                    []*Cast    `json:"cast"`
+	ID                      uint     `json:"id"`
+	URL                     *url.URL `json:"-"`
+	IMDBCode                string   `json:"imdb_code"`
+	Title                   string   `json:"title"`
+	TitleEnglish            string   `json:"title_english"`
+	Slug                    string   `json:"slug"`
+	Year                    uint     `json:"year"`
+	Rating                  float32  `json:"rating"`
+	Runtime                 uint     `json:"runtime"`
+	Genres                  []string `json:"genres"`
+	DownloadCount           uint     `json:"download_count"`
+	LikeCound               uint     `json:"like_count"`
+	DescriptionIntro        string   `json:"description_intro"`
+	DescriptionFull         string   `json:"description_full"`
+	YouTubeTrailerCode      string   `json:"yt_trailer_code"`
+	Language                string   `json:"language"`
+	MPARating               string   `json:"mpa_rating"`
+	BackgroundImage         *url.URL `json:"-"`
+	BackgroundImageOriginal *url.URL `json:"-"`
+	SmallCoverImage         *url.URL `json:"-"`
+	MediumCoverImage        *url.URL `json:"-"`
+	LargeCoverImage         *url.URL `json:"-"`
+	// MediumScreenshotImages and LargeScreenshotImages are set only if the
+	// movie was fetched with MovieWithImages.
+	MediumScreenshotImages []*url.URL `json:"-"`
+	LargeScreenshotImages  []*url.URL `json:"-"`
+	DateUploaded           time.Time  `json:"-"`
+	DateUploadedUnix       int64      `json:"date_uploaded_unix"`
+	Torrents               []*Torrent `json:"torrents"`
+	Cast                   []*Cast    `json:"cast"`
+	// Warnings lists values which had to be coerced during lenient decoding.
+	Warnings []string `json:"-"`
 }
 
 // UnmarshalJSON unmarshals movie encoded as JSON.
 func (m *Movie) UnmarshalJSON(data []byte) error {
+	return m.unmarshal(data, &decoder{})
+}
+
+func (m *Movie) unmarshal(data []byte, d *decoder) error {
 	type mov Movie
 	aux := &struct {
-		URLRaw       string `json:"url"`
-		BGImgURL     string `json:"background_image"`
-		BGImgURLOrig string `json:"background_image_original"`
-		SCoverImg    string `json:"small_cover_image"`
-		MCoverImg    string `json:"medium_cover_image"`
-		LCoverImg    string `json:"large_cover_image"`
+		URLRaw       *string         `json:"url"`
+		BGImgURL     *string         `json:"background_image"`
+		BGImgURLOrig *string         `json:"background_image_original"`
+		SCoverImg    *string         `json:"small_cover_image"`
+		MCoverImg    *string         `json:"medium_cover_image"`
+		LCoverImg    *string         `json:"large_cover_image"`
+		MScreen1     *string         `json:"medium_screenshot_image1"`
+		MScreen2     *string         `json:"medium_screenshot_image2"`
+		MScreen3     *string         `json:"medium_screenshot_image3"`
+		LScreen1     *string         `json:"large_screenshot_image1"`
+		LScreen2     *string         `json:"large_screenshot_image2"`
+		LScreen3     *string         `json:"large_screenshot_image3"`
+		Year         json.RawMessage `json:"year"`
+		Rating       json.RawMessage `json:"rating"`
+		Runtime      json.RawMessage `json:"runtime"`
+		Torrents     json.RawMessage `json:"torrents"`
+		Cast         json.RawMessage `json:"cast"`
 		*mov
 	}{
 		mov: (*mov)(m),
@@ -56,29 +79,150 @@ func (m *Movie) UnmarshalJSON(data []byte) error {
 	if err := json.Unmarshal(data, aux); err != nil {
 		return err
 	}
+	nums := []struct {
+		field string
+		dest  interface{}
+		raw   json.RawMessage
+	}{
+		{field: "year", dest: &m.Year, raw: aux.Year},
+		{field: "rating", dest: &m.Rating, raw: aux.Rating},
+		{field: "runtime", dest: &m.Runtime, raw: aux.Runtime},
+	}
+	for _, n := range nums {
+		if err := d.number(n.field, n.raw, n.dest); err != nil {
+			return err
+		}
+	}
 	urls := []struct {
-		dest **url.URL
-		str  string
+		field string
+		dest  **url.URL
+		str   *string
 	}{
-		{dest: &m.URL, str: aux.URLRaw},
-		{dest: &m.BackgroundImage, str: aux.BGImgURL},
-		{dest: &m.BackgroundImageOriginal, str: aux.BGImgURLOrig},
-		{dest: &m.SmallCoverImage, str: aux.SCoverImg},
-		{dest: &m.MediumCoverImage, str: aux.MCoverImg},
-		{dest: &m.LargeCoverImage, str: aux.LCoverImg},
+		{field: "url", dest: &m.URL, str: aux.URLRaw},
+		{field: "background_image", dest: &m.BackgroundImage, str: aux.BGImgURL},
+		{field: "background_image_original", dest: &m.BackgroundImageOriginal, str: aux.BGImgURLOrig},
+		{field: "small_cover_image", dest: &m.SmallCoverImage, str: aux.SCoverImg},
+		{field: "medium_cover_image", dest: &m.MediumCoverImage, str: aux.MCoverImg},
+		{field: "large_cover_image", dest: &m.LargeCoverImage, str: aux.LCoverImg},
 	}
 	for _, u := range urls {
-		if err := parseURL(u.dest, u.str); err != nil {
+		if err := d.url(u.field, u.dest, u.str); err != nil {
 			return err
 		}
 	}
+	screenshots := []struct {
+		field string
+		dest  *[]*url.URL
+		str   *string
+	}{
+		{field: "medium_screenshot_image1", dest: &m.MediumScreenshotImages, str: aux.MScreen1},
+		{field: "medium_screenshot_image2", dest: &m.MediumScreenshotImages, str: aux.MScreen2},
+		{field: "medium_screenshot_image3", dest: &m.MediumScreenshotImages, str: aux.MScreen3},
+		{field: "large_screenshot_image1", dest: &m.LargeScreenshotImages, str: aux.LScreen1},
+		{field: "large_screenshot_image2", dest: &m.LargeScreenshotImages, str: aux.LScreen2},
+		{field: "large_screenshot_image3", dest: &m.LargeScreenshotImages, str: aux.LScreen3},
+	}
+	m.MediumScreenshotImages, m.LargeScreenshotImages = nil, nil
+	for _, s := range screenshots {
+		// Screenshots are only returned with images, missing ones are skipped.
+		if s.str == nil {
+			continue
+		}
+		var u *url.URL
+		if err := d.url(s.field, &u, s.str); err != nil {
+			return err
+		}
+		if u != nil {
+			*s.dest = append(*s.dest, u)
+		}
+	}
 	parseTime(&m.DateUploaded, m.DateUploadedUnix)
-	for _, t := range m.Torrents {
-		t.movieName = m.Title
+	if len(aux.Torrents) > 0 {
+		raws, err := d.array("torrents", aux.Torrents)
+		if err != nil {
+			return err
+		}
+		m.Torrents = nil
+		if raws != nil {
+			m.Torrents = make([]*Torrent, 0, len(raws))
+		}
+		for i, raw := range raws {
+			field := fmt.Sprintf("torrents[%d]", i)
+			if d.skipNull(field, raw) {
+				continue
+			}
+			var t *Torrent
+			if !isNull(raw) {
+				t = &Torrent{movieName: m.Title}
+				if err := t.unmarshal(raw, d.nested(field)); err != nil {
+					return err
+				}
+			}
+			m.Torrents = append(m.Torrents, t)
+		}
 	}
+	if len(aux.Cast) > 0 {
+		raws, err := d.array("cast", aux.Cast)
+		if err != nil {
+			return err
+		}
+		m.Cast = nil
+		if raws != nil {
+			m.Cast = make([]*Cast, 0, len(raws))
+		}
+		for i, raw := range raws {
+			field := fmt.Sprintf("cast[%d]", i)
+			if d.skipNull(field, raw) {
+				continue
+			}
+			var c *Cast
+			if !isNull(raw) {
+				c = &Cast{}
+				if err := c.unmarshal(raw, d.nested(field)); err != nil {
+					return err
+				}
+			}
+			m.Cast = append(m.Cast, c)
+		}
+	}
+	m.Warnings = d.warnings
 	return nil
 }
 
+// MarshalJSON encodes the movie in the format used by YTS.LT API.
+func (m *Movie) MarshalJSON() ([]byte, error) {
+	type mov Movie
+	return json.Marshal(&struct {
+		URLRaw       string `json:"url"`
+		BGImgURL     string `json:"background_image"`
+		BGImgURLOrig string `json:"background_image_original"`
+		SCoverImg    string `json:"small_cover_image"`
+		MCoverImg    string `json:"medium_cover_image"`
+		LCoverImg    string `json:"large_cover_image"`
+		MScreen1     string `json:"medium_screenshot_image1,omitempty"`
+		MScreen2     string `json:"medium_screenshot_image2,omitempty"`
+		MScreen3     string `json:"medium_screenshot_image3,omitempty"`
+		LScreen1     string `json:"large_screenshot_image1,omitempty"`
+		LScreen2     string `json:"large_screenshot_image2,omitempty"`
+		LScreen3     string `json:"large_screenshot_image3,omitempty"`
+		*mov
+	}{
+		URLRaw:       urlutil.String(m.URL),
+		BGImgURL:     urlutil.String(m.BackgroundImage),
+		BGImgURLOrig: urlutil.String(m.BackgroundImageOriginal),
+		SCoverImg:    urlutil.String(m.SmallCoverImage),
+		MCoverImg:    urlutil.String(m.MediumCoverImage),
+		LCoverImg:    urlutil.String(m.LargeCoverImage),
+		MScreen1:     urlAt(m.MediumScreenshotImages, 0),
+		MScreen2:     urlAt(m.MediumScreenshotImages, 1),
+		MScreen3:     urlAt(m.MediumScreenshotImages, 2),
+		LScreen1:     urlAt(m.LargeScreenshotImages, 0),
+		LScreen2:     urlAt(m.LargeScreenshotImages, 1),
+		LScreen3:     urlAt(m.LargeScreenshotImages, 2),
+		mov:          (*mov)(m),
+	})
+}
+
 // Torrent contains information about torrent associated with the movie.
 type Torrent struct {
 	URL              *url.URL  `json:"-"`
@@ -96,9 +240,16 @@ type Torrent struct {
 
 // UnmarshalJSON unmarshals Torrent encoded as JSON.
 func (t *Torrent) UnmarshalJSON(data []byte) error {
+	return t.unmarshal(data, &decoder{})
+}
+
+func (t *Torrent) unmarshal(data []byte, d *decoder) error {
 	type tor Torrent
 	aux := &struct {
-		URLRaw string `json:"url"`
+		URLRaw    *string         `json:"url"`
+		Seeds     json.RawMessage `json:"seeds"`
+		Peers     json.RawMessage `json:"peers"`
+		SizeBytes json.RawMessage `json:"size_bytes"`
 		*tor
 	}{
 		tor: (*tor)(t),
@@ -106,13 +257,39 @@ func (t *Torrent) UnmarshalJSON(data []byte) error {
 	if err := json.Unmarshal(data, aux); err != nil {
 		return err
 	}
-	if err := parseURL(&t.URL, aux.URLRaw); err != nil {
+	nums := []struct {
+		field string
+		dest  interface{}
+		raw   json.RawMessage
+	}{
+		{field: "seeds", dest: &t.Seeds, raw: aux.Seeds},
+		{field: "peers", dest: &t.Peers, raw: aux.Peers},
+		{field: "size_bytes", dest: &t.SizeBytes, raw: aux.SizeBytes},
+	}
+	for _, n := range nums {
+		if err := d.number(n.field, n.raw, n.dest); err != nil {
+			return err
+		}
+	}
+	if err := d.url("url", &t.URL, aux.URLRaw); err != nil {
 		return err
 	}
 	parseTime(&t.DateUploaded, t.DateUploadedUnix)
 	return nil
 }
 
+// MarshalJSON encodes the torrent in the format used by YTS.LT API.
+func (t *Torrent) MarshalJSON() ([]byte, error) {
+	type tor Torrent
+	return json.Marshal(&struct {
+		URLRaw string `json:"url"`
+		*tor
+	}{
+		URLRaw: urlutil.String(t.URL),
+		tor:    (*tor)(t),
+	})
+}
+
 // DefaultTackers is a default, recommended list of trackers.
 var DefaultTackers = []string{
 	"udp://open.demonii.com:1337/announce",
@@ -149,9 +326,13 @@ type Cast struct {
 
 // UnmarshalJSON unmarshals Cast encoded as JSON.
 func (c *Cast) UnmarshalJSON(data []byte) error {
+	return c.unmarshal(data, &decoder{})
+}
+
+func (c *Cast) unmarshal(data []byte, d *decoder) error {
 	type cst Cast
 	aux := &struct {
-		SmallImageURL string `json:"url_small_image"`
+		SmallImageURL *string `json:"url_small_image"`
 		*cst
 	}{
 		cst: (*cst)(c),
@@ -159,17 +340,26 @@ func (c *Cast) UnmarshalJSON(data []byte) error {
 	if err := json.Unmarshal(data, aux); err != nil {
 		return err
 	}
-	if err := parseURL(&c.URLSmallImage, aux.SmallImageURL); err != nil {
-		return err
-	}
-	return nil
+	return d.url("url_small_image", &c.URLSmallImage, aux.SmallImageURL)
+}
+
+// MarshalJSON encodes the cast member in the format used by YTS.LT API.
+func (c *Cast) MarshalJSON() ([]byte, error) {
+	type cst Cast
+	return json.Marshal(&struct {
+		SmallImageURL string `json:"url_small_image"`
+		*cst
+	}{
+		SmallImageURL: urlutil.String(c.URLSmallImage),
+		cst:           (*cst)(c),
+	})
 }
 
 type TorrentsBySize []*Torrent
 
 func (t TorrentsBySize) Len() int           { return len(t) }
 func (t TorrentsBySize) Swap(i, j int)      { t[i], t[j] = t[j], t[i] }
-func (t TorrentsBySize) Less(i, j int) bool { return t[i].SizeBytes < t[j].SizeBytes }
+func (t TorrentsBySize) Less(i, j int) bool { return t[i].Bytes() < t[j].Bytes() }
 
 type TorrentsBySeeds []*Torrent
 
@@ -181,6 +371,13 @@ func parseTime(dest *time.Time, unix int64) {
 	*dest = time.Unix(unix, 0)
 }
 
+func urlAt(urls []*url.URL, i int) string {
+	if i >= len(urls) {
+		return ""
+	}
+	return urlutil.String(urls[i])
+}
+
 func parseURL(dest **url.URL, str string) error {
 	u, err := url.Parse(str)
 	if err != nil {
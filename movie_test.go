@@ -2,6 +2,9 @@ package ytsgo
 
 import (
 	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -46,8 +49,18 @@ func TestUnMarshalMovie(t *testing.T) {
 				SmallCoverImage:         mustURL("https://yts.lt/assets/images/movies/13_2010/small-cover.jpg", t),
 				MediumCoverImage:        mustURL("https://yts.lt/assets/images/movies/13_2010/medium-cover.jpg", t),
 				LargeCoverImage:         mustURL("https://yts.lt/assets/images/movies/13_2010/large-cover.jpg", t),
-				DateUploaded:            time.Unix(1446320797, 0),
-				DateUploadedUnix:        1446320797,
+				MediumScreenshotImages: []*url.URL{
+					mustURL("https://yts.lt/assets/images/movies/13_2010/medium-screenshot1.jpg", t),
+					mustURL("https://yts.lt/assets/images/movies/13_2010/medium-screenshot2.jpg", t),
+					mustURL("https://yts.lt/assets/images/movies/13_2010/medium-screenshot3.jpg", t),
+				},
+				LargeScreenshotImages: []*url.URL{
+					mustURL("https://yts.lt/assets/images/movies/13_2010/large-screenshot1.jpg", t),
+					mustURL("https://yts.lt/assets/images/movies/13_2010/large-screenshot2.jpg", t),
+					mustURL("https://yts.lt/assets/images/movies/13_2010/large-screenshot3.jpg", t),
+				},
+				DateUploaded:     time.Unix(1446320797, 0),
+				DateUploadedUnix: 1446320797,
 				Torrents: []*Torrent{{
 					URL:              mustURL("https://yts.lt/torrent/download/BE046ED20B048C4FB86E15838DD69DADB27C5E8A", t),
 					Hash:             "BE046ED20B048C4FB86E15838DD69DADB27C5E8A",
@@ -152,3 +165,75 @@ func TestMagnet(t *testing.T) {
 		})
 	}
 }
+
+func FuzzMovieUnmarshalJSON(f *testing.F) {
+	f.Add([]byte(`{}`))
+	f.Add([]byte(`{"url": ":"}`))
+	f.Add([]byte(`{"torrents": [{"url": ""}], "cast": [{"url_small_image": ""}]}`))
+	for _, file := range []string{"movie.json", "bad_json.json"} {
+		data, err := ioutil.ReadFile(filepath.Join("testdata", file))
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		m := &Movie{}
+		if err := json.Unmarshal(data, m); err != nil {
+			return
+		}
+		for _, tr := range m.Torrents {
+			if tr != nil && tr.movieName != m.Title {
+				t.Errorf("Torrent movie name %q does not match title %q", tr.movieName, m.Title)
+			}
+		}
+	})
+}
+
+func FuzzTorrentUnmarshalJSON(f *testing.F) {
+	f.Add([]byte(`{}`))
+	f.Add([]byte(`{"url": "https://yts.lt/torrent/download/BE04", "hash": "BE04", "size_bytes": 992466698, "date_uploaded_unix": 1446320797}`))
+	f.Add([]byte(`{"url": 1}`))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		tr := &Torrent{}
+		if err := json.Unmarshal(data, tr); err != nil {
+			return
+		}
+		if tr.URL == nil {
+			t.Error("Torrent URL is nil after successful unmarshal")
+		}
+	})
+}
+
+func FuzzCastUnmarshalJSON(f *testing.F) {
+	f.Add([]byte(`{}`))
+	f.Add([]byte(`{"name": "Jason Statham", "character_name": "Jasper", "url_small_image": "https://yts.lt/assets/images/actors/thumb/nm0005458.jpg", "imdb_code": "0005458"}`))
+	f.Add([]byte(`{"url_small_image": "%zz"}`))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		c := &Cast{}
+		if err := json.Unmarshal(data, c); err != nil {
+			return
+		}
+		if c.URLSmallImage == nil {
+			t.Error("Cast image URL is nil after successful unmarshal")
+		}
+	})
+}
+
+func TestMarshalMovie(t *testing.T) {
+	want := &Movie{}
+	if err := json.Unmarshal(loadTestData("movie.json", t), want); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	data, err := json.Marshal(want)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	got := &Movie{}
+	if err := json.Unmarshal(data, got); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(want, got, cmp.AllowUnexported(Torrent{})); diff != "" {
+		t.Errorf("Movie did not round trip, diff -want +got\n%s", diff)
+	}
+}
@@ -0,0 +1,249 @@
+// Package nfo generates movie.nfo files and artwork of YTS movies in the
+// layout read by Kodi and Jellyfin:
+//
+//	The Matrix (1999)/
+//		movie.nfo
+//		poster.jpg
+//		fanart.jpg
+package nfo
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/qopher/ytsgo"
+	"github.com/qopher/ytsgo/internal/fsutil"
+	"github.com/qopher/ytsgo/internal/urlutil"
+)
+
+const (
+	// FileName is the name of the NFO file in the movie directory.
+	FileName = "movie.nfo"
+	// MaxImageSize limits the size of downloaded images.
+	MaxImageSize = 20 << 20
+)
+
+type movie struct {
+	XMLName       xml.Name   `xml:"movie"`
+	Title         string     `xml:"title"`
+	OriginalTitle string     `xml:"originaltitle,omitempty"`
+	Year          uint       `xml:"year,omitempty"`
+	Ratings       *ratings   `xml:"ratings,omitempty"`
+	Rating        float32    `xml:"rating,omitempty"`
+	Outline       string     `xml:"outline,omitempty"`
+	Plot          string     `xml:"plot,omitempty"`
+	Runtime       uint       `xml:"runtime,omitempty"`
+	MPAA          string     `xml:"mpaa,omitempty"`
+	UniqueIDs     []uniqueID `xml:"uniqueid"`
+	IMDBID        string     `xml:"imdbid,omitempty"`
+	Genres        []string   `xml:"genre"`
+	Thumbs        []thumb    `xml:"thumb"`
+	Fanart        *fanart    `xml:"fanart,omitempty"`
+	Trailer       string     `xml:"trailer,omitempty"`
+	Language      string     `xml:"language,omitempty"`
+	Actors        []actor    `xml:"actor"`
+}
+
+type ratings struct {
+	Rating []rating `xml:"rating"`
+}
+
+type rating struct {
+	Name    string  `xml:"name,attr"`
+	Max     int     `xml:"max,attr"`
+	Default bool    `xml:"default,attr"`
+	Value   float32 `xml:"value"`
+}
+
+type uniqueID struct {
+	Type    string `xml:"type,attr"`
+	Default bool   `xml:"default,attr"`
+	Value   string `xml:",chardata"`
+}
+
+type thumb struct {
+	Aspect string `xml:"aspect,attr,omitempty"`
+	URL    string `xml:",chardata"`
+}
+
+type fanart struct {
+	Thumbs []thumb `xml:"thumb"`
+}
+
+type actor struct {
+	Name  string `xml:"name"`
+	Role  string `xml:"role,omitempty"`
+	Order int    `xml:"order"`
+	Thumb string `xml:"thumb,omitempty"`
+}
+
+func newMovie(m *ytsgo.Movie) *movie {
+	ret := &movie{
+		Title:   m.Title,
+		Year:    m.Year,
+		Rating:  m.Rating,
+		Outline: m.DescriptionIntro,
+		Plot:    m.DescriptionFull,
+		Runtime: m.Runtime,
+		MPAA:    m.MPARating,
+		IMDBID:  m.IMDBCode,
+		Genres:  m.Genres,
+		// Language is a name, eg. "English", Kodi expects a code but displays names fine.
+		Language: m.Language,
+	}
+	if m.TitleEnglish != "" && m.TitleEnglish != m.Title {
+		ret.OriginalTitle = m.Title
+		ret.Title = m.TitleEnglish
+	}
+	if ret.Plot == "" {
+		ret.Plot = m.DescriptionIntro
+	}
+	if m.Rating > 0 {
+		ret.Ratings = &ratings{Rating: []rating{{Name: "imdb", Max: 10, Default: true, Value: m.Rating}}}
+	}
+	if m.IMDBCode != "" {
+		ret.UniqueIDs = append(ret.UniqueIDs, uniqueID{Type: "imdb", Default: true, Value: m.IMDBCode})
+	}
+	if m.ID > 0 {
+		ret.UniqueIDs = append(ret.UniqueIDs, uniqueID{Type: "yts", Value: fmt.Sprint(m.ID)})
+	}
+	if u := urlutil.String(m.LargeCoverImage); u != "" {
+		ret.Thumbs = append(ret.Thumbs, thumb{Aspect: "poster", URL: u})
+	}
+	if u := urlutil.String(m.BackgroundImageOriginal); u != "" {
+		ret.Fanart = &fanart{Thumbs: []thumb{{URL: u}}}
+	}
+	if m.YouTubeTrailerCode != "" {
+		ret.Trailer = "plugin://plugin.video.youtube/?action=play_video&videoid=" + m.YouTubeTrailerCode
+	}
+	for i, c := range m.Cast {
+		if c == nil {
+			continue
+		}
+		ret.Actors = append(ret.Actors, actor{Name: c.Name, Role: c.CharacterName, Order: i, Thumb: urlutil.String(c.URLSmallImage)})
+	}
+	return ret
+}
+
+// Write writes the NFO XML document of the movie to w.
+func Write(w io.Writer, m *ytsgo.Movie) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(newMovie(m)); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// DirName returns the name of the movie directory, eg. "The Matrix (1999)".
+func DirName(m *ytsgo.Movie) string {
+	name := fsutil.SanitizeName(m.Title)
+	if m.Year > 0 {
+		name = fmt.Sprintf("%s (%d)", name, m.Year)
+	}
+	return name
+}
+
+// Generator writes NFO files and downloads artwork.
+type Generator struct {
+	// HTTPClient is used to download images, http.DefaultClient if nil.
+	HTTPClient *http.Client
+	// NoArtwork disables downloading of poster and fanart.
+	NoArtwork bool
+	// Overwrite makes the Generator replace existing files. Existing
+	// artwork is kept by default, so that images chosen by the user survive.
+	Overwrite bool
+}
+
+// Generate writes movie.nfo, poster and fanart of the movie to dir, which is
+// usually the directory with the movie file. Images keep the extension of
+// their URL, eg. poster.jpg. Written paths are returned.
+func (g *Generator) Generate(ctx context.Context, dir string, m *ytsgo.Movie) ([]string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	var written []string
+	nfoPath := filepath.Join(dir, FileName)
+	if g.Overwrite || !exists(nfoPath) {
+		err := fsutil.Write(nfoPath, func(w io.Writer) error {
+			return Write(w, m)
+		})
+		if err != nil {
+			return nil, err
+		}
+		written = append(written, nfoPath)
+	}
+	if g.NoArtwork {
+		return written, nil
+	}
+	for _, img := range []struct {
+		name string
+		u    *url.URL
+	}{
+		{name: "poster", u: m.LargeCoverImage},
+		{name: "fanart", u: m.BackgroundImageOriginal},
+	} {
+		if img.u == nil || img.u.String() == "" {
+			continue
+		}
+		ext := strings.ToLower(path.Ext(img.u.Path))
+		if ext != ".png" && ext != ".jpeg" {
+			ext = ".jpg"
+		}
+		p := filepath.Join(dir, img.name+ext)
+		if !g.Overwrite && exists(p) {
+			continue
+		}
+		if err := g.download(ctx, img.u, p); err != nil {
+			return written, fmt.Errorf("failed to download %s: %v", img.name, err)
+		}
+		written = append(written, p)
+	}
+	return written, nil
+}
+
+func (g *Generator) download(ctx context.Context, u *url.URL, p string) error {
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return err
+	}
+	hc := g.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	rsp, err := hc.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		return fmt.Errorf("server returned code %v: %s", rsp.StatusCode, rsp.Status)
+	}
+	if ct := rsp.Header.Get("Content-Type"); ct != "" && !strings.HasPrefix(ct, "image/") {
+		return fmt.Errorf("unexpected content type %q", ct)
+	}
+	return fsutil.Write(p, func(w io.Writer) error {
+		n, err := io.Copy(w, io.LimitReader(rsp.Body, MaxImageSize+1))
+		if err == nil && n > MaxImageSize {
+			err = fmt.Errorf("image is larger than %d bytes", MaxImageSize)
+		}
+		return err
+	})
+}
+
+func exists(p string) bool {
+	_, err := os.Stat(p)
+	return err == nil
+}
@@ -0,0 +1,180 @@
+package nfo
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qopher/ytsgo"
+)
+
+func mustURL(s string) *url.URL {
+	u, err := url.Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return u
+}
+
+func testMovie(base string) *ytsgo.Movie {
+	return &ytsgo.Movie{
+		ID:                      3525,
+		IMDBCode:                "tt0133093",
+		Title:                   "The Matrix",
+		TitleEnglish:            "The Matrix",
+		Year:                    1999,
+		Rating:                  8.7,
+		Runtime:                 136,
+		Genres:                  []string{"Action", "Sci-Fi"},
+		DescriptionIntro:        "Neo & Morpheus.",
+		DescriptionFull:         "Thomas A. Anderson is a man living two lives.",
+		YouTubeTrailerCode:      "m8e-FF8MsqU",
+		Language:                "English",
+		MPARating:               "R",
+		LargeCoverImage:         mustURL(base + "/assets/large-cover.jpg"),
+		BackgroundImageOriginal: mustURL(base + "/assets/background.png"),
+		Cast: []*ytsgo.Cast{
+			{Name: "Keanu Reeves", CharacterName: "Neo", IMDBCode: "0000206", URLSmallImage: mustURL("https://yts.lt/actors/nm0000206.jpg")},
+			{Name: "Laurence Fishburne", CharacterName: "Morpheus"},
+		},
+	}
+}
+
+const wantNFO = `<?xml version="1.0" encoding="UTF-8"?>
+<movie>
+  <title>The Matrix</title>
+  <year>1999</year>
+  <ratings>
+    <rating name="imdb" max="10" default="true">
+      <value>8.7</value>
+    </rating>
+  </ratings>
+  <rating>8.7</rating>
+  <outline>Neo &amp; Morpheus.</outline>
+  <plot>Thomas A. Anderson is a man living two lives.</plot>
+  <runtime>136</runtime>
+  <mpaa>R</mpaa>
+  <uniqueid type="imdb" default="true">tt0133093</uniqueid>
+  <uniqueid type="yts" default="false">3525</uniqueid>
+  <imdbid>tt0133093</imdbid>
+  <genre>Action</genre>
+  <genre>Sci-Fi</genre>
+  <thumb aspect="poster">https://yts.lt/assets/large-cover.jpg</thumb>
+  <fanart>
+    <thumb>https://yts.lt/assets/background.png</thumb>
+  </fanart>
+  <trailer>plugin://plugin.video.youtube/?action=play_video&amp;videoid=m8e-FF8MsqU</trailer>
+  <language>English</language>
+  <actor>
+    <name>Keanu Reeves</name>
+    <role>Neo</role>
+    <order>0</order>
+    <thumb>https://yts.lt/actors/nm0000206.jpg</thumb>
+  </actor>
+  <actor>
+    <name>Laurence Fishburne</name>
+    <role>Morpheus</role>
+    <order>1</order>
+  </actor>
+</movie>
+`
+
+func TestWrite(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, testMovie("https://yts.lt")); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	if diff := cmp.Diff(wantNFO, buf.String()); diff != "" {
+		t.Errorf("Unexpected NFO, diff -want +got\n%s", diff)
+	}
+}
+
+func TestWriteOriginalTitle(t *testing.T) {
+	var buf bytes.Buffer
+	m := &ytsgo.Movie{Title: "Le fabuleux destin d'Amélie Poulain", TitleEnglish: "Amélie", DescriptionIntro: "Intro"}
+	if err := Write(&buf, m); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	for _, s := range []string{"<title>Amélie</title>", "<originaltitle>Le fabuleux destin d&#39;Amélie Poulain</originaltitle>", "<plot>Intro</plot>"} {
+		if !bytes.Contains(buf.Bytes(), []byte(s)) {
+			t.Errorf("NFO does not contain %q:\n%s", s, buf.String())
+		}
+	}
+}
+
+func TestDirName(t *testing.T) {
+	for _, tc := range []struct {
+		m    *ytsgo.Movie
+		want string
+	}{
+		{m: &ytsgo.Movie{Title: "The Matrix", Year: 1999}, want: "The Matrix (1999)"},
+		{m: &ytsgo.Movie{Title: "Mission: Impossible"}, want: "Mission_ Impossible"},
+	} {
+		if got := DirName(tc.m); got != tc.want {
+			t.Errorf("DirName(%q) = %q want %q", tc.m.Title, got, tc.want)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/assets/large-cover.jpg":
+			w.Header().Set("Content-Type", "image/jpeg")
+			w.Write([]byte("poster"))
+		case "/assets/background.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte("fanart"))
+		case "/assets/challenge.jpg":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html></html>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+	m := testMovie(ts.URL)
+	dir := filepath.Join(t.TempDir(), DirName(m))
+	g := &Generator{}
+	got, err := g.Generate(context.Background(), dir, m)
+	if err != nil {
+		t.Fatalf("Generate() failed: %v", err)
+	}
+	want := []string{filepath.Join(dir, "movie.nfo"), filepath.Join(dir, "poster.jpg"), filepath.Join(dir, "fanart.png")}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Unexpected written files, diff -want +got\n%s", diff)
+	}
+	for p, content := range map[string]string{"poster.jpg": "poster", "fanart.png": "fanart"} {
+		data, err := ioutil.ReadFile(filepath.Join(dir, p))
+		if err != nil || string(data) != content {
+			t.Errorf("Unexpected %s content %q, err %v", p, data, err)
+		}
+	}
+	// Existing files are kept unless Overwrite is set.
+	if got, err := g.Generate(context.Background(), dir, m); err != nil || len(got) != 0 {
+		t.Errorf("Second Generate() = %v, %v want no files written", got, err)
+	}
+	g.Overwrite = true
+	if got, err := g.Generate(context.Background(), dir, m); err != nil || len(got) != 3 {
+		t.Errorf("Generate() with Overwrite = %v, %v want 3 files written", got, err)
+	}
+
+	m.LargeCoverImage = mustURL(ts.URL + "/assets/challenge.jpg")
+	if _, err := g.Generate(context.Background(), dir, m); err == nil {
+		t.Error("Generate() with non image poster succeeded, want error")
+	}
+	m.LargeCoverImage = mustURL(ts.URL + "/assets/missing.jpg")
+	if _, err := g.Generate(context.Background(), dir, m); err == nil {
+		t.Error("Generate() with missing poster succeeded, want error")
+	}
+	g.NoArtwork = true
+	if got, err := g.Generate(context.Background(), dir, m); err != nil || len(got) != 1 {
+		t.Errorf("Generate() with NoArtwork = %v, %v want only the NFO", got, err)
+	}
+}
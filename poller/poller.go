@@ -0,0 +1,357 @@
+// Package poller detects movies and torrents newly uploaded to YTS.LT and
+// delivers them to webhooks.
+package poller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/qopher/ytsgo"
+	"github.com/qopher/ytsgo/internal/fsutil"
+	"github.com/qopher/ytsgo/webhook"
+)
+
+const (
+	// DefaultMaxPages is a default limit of pages fetched by a single Poll.
+	DefaultMaxPages = 5
+	pageSize        = 50
+)
+
+// Lister lists movies. It is implemented by *ytsgo.Client.
+type Lister interface {
+	ListMovies(opts ...ytsgo.ListMoviesOption) (*ytsgo.Movies, error)
+}
+
+// Rules select releases which are delivered. Empty fields match everything.
+type Rules struct {
+	// Genres match movies with at least one of the genres.
+	Genres []string
+	// MinRating is the minimal IMDb rating.
+	MinRating float32
+	// Qualities match torrents with one of the qualities.
+	Qualities []string
+	// Languages match movies in one of the languages.
+	Languages []string
+}
+
+// matchMovie reports whether the movie passes movie level rules.
+func (r *Rules) matchMovie(m *ytsgo.Movie) bool {
+	if m.Rating < r.MinRating {
+		return false
+	}
+	if len(r.Languages) > 0 && !containsFold(r.Languages, m.Language) {
+		return false
+	}
+	if len(r.Genres) == 0 {
+		return true
+	}
+	for _, g := range m.Genres {
+		if containsFold(r.Genres, g) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchTorrent reports whether the torrent passes torrent level rules.
+func (r *Rules) matchTorrent(t *ytsgo.Torrent) bool {
+	return len(r.Qualities) == 0 || containsFold(r.Qualities, t.Quality)
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Release is a payload delivered to webhooks.
+type Release struct {
+	// NewMovie is false if only new torrents of a known movie were found.
+	NewMovie bool           `json:"new_movie"`
+	Movie    *ytsgo.Movie   `json:"movie"`
+	Torrents []*ReleaseItem `json:"torrents"`
+}
+
+// ReleaseItem is a new torrent with its magnet link.
+type ReleaseItem struct {
+	*ytsgo.Torrent
+	Magnet string `json:"magnet"`
+}
+
+// MarshalJSON encodes the torrent together with the magnet link.
+func (r *ReleaseItem) MarshalJSON() ([]byte, error) {
+	t, err := json.Marshal(r.Torrent)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(t, &fields); err != nil {
+		return nil, err
+	}
+	fields["magnet"], err = json.Marshal(r.Magnet)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(fields)
+}
+
+// State is persisted between polls.
+type State struct {
+	// Movies are IDs of movies seen so far.
+	Movies map[uint]bool `json:"movies"`
+	// Torrents are hashes of torrents seen so far.
+	Torrents map[string]bool `json:"torrents"`
+	// LastPoll is the time of the last successful poll.
+	LastPoll time.Time `json:"last_poll"`
+	// Delivered are hashes of torrents delivered to a webhook URL while
+	// other webhooks failed, so that they are not sent to it again.
+	Delivered map[string]map[string]bool `json:"delivered,omitempty"`
+}
+
+// NewState returns an empty State.
+func NewState() *State {
+	return &State{
+		Movies:    make(map[uint]bool),
+		Torrents:  make(map[string]bool),
+		Delivered: make(map[string]map[string]bool),
+	}
+}
+
+// empty reports whether nothing was polled yet.
+func (s *State) empty() bool {
+	return len(s.Movies) == 0 && len(s.Torrents) == 0
+}
+
+// LoadState reads the state saved by SaveFile. A missing file results in an empty state.
+func LoadState(path string) (*State, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return NewState(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	s := NewState()
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, err
+	}
+	if s.Movies == nil {
+		s.Movies = make(map[uint]bool)
+	}
+	if s.Torrents == nil {
+		s.Torrents = make(map[string]bool)
+	}
+	if s.Delivered == nil {
+		s.Delivered = make(map[string]map[string]bool)
+	}
+	return s, nil
+}
+
+// SaveFile writes the state as JSON. The file is replaced atomically.
+func (s *State) SaveFile(path string) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return fsutil.WriteFile(path, data)
+}
+
+// Poller finds new releases and delivers them to webhooks.
+type Poller struct {
+	Client  Lister
+	Rules   Rules
+	Senders []*webhook.Sender
+	State   *State
+	// StatePath, if not empty, is a file the State is saved to after every
+	// poll.
+	StatePath string
+	// MaxPages overrides DefaultMaxPages if positive.
+	MaxPages uint
+	// Backfill if true delivers releases found during the first poll. By
+	// default the first poll only records what is already available.
+	Backfill bool
+}
+
+// Poll fetches movies sorted by upload date until a page without unseen
+// movies or torrents and delivers releases matching the Rules. Releases are
+// marked as seen only after they are delivered to all webhooks, so failed
+// deliveries are retried by the next Poll, only for the webhooks which failed.
+func (p *Poller) Poll(ctx context.Context) ([]*Release, error) {
+	if p.State == nil {
+		p.State = NewState()
+	}
+	first := p.State.empty()
+	maxPages := p.MaxPages
+	if maxPages == 0 {
+		maxPages = DefaultMaxPages
+	}
+	var found []*Release
+	for page := uint(1); page <= maxPages; page++ {
+		mvs, err := p.Client.ListMovies(ytsgo.LMSortBy("date_added"), ytsgo.LMOrderBy("desc"), ytsgo.LMLimit(pageSize), ytsgo.LMPage(page))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list page %d: %w", page, err)
+		}
+		if mvs == nil || len(mvs.Movies) == 0 {
+			break
+		}
+		unseen := false
+		for _, m := range mvs.Movies {
+			if m == nil {
+				continue
+			}
+			if r := p.State.release(m); r != nil {
+				unseen = true
+				found = append(found, r)
+			}
+		}
+		if !unseen || len(mvs.Movies) < pageSize {
+			break
+		}
+	}
+	var delivered []*Release
+	var firstErr error
+	for _, r := range found {
+		matched := p.filter(r)
+		if matched != nil && (!first || p.Backfill) {
+			if err := p.deliver(ctx, matched); err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				continue
+			}
+			delivered = append(delivered, matched)
+		}
+		p.State.markSeen(r)
+	}
+	if firstErr == nil {
+		p.State.LastPoll = time.Now()
+	}
+	if p.StatePath != "" {
+		if err := p.State.SaveFile(p.StatePath); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return delivered, firstErr
+}
+
+// release returns a release with unseen torrents of the movie or nil if all were seen.
+func (s *State) release(m *ytsgo.Movie) *Release {
+	r := &Release{
+		NewMovie: !s.Movies[m.ID],
+		Movie:    m,
+	}
+	for _, t := range m.Torrents {
+		if t != nil && !s.Torrents[t.Hash] {
+			r.Torrents = append(r.Torrents, &ReleaseItem{Torrent: t, Magnet: t.Magnet()})
+		}
+	}
+	if !r.NewMovie && len(r.Torrents) == 0 {
+		return nil
+	}
+	return r
+}
+
+func (s *State) markSeen(r *Release) {
+	s.Movies[r.Movie.ID] = true
+	for _, t := range r.Torrents {
+		s.Torrents[t.Hash] = true
+	}
+}
+
+// filter returns the release restricted to torrents matching the rules or nil if nothing matches.
+func (p *Poller) filter(r *Release) *Release {
+	if !p.Rules.matchMovie(r.Movie) {
+		return nil
+	}
+	ret := &Release{
+		NewMovie: r.NewMovie,
+		Movie:    r.Movie,
+	}
+	for _, t := range r.Torrents {
+		if p.Rules.matchTorrent(t.Torrent) {
+			ret.Torrents = append(ret.Torrents, t)
+		}
+	}
+	if len(ret.Torrents) == 0 {
+		return nil
+	}
+	return ret
+}
+
+// deliver sends the release to every webhook, skipping torrents already
+// delivered to it. All webhooks are tried, the first error is returned.
+func (p *Poller) deliver(ctx context.Context, r *Release) error {
+	if p.State.Delivered == nil {
+		p.State.Delivered = make(map[string]map[string]bool)
+	}
+	var firstErr error
+	for _, s := range p.Senders {
+		done := p.State.Delivered[s.URL]
+		pending := &Release{NewMovie: r.NewMovie, Movie: r.Movie}
+		for _, t := range r.Torrents {
+			if !done[t.Hash] {
+				pending.Torrents = append(pending.Torrents, t)
+			}
+		}
+		if len(pending.Torrents) == 0 {
+			continue
+		}
+		if err := s.Send(ctx, pending); err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to deliver %q: %w", r.Movie.Title, err)
+			}
+			continue
+		}
+		if done == nil {
+			done = make(map[string]bool)
+			p.State.Delivered[s.URL] = done
+		}
+		for _, t := range pending.Torrents {
+			done[t.Hash] = true
+		}
+	}
+	if firstErr != nil {
+		return firstErr
+	}
+	// Every webhook has the release, it is marked as seen instead.
+	for u, done := range p.State.Delivered {
+		for _, t := range r.Torrents {
+			delete(done, t.Hash)
+		}
+		if len(done) == 0 {
+			delete(p.State.Delivered, u)
+		}
+	}
+	return nil
+}
+
+// Run polls every interval until ctx is done. Poll errors are passed to onErr
+// which may be nil, delivered releases to onRelease which may be nil.
+func (p *Poller) Run(ctx context.Context, interval time.Duration, onRelease func(*Release), onErr func(error)) error {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		rs, err := p.Poll(ctx)
+		if err != nil && onErr != nil {
+			onErr(err)
+		}
+		if onRelease != nil {
+			for _, r := range rs {
+				onRelease(r)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
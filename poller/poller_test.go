@@ -0,0 +1,260 @@
+package poller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qopher/ytsgo"
+	"github.com/qopher/ytsgo/webhook"
+)
+
+// fakeLister serves movies newest first.
+type fakeLister struct {
+	movies []*ytsgo.Movie
+	pages  int
+	err    error
+}
+
+func (f *fakeLister) ListMovies(opts ...ytsgo.ListMoviesOption) (*ytsgo.Movies, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	v := url.Values{}
+	for _, o := range opts {
+		o(v)
+	}
+	if v.Get("sort_by") != "date_added" || v.Get("order_by") != "desc" {
+		return nil, fmt.Errorf("unexpected query %v", v)
+	}
+	f.pages++
+	page, _ := strconv.Atoi(v.Get("page"))
+	limit, _ := strconv.Atoi(v.Get("limit"))
+	from, to := (page-1)*limit, page*limit
+	if from > len(f.movies) {
+		from = len(f.movies)
+	}
+	if to > len(f.movies) {
+		to = len(f.movies)
+	}
+	return &ytsgo.Movies{MovieCount: uint(len(f.movies)), Movies: f.movies[from:to]}, nil
+}
+
+func movie(id uint, genre, language string, rating float32, qualities ...string) *ytsgo.Movie {
+	m := &ytsgo.Movie{
+		ID:       id,
+		Title:    fmt.Sprintf("Movie %d", id),
+		Genres:   []string{genre},
+		Language: language,
+		Rating:   rating,
+	}
+	for _, q := range qualities {
+		m.Torrents = append(m.Torrents, &ytsgo.Torrent{Hash: fmt.Sprintf("%d-%s", id, q), Quality: q})
+	}
+	return m
+}
+
+type hook struct {
+	mu       sync.Mutex
+	secret   []byte
+	releases []string
+	fail     bool
+}
+
+func (h *hook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.fail {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if !webhook.Verify(h.secret, body, r.Header.Get(webhook.SignatureHeader)) {
+		http.Error(w, "bad signature", http.StatusForbidden)
+		return
+	}
+	var rel struct {
+		NewMovie bool `json:"new_movie"`
+		Movie    struct {
+			ID uint `json:"id"`
+		} `json:"movie"`
+		Torrents []struct {
+			Quality string `json:"quality"`
+			Magnet  string `json:"magnet"`
+		} `json:"torrents"`
+	}
+	if err := json.Unmarshal(body, &rel); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s := fmt.Sprintf("%d new:%v", rel.Movie.ID, rel.NewMovie)
+	for _, t := range rel.Torrents {
+		if t.Magnet == "" {
+			http.Error(w, "no magnet", http.StatusBadRequest)
+			return
+		}
+		s += " " + t.Quality
+	}
+	h.releases = append(h.releases, s)
+}
+
+func TestPoll(t *testing.T) {
+	h := &hook{secret: []byte("secret")}
+	ts := httptest.NewServer(h)
+	defer ts.Close()
+	l := &fakeLister{movies: []*ytsgo.Movie{
+		movie(2, "Action", "English", 7, "720p", "1080p"),
+		movie(1, "Drama", "English", 8, "1080p"),
+	}}
+	path := filepath.Join(t.TempDir(), "state.json")
+	p := &Poller{
+		Client: l,
+		Rules: Rules{
+			Genres:    []string{"action", "comedy"},
+			MinRating: 6,
+			Qualities: []string{"1080p", "2160p"},
+			Languages: []string{"english"},
+		},
+		Senders:   []*webhook.Sender{{URL: ts.URL, Secret: h.secret, Backoff: time.Millisecond}},
+		StatePath: path,
+	}
+	ctx := context.Background()
+
+	// The first poll only records the state.
+	if rs, err := p.Poll(ctx); err != nil || len(rs) != 0 {
+		t.Fatalf("Unexpected result of the first poll, got %d releases: %v", len(rs), err)
+	}
+	if len(h.releases) != 0 {
+		t.Errorf("Unexpected releases delivered on the first poll: %v", h.releases)
+	}
+
+	l.movies = append([]*ytsgo.Movie{
+		movie(5, "Comedy", "French", 9, "1080p"),
+		movie(4, "Horror", "English", 9, "2160p"),
+		movie(3, "Comedy", "English", 5, "1080p"),
+	}, l.movies...)
+	l.movies[3].Torrents = append(l.movies[3].Torrents, &ytsgo.Torrent{Hash: "2-2160p", Quality: "2160p"})
+	l.movies = append(l.movies, movie(6, "Action", "English", 9, "2160p"))
+	rs, err := p.Poll(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]string{"2 new:false 2160p", "6 new:true 2160p"}, h.releases); diff != "" {
+		t.Errorf("Unexpected releases, diff -want +got\n%s", diff)
+	}
+	if len(rs) != 2 {
+		t.Errorf("Unexpected number of releases, got %d want 2", len(rs))
+	}
+
+	// Nothing new.
+	h.releases = nil
+	if _, err := p.Poll(ctx); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(h.releases) != 0 {
+		t.Errorf("Unexpected releases: %v", h.releases)
+	}
+
+	// Failed deliveries are retried by the next poll, also after restart.
+	l.movies = append([]*ytsgo.Movie{movie(7, "Action", "English", 7, "720p", "1080p")}, l.movies...)
+	h.fail = true
+	if _, err := p.Poll(ctx); err == nil {
+		t.Fatal("Expected delivery error")
+	}
+	h.fail = false
+	state, err := LoadState(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	p.State = state
+	if _, err := p.Poll(ctx); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]string{"7 new:true 1080p"}, h.releases); diff != "" {
+		t.Errorf("Unexpected releases, diff -want +got\n%s", diff)
+	}
+}
+
+func TestPollBackfillAndPaging(t *testing.T) {
+	h := &hook{secret: []byte("s")}
+	ts := httptest.NewServer(h)
+	defer ts.Close()
+	l := &fakeLister{}
+	for i := 120; i > 0; i-- {
+		l.movies = append(l.movies, movie(uint(i), "Drama", "English", 7, "720p"))
+	}
+	p := &Poller{
+		Client:   l,
+		Senders:  []*webhook.Sender{{URL: ts.URL, Secret: h.secret}},
+		MaxPages: 2,
+		Backfill: true,
+	}
+	rs, err := p.Poll(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(rs) != 100 || len(h.releases) != 100 || l.pages != 2 {
+		t.Errorf("Unexpected result, got %d releases, %d delivered, %d pages", len(rs), len(h.releases), l.pages)
+	}
+
+	l.err = errors.New("some error")
+	if _, err := p.Poll(context.Background()); err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+func TestLoadMissingState(t *testing.T) {
+	s, err := LoadState(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil || !s.empty() {
+		t.Errorf("Unexpected state for missing file: %+v, %v", s, err)
+	}
+}
+
+func TestPollPartialDelivery(t *testing.T) {
+	ok, failing := &hook{secret: []byte("s")}, &hook{secret: []byte("s"), fail: true}
+	okServer, failingServer := httptest.NewServer(ok), httptest.NewServer(failing)
+	defer okServer.Close()
+	defer failingServer.Close()
+	l := &fakeLister{movies: []*ytsgo.Movie{nil, movie(1, "Drama", "English", 7, "720p")}}
+	p := &Poller{
+		Client: l,
+		Senders: []*webhook.Sender{
+			{URL: okServer.URL, Secret: ok.secret},
+			{URL: failingServer.URL, Secret: failing.secret},
+		},
+		Backfill: true,
+	}
+	ctx := context.Background()
+	if _, err := p.Poll(ctx); err == nil {
+		t.Fatal("Expected delivery error")
+	}
+	failing.mu.Lock()
+	failing.fail = false
+	failing.mu.Unlock()
+	for i := 0; i < 2; i++ {
+		if _, err := p.Poll(ctx); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	want := []string{"1 new:true 720p"}
+	if diff := cmp.Diff(want, ok.releases); diff != "" {
+		t.Errorf("Unexpected releases of working webhook, diff -want +got\n%s", diff)
+	}
+	if diff := cmp.Diff(want, failing.releases); diff != "" {
+		t.Errorf("Unexpected releases of failing webhook, diff -want +got\n%s", diff)
+	}
+	if len(p.State.Delivered) != 0 {
+		t.Errorf("Delivered torrents were not cleared: %v", p.State.Delivered)
+	}
+}
@@ -0,0 +1,319 @@
+// Package proxy implements a caching HTTP proxy re-exposing the YTS.LT API.
+//
+// The Server serves list_movies.json, movie_details.json and
+// movie_suggestions.json in the wire format of the API, so existing consumers
+// only need to change the base URL. Responses are cached, upstream requests
+// are rate limited and, if the ytsgo.Client is created with the Mirrors option,
+// failed requests are retried on mirrors.
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/qopher/ytsgo"
+)
+
+const (
+	// DefaultTTL is a default time responses are cached.
+	DefaultTTL = 5 * time.Minute
+	// DefaultMaxEntries is a default maximal number of cached responses.
+	DefaultMaxEntries = 1000
+)
+
+// Backend queries the API. It is implemented by *ytsgo.Client.
+type Backend interface {
+	Movie(id int, opts ...ytsgo.MovieOption) (*ytsgo.Movie, error)
+	ListMovies(opts ...ytsgo.ListMoviesOption) (*ytsgo.Movies, error)
+	Suggestions(id int) ([]*ytsgo.Movie, error)
+}
+
+// Server is an http.Handler serving the API endpoints on any path prefix,
+// eg. /api/v2/list_movies.json.
+type Server struct {
+	Backend Backend
+	// TTL overrides DefaultTTL if positive.
+	TTL time.Duration
+	// MaxEntries overrides DefaultMaxEntries if positive.
+	MaxEntries int
+	// RateLimit is a maximal number of upstream requests per second. If it is
+	// not positive upstream requests are not limited. Requests over the limit
+	// are served from stale cache if possible, otherwise they fail with 429.
+	RateLimit float64
+	// Burst is a number of upstream requests allowed at once, 1 if not positive.
+	Burst int
+
+	mu      sync.Mutex
+	cache   map[string]*entry
+	limiter *limiter
+}
+
+// entry is a cached response body.
+type entry struct {
+	body    []byte
+	expires time.Time
+}
+
+// endpoint describes a single API endpoint.
+type endpoint struct {
+	params []string
+	fetch  func(s *Server, v url.Values) (interface{}, error)
+}
+
+var endpoints = map[string]endpoint{
+	"list_movies.json": {
+		params: []string{"limit", "page", "quality", "minimum_rating", "query_term", "genre", "sort_by", "order_by", "with_rt_ratings"},
+		fetch:  (*Server).listMovies,
+	},
+	"movie_details.json": {
+		params: []string{"movie_id", "with_images", "with_cast"},
+		fetch:  (*Server).movie,
+	},
+	"movie_suggestions.json": {
+		params: []string{"movie_id"},
+		fetch:  (*Server).suggestions,
+	},
+}
+
+// envelope is the top level object of every API response.
+type envelope struct {
+	Status        string      `json:"status"`
+	StatusMessage string      `json:"status_message"`
+	Data          interface{} `json:"data,omitempty"`
+}
+
+// badRequest is returned by endpoint fetch functions for invalid parameters.
+type badRequest struct {
+	msg string
+}
+
+func (e *badRequest) Error() string {
+	return e.msg
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := path.Base(r.URL.Path)
+	ep, ok := endpoints[name]
+	if !ok {
+		writeError(w, http.StatusNotFound, "unknown endpoint "+name)
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	// Only known parameters are part of the key so that unrelated ones do not bust the cache.
+	q := r.URL.Query()
+	v := url.Values{}
+	for _, p := range ep.params {
+		if val := q.Get(p); val != "" {
+			v.Set(p, val)
+		}
+	}
+	key := name + "?" + v.Encode()
+	e, fresh := s.lookup(key)
+	if fresh {
+		writeBody(w, "HIT", e.body)
+		return
+	}
+	if !s.allow() {
+		if e != nil {
+			writeBody(w, "STALE", e.body)
+			return
+		}
+		w.Header().Set("Retry-After", "1")
+		writeError(w, http.StatusTooManyRequests, "rate limit exceeded")
+		return
+	}
+	data, err := ep.fetch(s, v)
+	if err != nil {
+		if br, ok := err.(*badRequest); ok {
+			writeError(w, http.StatusBadRequest, br.msg)
+			return
+		}
+		if e != nil {
+			writeBody(w, "STALE", e.body)
+			return
+		}
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	body, err := json.Marshal(&envelope{Status: "ok", StatusMessage: "Query was successful", Data: data})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.store(key, body)
+	writeBody(w, "MISS", body)
+}
+
+func writeBody(w http.ResponseWriter, cache string, body []byte) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Cache", cache)
+	w.Write(body)
+}
+
+func writeError(w http.ResponseWriter, code int, msg string) {
+	body, _ := json.Marshal(&envelope{Status: "error", StatusMessage: msg})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(body)
+}
+
+// moviesData is the data object of list_movies.json and movie_suggestions.json.
+type moviesData struct {
+	MovieCount uint           `json:"movie_count"`
+	Limit      uint           `json:"limit,omitempty"`
+	Page       uint           `json:"page_number,omitempty"`
+	Movies     []*ytsgo.Movie `json:"movies,omitempty"`
+}
+
+func (s *Server) listMovies(v url.Values) (interface{}, error) {
+	opts, err := ytsgo.ListMoviesQuery(v)
+	if err != nil {
+		return nil, &badRequest{msg: err.Error()}
+	}
+	mvs, err := s.Backend.ListMovies(opts...)
+	if err != nil {
+		return nil, err
+	}
+	if mvs == nil {
+		return &moviesData{}, nil
+	}
+	return &moviesData{MovieCount: mvs.MovieCount, Limit: mvs.Limit, Page: mvs.Page, Movies: mvs.Movies}, nil
+}
+
+func movieID(v url.Values) (int, error) {
+	id, err := strconv.Atoi(v.Get("movie_id"))
+	if err != nil || id <= 0 {
+		return 0, &badRequest{msg: fmt.Sprintf("invalid movie_id %q", v.Get("movie_id"))}
+	}
+	return id, nil
+}
+
+func (s *Server) movie(v url.Values) (interface{}, error) {
+	id, err := movieID(v)
+	if err != nil {
+		return nil, err
+	}
+	var opts []ytsgo.MovieOption
+	if b, _ := strconv.ParseBool(v.Get("with_images")); b {
+		opts = append(opts, ytsgo.MovieWithImages(true))
+	}
+	if b, _ := strconv.ParseBool(v.Get("with_cast")); b {
+		opts = append(opts, ytsgo.MovieWithCast(true))
+	}
+	m, err := s.Backend.Movie(id, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &struct {
+		Movie *ytsgo.Movie `json:"movie"`
+	}{Movie: m}, nil
+}
+
+func (s *Server) suggestions(v url.Values) (interface{}, error) {
+	id, err := movieID(v)
+	if err != nil {
+		return nil, err
+	}
+	mvs, err := s.Backend.Suggestions(id)
+	if err != nil {
+		return nil, err
+	}
+	return &moviesData{MovieCount: uint(len(mvs)), Movies: mvs}, nil
+}
+
+// lookup returns the cached entry for key, if any, and whether it is fresh.
+func (s *Server) lookup(key string) (*entry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.cache[key]
+	if !ok {
+		return nil, false
+	}
+	return e, time.Now().Before(e.expires)
+}
+
+func (s *Server) store(key string, body []byte) {
+	ttl := s.TTL
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	max := s.MaxEntries
+	if max <= 0 {
+		max = DefaultMaxEntries
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cache == nil {
+		s.cache = make(map[string]*entry)
+	}
+	if _, ok := s.cache[key]; !ok && len(s.cache) >= max {
+		s.evict()
+	}
+	s.cache[key] = &entry{body: body, expires: time.Now().Add(ttl)}
+}
+
+// evict removes the entry which expires first. s.mu must be held.
+func (s *Server) evict() {
+	var (
+		oldest string
+		exp    time.Time
+	)
+	for k, e := range s.cache {
+		if oldest == "" || e.expires.Before(exp) {
+			oldest, exp = k, e.expires
+		}
+	}
+	delete(s.cache, oldest)
+}
+
+// allow reports whether an upstream request can be sent now.
+func (s *Server) allow() bool {
+	if s.RateLimit <= 0 {
+		return true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.limiter == nil {
+		s.limiter = newLimiter(s.RateLimit, s.Burst)
+	}
+	return s.limiter.allow(time.Now())
+}
+
+// limiter is a token bucket refilled at rate tokens per second.
+type limiter struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newLimiter(rate float64, burst int) *limiter {
+	if burst <= 0 {
+		burst = 1
+	}
+	return &limiter{rate: rate, burst: float64(burst), tokens: float64(burst)}
+}
+
+func (l *limiter) allow(now time.Time) bool {
+	if !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+	}
+	l.last = now
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
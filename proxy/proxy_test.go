@@ -0,0 +1,255 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qopher/ytsgo"
+)
+
+// upstream serves API responses from the testdata directory of ytsgo.
+type upstream struct {
+	mu    sync.Mutex
+	hits  int
+	files map[string]string
+	fail  bool
+}
+
+func (u *upstream) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.hits++
+	if u.fail {
+		http.Error(w, "down", http.StatusInternalServerError)
+		return
+	}
+	body, err := ioutil.ReadFile(filepath.Join("..", "testdata", u.files[path.Base(r.URL.Path)]))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
+
+func (u *upstream) count() int {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.hits
+}
+
+func newUpstream(t *testing.T) (*upstream, *ytsgo.Client, func()) {
+	t.Helper()
+	u := &upstream{files: map[string]string{
+		"movie_details.json":     "matrix.json",
+		"list_movies.json":       "matrixes.json",
+		"movie_suggestions.json": "suggestions.json",
+	}}
+	ts := httptest.NewServer(u)
+	c, err := ytsgo.New(ytsgo.BaseURL(ts.URL+"/api/v2/"), ytsgo.HTTPTimeout(5*time.Second))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	return u, c, ts.Close
+}
+
+// TestWireFormat checks that a Client pointed at the proxy gets the same
+// results as a Client querying the API directly.
+func TestWireFormat(t *testing.T) {
+	_, direct, closeUpstream := newUpstream(t)
+	defer closeUpstream()
+	ps := httptest.NewServer(&Server{Backend: direct})
+	defer ps.Close()
+	proxied, err := ytsgo.New(ytsgo.BaseURL(ps.URL+"/api/v2/"), ytsgo.HTTPTimeout(5*time.Second))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	testData := []struct {
+		desc string
+		call func(c *ytsgo.Client) (interface{}, error)
+	}{
+		{
+			desc: "movie",
+			call: func(c *ytsgo.Client) (interface{}, error) {
+				return c.Movie(10, ytsgo.MovieWithCast(true), ytsgo.MovieWithImages(true))
+			},
+		},
+		{
+			desc: "list movies",
+			call: func(c *ytsgo.Client) (interface{}, error) {
+				return c.ListMovies(ytsgo.LMSearch("matrix"), ytsgo.LMLimit(3))
+			},
+		},
+		{
+			desc: "suggestions",
+			call: func(c *ytsgo.Client) (interface{}, error) {
+				return c.Suggestions(10)
+			},
+		},
+	}
+	for _, tc := range testData {
+		t.Run(tc.desc, func(t *testing.T) {
+			want, err := tc.call(direct)
+			if err != nil {
+				t.Fatalf("Direct call failed: %v", err)
+			}
+			got, err := tc.call(proxied)
+			if err != nil {
+				t.Fatalf("Proxied call failed: %v", err)
+			}
+			if diff := cmp.Diff(want, got, cmp.AllowUnexported(ytsgo.Torrent{})); diff != "" {
+				t.Errorf("Unexpected proxied result, diff -want +got\n%s", diff)
+			}
+		})
+	}
+}
+
+func get(t *testing.T, url string) (int, string, string) {
+	t.Helper()
+	rsp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("GET %s failed: %v", url, err)
+	}
+	defer rsp.Body.Close()
+	var env envelope
+	if err := json.NewDecoder(rsp.Body).Decode(&env); err != nil {
+		t.Fatalf("Invalid response: %v", err)
+	}
+	return rsp.StatusCode, rsp.Header.Get("X-Cache"), env.Status
+}
+
+func TestCache(t *testing.T) {
+	u, c, closeUpstream := newUpstream(t)
+	defer closeUpstream()
+	s := &Server{Backend: c, TTL: time.Hour}
+	ps := httptest.NewServer(s)
+	defer ps.Close()
+	testData := []struct {
+		desc      string
+		path      string
+		fail      bool
+		expire    bool
+		wantCode  int
+		wantCache string
+		wantHits  int
+	}{
+		{desc: "miss", path: "/api/v2/movie_details.json?movie_id=10", wantCode: http.StatusOK, wantCache: "MISS", wantHits: 1},
+		{desc: "hit", path: "/api/v2/movie_details.json?movie_id=10&foo=bar", wantCode: http.StatusOK, wantCache: "HIT", wantHits: 1},
+		{desc: "other params", path: "/api/v2/movie_details.json?movie_id=10&with_cast=true", wantCode: http.StatusOK, wantCache: "MISS", wantHits: 2},
+		{desc: "stale on error", path: "/api/v2/movie_details.json?movie_id=10", fail: true, expire: true, wantCode: http.StatusOK, wantCache: "STALE", wantHits: 3},
+		{desc: "upstream error", path: "/api/v2/movie_suggestions.json?movie_id=10", fail: true, wantCode: http.StatusBadGateway, wantHits: 4},
+		{desc: "bad movie id", path: "/api/v2/movie_details.json?movie_id=x", wantCode: http.StatusBadRequest, wantHits: 4},
+		{desc: "bad limit", path: "/list_movies.json?limit=x", wantCode: http.StatusBadRequest, wantHits: 4},
+		{desc: "unknown endpoint", path: "/api/v2/user_details.json", wantCode: http.StatusNotFound, wantHits: 4},
+	}
+	for _, tc := range testData {
+		t.Run(tc.desc, func(t *testing.T) {
+			u.mu.Lock()
+			u.fail = tc.fail
+			u.mu.Unlock()
+			if tc.expire {
+				s.mu.Lock()
+				for _, e := range s.cache {
+					e.expires = time.Now()
+				}
+				s.mu.Unlock()
+			}
+			code, cache, status := get(t, ps.URL+tc.path)
+			if code != tc.wantCode {
+				t.Errorf("Unexpected code, got %d want %d", code, tc.wantCode)
+			}
+			if cache != tc.wantCache {
+				t.Errorf("Unexpected X-Cache, got %q want %q", cache, tc.wantCache)
+			}
+			if wantStatus := "ok"; code != http.StatusOK {
+				wantStatus = "error"
+				if status != wantStatus {
+					t.Errorf("Unexpected status, got %q want %q", status, wantStatus)
+				}
+			}
+			if got := u.count(); got != tc.wantHits {
+				t.Errorf("Unexpected upstream hits, got %d want %d", got, tc.wantHits)
+			}
+		})
+	}
+}
+
+type fakeBackend struct {
+	calls int
+	err   error
+}
+
+func (f *fakeBackend) Movie(id int, opts ...ytsgo.MovieOption) (*ytsgo.Movie, error) {
+	f.calls++
+	return &ytsgo.Movie{ID: uint(id)}, f.err
+}
+
+func (f *fakeBackend) ListMovies(opts ...ytsgo.ListMoviesOption) (*ytsgo.Movies, error) {
+	f.calls++
+	return nil, f.err
+}
+
+func (f *fakeBackend) Suggestions(id int) ([]*ytsgo.Movie, error) {
+	f.calls++
+	return nil, f.err
+}
+
+func TestRateLimit(t *testing.T) {
+	b := &fakeBackend{}
+	ps := httptest.NewServer(&Server{Backend: b, RateLimit: 0.001, Burst: 2})
+	defer ps.Close()
+	for i, want := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		code, _, _ := get(t, ps.URL+"/list_movies.json?page="+strconv.Itoa(i+1))
+		if code != want {
+			t.Errorf("Request %d: unexpected code, got %d want %d", i, code, want)
+		}
+	}
+	// Cached responses do not count against the limit.
+	if code, cache, _ := get(t, ps.URL+"/list_movies.json?page=1"); code != http.StatusOK || cache != "HIT" {
+		t.Errorf("Unexpected cached response %d %q", code, cache)
+	}
+	if b.calls != 2 {
+		t.Errorf("Unexpected backend calls, got %d want 2", b.calls)
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	l := newLimiter(2, 1)
+	now := time.Unix(0, 0)
+	for _, tc := range []struct {
+		after time.Duration
+		want  bool
+	}{
+		{after: 0, want: true},
+		{after: 0, want: false},
+		{after: 250 * time.Millisecond, want: false},
+		{after: 250 * time.Millisecond, want: true},
+		{after: 10 * time.Second, want: true},
+		{after: 0, want: false},
+	} {
+		now = now.Add(tc.after)
+		if got := l.allow(now); got != tc.want {
+			t.Errorf("allow(%v) = %v want %v", now, got, tc.want)
+		}
+	}
+}
+
+func TestEviction(t *testing.T) {
+	s := &Server{Backend: &fakeBackend{}, MaxEntries: 2}
+	for _, k := range []string{"a", "b", "c"} {
+		s.store(k, nil)
+		time.Sleep(time.Millisecond)
+	}
+	if _, ok := s.cache["a"]; ok || len(s.cache) != 2 {
+		t.Errorf("Unexpected cache keys after eviction: %v", s.cache)
+	}
+}
@@ -0,0 +1,306 @@
+// Package qbittorrent is a client of the qBittorrent Web API (v2) used to
+// enqueue YTS torrents. The API is described at
+// https://github.com/qbittorrent/qBittorrent/wiki/WebUI-API-(qBittorrent-4.1)
+package qbittorrent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/qopher/ytsgo"
+)
+
+// DefaultURL is a default address of a local qBittorrent Web UI.
+const DefaultURL = "http://localhost:8080"
+
+// maxTorrentSize limits the size of .torrent files downloaded by Upload.
+const maxTorrentSize = 10 << 20
+
+// maxResponseSize limits the size of Web API responses.
+const maxResponseSize = 16 << 20
+
+var (
+	// ErrLoginFailed is returned if qBittorrent rejects the credentials.
+	ErrLoginFailed = errors.New("qbittorrent login failed")
+	// ErrAddFailed is returned if qBittorrent rejects the added torrent.
+	ErrAddFailed = errors.New("qbittorrent failed to add torrent")
+)
+
+// Client sends requests to qBittorrent. It logs in on the first request and
+// again whenever the session expires.
+type Client struct {
+	// URL overrides DefaultURL if not empty.
+	URL      string
+	Username string
+	Password string
+	// HTTPClient is used to send requests, http.DefaultClient if nil.
+	HTTPClient *http.Client
+
+	mu  sync.Mutex
+	sid string
+}
+
+// AddOptions configure added torrents.
+type AddOptions struct {
+	// SavePath overrides the default download directory.
+	SavePath string
+	// Category of the torrent. qBittorrent creates missing categories.
+	Category string
+	// Tags of the torrent, they require qBittorrent 4.2 or newer.
+	Tags []string
+	// Paused adds the torrent without starting it.
+	Paused bool
+}
+
+// Torrent is a torrent returned by torrents/info.
+type Torrent struct {
+	Hash string `json:"hash"`
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+	// Progress is between 0 and 1.
+	Progress float64 `json:"progress"`
+	// DownloadSpeed is in bytes per second.
+	DownloadSpeed int64 `json:"dlspeed"`
+	// ETA is in seconds.
+	ETA      int64  `json:"eta"`
+	State    string `json:"state"`
+	Category string `json:"category"`
+	// Tags is a comma separated list.
+	Tags     string `json:"tags"`
+	SavePath string `json:"save_path"`
+}
+
+// Filter selects torrents returned by Torrents. Empty fields match all torrents.
+type Filter struct {
+	Category string
+	Tag      string
+	// Hashes are info hashes of wanted torrents.
+	Hashes []string
+}
+
+func (c *Client) baseURL() string {
+	if c.URL == "" {
+		return DefaultURL
+	}
+	return strings.TrimSuffix(c.URL, "/")
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient == nil {
+		return http.DefaultClient
+	}
+	return c.HTTPClient
+}
+
+// Login starts a new session. Other methods call it when needed.
+func (c *Client) Login(ctx context.Context) error {
+	form := url.Values{"username": {c.Username}, "password": {c.Password}}
+	req, err := http.NewRequest("POST", c.baseURL()+"/api/v2/auth/login", strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Referer", c.baseURL())
+	rsp, err := c.httpClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+	body, err := ioutil.ReadAll(io.LimitReader(rsp.Body, 1024))
+	if err != nil {
+		return err
+	}
+	if rsp.StatusCode != http.StatusOK || strings.TrimSpace(string(body)) != "Ok." {
+		return ErrLoginFailed
+	}
+	for _, ck := range rsp.Cookies() {
+		if ck.Name == "SID" {
+			c.mu.Lock()
+			c.sid = ck.Value
+			c.mu.Unlock()
+			return nil
+		}
+	}
+	return fmt.Errorf("qbittorrent login returned no session cookie")
+}
+
+// do sends a request built by newReq, logging in first if there is no session
+// or it has expired. The body of the response is returned.
+func (c *Client) do(ctx context.Context, newReq func() (*http.Request, error)) ([]byte, error) {
+	c.mu.Lock()
+	loggedIn := c.sid != ""
+	c.mu.Unlock()
+	if !loggedIn {
+		if err := c.Login(ctx); err != nil {
+			return nil, err
+		}
+	}
+	for attempt := 0; ; attempt++ {
+		req, err := newReq()
+		if err != nil {
+			return nil, err
+		}
+		req = req.WithContext(ctx)
+		req.Header.Set("Referer", c.baseURL())
+		c.mu.Lock()
+		req.AddCookie(&http.Cookie{Name: "SID", Value: c.sid})
+		c.mu.Unlock()
+		rsp, err := c.httpClient().Do(req)
+		if err != nil {
+			return nil, err
+		}
+		body, err := ioutil.ReadAll(io.LimitReader(rsp.Body, maxResponseSize+1))
+		rsp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if len(body) > maxResponseSize {
+			return nil, fmt.Errorf("qbittorrent response is larger than %d bytes", maxResponseSize)
+		}
+		if rsp.StatusCode == http.StatusForbidden && attempt == 0 {
+			if err := c.Login(ctx); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if rsp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("qbittorrent returned code %v: %s", rsp.StatusCode, rsp.Status)
+		}
+		return body, nil
+	}
+}
+
+// add posts a multipart torrents/add request with fields set by addData.
+func (c *Client) add(ctx context.Context, opts AddOptions, addData func(w *multipart.Writer) error) error {
+	body, err := c.do(ctx, func() (*http.Request, error) {
+		var buf bytes.Buffer
+		w := multipart.NewWriter(&buf)
+		if err := addData(w); err != nil {
+			return nil, err
+		}
+		fields := map[string]string{
+			"savepath": opts.SavePath,
+			"category": opts.Category,
+			"tags":     strings.Join(opts.Tags, ","),
+		}
+		if opts.Paused {
+			fields["paused"] = "true"
+		}
+		for k, v := range fields {
+			if v == "" {
+				continue
+			}
+			if err := w.WriteField(k, v); err != nil {
+				return nil, err
+			}
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		req, err := http.NewRequest("POST", c.baseURL()+"/api/v2/torrents/add", &buf)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", w.FormDataContentType())
+		return req, nil
+	})
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(string(body)) == "Fails." {
+		return ErrAddFailed
+	}
+	return nil
+}
+
+// Add adds the torrent using its magnet link.
+func (c *Client) Add(ctx context.Context, t *ytsgo.Torrent, opts AddOptions) error {
+	return c.AddMagnet(ctx, t.Magnet(), opts)
+}
+
+// AddMagnet adds the torrent from a magnet link or a .torrent URL.
+func (c *Client) AddMagnet(ctx context.Context, magnet string, opts AddOptions) error {
+	return c.add(ctx, opts, func(w *multipart.Writer) error {
+		return w.WriteField("urls", magnet)
+	})
+}
+
+// AddFile uploads the content of a .torrent file.
+func (c *Client) AddFile(ctx context.Context, name string, torrent []byte, opts AddOptions) error {
+	return c.add(ctx, opts, func(w *multipart.Writer) error {
+		fw, err := w.CreateFormFile("torrents", name)
+		if err != nil {
+			return err
+		}
+		_, err = fw.Write(torrent)
+		return err
+	})
+}
+
+// Upload downloads the .torrent file from Torrent.URL and uploads it to
+// qBittorrent. It is useful when qBittorrent cannot reach YTS itself.
+func (c *Client) Upload(ctx context.Context, t *ytsgo.Torrent, opts AddOptions) error {
+	if t.URL == nil {
+		return fmt.Errorf("torrent %s has no URL", t.Hash)
+	}
+	req, err := http.NewRequest("GET", t.URL.String(), nil)
+	if err != nil {
+		return err
+	}
+	rsp, err := c.httpClient().Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		return fmt.Errorf("torrent download returned code %v: %s", rsp.StatusCode, rsp.Status)
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(rsp.Body, maxTorrentSize+1))
+	if err != nil {
+		return err
+	}
+	if len(data) > maxTorrentSize {
+		return fmt.Errorf("torrent file of %s is larger than %d bytes", t.Hash, maxTorrentSize)
+	}
+	return c.AddFile(ctx, strings.ToLower(t.Hash)+".torrent", data, opts)
+}
+
+// Torrents returns torrents matching the filter.
+func (c *Client) Torrents(ctx context.Context, f Filter) ([]*Torrent, error) {
+	v := url.Values{}
+	if f.Category != "" {
+		v.Set("category", f.Category)
+	}
+	if f.Tag != "" {
+		v.Set("tag", f.Tag)
+	}
+	if len(f.Hashes) > 0 {
+		hashes := make([]string, len(f.Hashes))
+		for i, h := range f.Hashes {
+			hashes[i] = strings.ToLower(h)
+		}
+		v.Set("hashes", strings.Join(hashes, "|"))
+	}
+	body, err := c.do(ctx, func() (*http.Request, error) {
+		return http.NewRequest("GET", c.baseURL()+"/api/v2/torrents/info?"+v.Encode(), nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	var ret []*Torrent
+	if err := json.Unmarshal(body, &ret); err != nil {
+		return nil, fmt.Errorf("failed to decode qbittorrent torrents: %v", err)
+	}
+	return ret, nil
+}
@@ -0,0 +1,206 @@
+package qbittorrent
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qopher/ytsgo"
+)
+
+// added is a torrent received by torrents/add.
+type added struct {
+	URLs     string
+	File     string
+	FileName string
+	Fields   map[string]string
+}
+
+// fakeQBittorrent implements a subset of the qBittorrent Web API.
+type fakeQBittorrent struct {
+	mu       sync.Mutex
+	sessions int
+	sid      string
+	logins   int
+	added    []added
+	torrents []*Torrent
+	query    url.Values
+	reject   bool
+}
+
+func (f *fakeQBittorrent) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.URL.Path == "/api/v2/auth/login" {
+		f.logins++
+		if r.FormValue("username") != "admin" || r.FormValue("password") != "secret" {
+			w.Write([]byte("Fails."))
+			return
+		}
+		f.sessions++
+		f.sid = "sid" + strconv.Itoa(f.sessions)
+		http.SetCookie(w, &http.Cookie{Name: "SID", Value: f.sid})
+		w.Write([]byte("Ok."))
+		return
+	}
+	if ck, err := r.Cookie("SID"); err != nil || ck.Value != f.sid {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	switch r.URL.Path {
+	case "/api/v2/torrents/add":
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		a := added{URLs: r.FormValue("urls"), Fields: map[string]string{}}
+		for _, k := range []string{"savepath", "category", "tags", "paused"} {
+			if v := r.FormValue(k); v != "" {
+				a.Fields[k] = v
+			}
+		}
+		if file, hdr, err := r.FormFile("torrents"); err == nil {
+			data, _ := ioutil.ReadAll(file)
+			a.File, a.FileName = string(data), hdr.Filename
+		}
+		f.added = append(f.added, a)
+		if f.reject {
+			w.Write([]byte("Fails."))
+			return
+		}
+		w.Write([]byte("Ok."))
+	case "/api/v2/torrents/info":
+		f.query = r.URL.Query()
+		json.NewEncoder(w).Encode(f.torrents)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func newTestClient(t *testing.T, f *fakeQBittorrent) (*Client, func()) {
+	t.Helper()
+	ts := httptest.NewServer(f)
+	return &Client{URL: ts.URL + "/", Username: "admin", Password: "secret"}, ts.Close
+}
+
+func TestAdd(t *testing.T) {
+	f := &fakeQBittorrent{}
+	c, done := newTestClient(t, f)
+	defer done()
+	ctx := context.Background()
+	trt := &ytsgo.Torrent{Hash: "ABCD", Quality: "1080p"}
+	opts := AddOptions{SavePath: "/movies", Category: "yts", Tags: []string{"1080p", "new"}, Paused: true}
+	if err := c.Add(ctx, trt, opts); err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+	if err := c.AddMagnet(ctx, "magnet:?xt=urn:btih:EFGH", AddOptions{}); err != nil {
+		t.Fatalf("AddMagnet() failed: %v", err)
+	}
+	want := []added{
+		{
+			URLs:   trt.Magnet(),
+			Fields: map[string]string{"savepath": "/movies", "category": "yts", "tags": "1080p,new", "paused": "true"},
+		},
+		{URLs: "magnet:?xt=urn:btih:EFGH", Fields: map[string]string{}},
+	}
+	if diff := cmp.Diff(want, f.added); diff != "" {
+		t.Errorf("Unexpected added torrents, diff -want +got\n%s", diff)
+	}
+	if f.logins != 1 {
+		t.Errorf("Unexpected number of logins, got %d want 1", f.logins)
+	}
+}
+
+func TestUpload(t *testing.T) {
+	f := &fakeQBittorrent{}
+	c, done := newTestClient(t, f)
+	defer done()
+	files := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/torrent/download/ABCD" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("d8:announce0:e"))
+	}))
+	defer files.Close()
+	u, _ := url.Parse(files.URL + "/torrent/download/ABCD")
+	if err := c.Upload(context.Background(), &ytsgo.Torrent{URL: u, Hash: "ABCD"}, AddOptions{Category: "yts"}); err != nil {
+		t.Fatalf("Upload() failed: %v", err)
+	}
+	want := []added{{File: "d8:announce0:e", FileName: "abcd.torrent", Fields: map[string]string{"category": "yts"}}}
+	if diff := cmp.Diff(want, f.added); diff != "" {
+		t.Errorf("Unexpected added torrents, diff -want +got\n%s", diff)
+	}
+	missing, _ := url.Parse(files.URL + "/torrent/download/NONE")
+	if err := c.Upload(context.Background(), &ytsgo.Torrent{URL: missing}, AddOptions{}); err == nil {
+		t.Error("Upload() of missing file succeeded, want error")
+	}
+	if err := c.Upload(context.Background(), &ytsgo.Torrent{}, AddOptions{}); err == nil {
+		t.Error("Upload() without URL succeeded, want error")
+	}
+}
+
+func TestTorrents(t *testing.T) {
+	f := &fakeQBittorrent{torrents: []*Torrent{
+		{Hash: "abcd", Name: "The Matrix", Progress: 0.25, State: "downloading", Category: "yts", Tags: "1080p"},
+	}}
+	c, done := newTestClient(t, f)
+	defer done()
+	got, err := c.Torrents(context.Background(), Filter{Category: "yts", Tag: "1080p", Hashes: []string{"ABCD", "EFGH"}})
+	if err != nil {
+		t.Fatalf("Torrents() failed: %v", err)
+	}
+	if diff := cmp.Diff(f.torrents, got); diff != "" {
+		t.Errorf("Unexpected torrents, diff -want +got\n%s", diff)
+	}
+	wantQuery := url.Values{"category": {"yts"}, "tag": {"1080p"}, "hashes": {"abcd|efgh"}}
+	if diff := cmp.Diff(wantQuery, f.query); diff != "" {
+		t.Errorf("Unexpected query, diff -want +got\n%s", diff)
+	}
+}
+
+func TestSessionExpired(t *testing.T) {
+	f := &fakeQBittorrent{}
+	c, done := newTestClient(t, f)
+	defer done()
+	ctx := context.Background()
+	if _, err := c.Torrents(ctx, Filter{}); err != nil {
+		t.Fatalf("Torrents() failed: %v", err)
+	}
+	f.mu.Lock()
+	f.sid = "expired"
+	f.mu.Unlock()
+	if _, err := c.Torrents(ctx, Filter{}); err != nil {
+		t.Fatalf("Torrents() after session expired failed: %v", err)
+	}
+	if f.logins != 2 {
+		t.Errorf("Unexpected number of logins, got %d want 2", f.logins)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	f := &fakeQBittorrent{reject: true}
+	c, done := newTestClient(t, f)
+	defer done()
+	ctx := context.Background()
+	if err := c.AddMagnet(ctx, "magnet:?xt=urn:btih:ABCD", AddOptions{}); err != ErrAddFailed {
+		t.Errorf("Unexpected error, got %v want %v", err, ErrAddFailed)
+	}
+	f.torrents = []*Torrent{{Name: strings.Repeat("x", maxResponseSize)}}
+	if _, err := c.Torrents(ctx, Filter{}); err == nil {
+		t.Error("Expected an error for too large response")
+	}
+	c.Password = "bad"
+	c.sid = ""
+	if _, err := c.Torrents(ctx, Filter{}); err != ErrLoginFailed {
+		t.Errorf("Unexpected error, got %v want %v", err, ErrLoginFailed)
+	}
+}
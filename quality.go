@@ -0,0 +1,46 @@
+package ytsgo
+
+// File quality.go contains helpers to compare torrent qualities.
+
+import (
+	"strings"
+)
+
+// Qualities lists torrent qualities served by YTS, from the lowest.
+var Qualities = []string{"480p", "720p", "1080p", "2160p", "3D"}
+
+// QualityRank returns a rank of the quality used to compare resolutions.
+// Higher rank means better resolution. 3D and unknown qualities have rank 0.
+func QualityRank(q string) int {
+	switch strings.ToLower(q) {
+	case "480p":
+		return 1
+	case "720p":
+		return 2
+	case "1080p":
+		return 3
+	case "2160p", "4k":
+		return 4
+	}
+	return 0
+}
+
+// BestTorrent returns the torrent with the highest quality rank. Ties are
+// resolved by the number of seeds. Nil is returned if there are no torrents.
+func BestTorrent(torrents []*Torrent) *Torrent {
+	var best *Torrent
+	for _, t := range torrents {
+		if t == nil {
+			continue
+		}
+		if best == nil {
+			best = t
+			continue
+		}
+		r, br := QualityRank(t.Quality), QualityRank(best.Quality)
+		if r > br || r == br && t.Seeds > best.Seeds {
+			best = t
+		}
+	}
+	return best
+}
@@ -0,0 +1,56 @@
+package ytsgo
+
+import (
+	"testing"
+)
+
+func TestQualityRank(t *testing.T) {
+	for i := 1; i < 4; i++ {
+		if QualityRank(Qualities[i-1]) >= QualityRank(Qualities[i]) {
+			t.Errorf("Quality %q should rank lower than %q", Qualities[i-1], Qualities[i])
+		}
+	}
+	if got := QualityRank("3D"); got != 0 {
+		t.Errorf("Unexpected rank of 3D, got %d want 0", got)
+	}
+	if got, want := QualityRank("4K"), QualityRank("2160p"); got != want {
+		t.Errorf("Unexpected rank of 4K, got %d want %d", got, want)
+	}
+}
+
+func TestBestTorrent(t *testing.T) {
+	testData := []struct {
+		desc     string
+		torrents []*Torrent
+		want     string
+	}{
+		{
+			desc: "highest quality",
+			torrents: []*Torrent{
+				{Hash: "a", Quality: "720p", Seeds: 100},
+				{Hash: "b", Quality: "2160p", Seeds: 1},
+				{Hash: "c", Quality: "3D", Seeds: 5},
+			},
+			want: "b",
+		},
+		{
+			desc: "more seeds",
+			torrents: []*Torrent{
+				{Hash: "a", Quality: "1080p", Seeds: 10, Type: "bluray"},
+				nil,
+				{Hash: "b", Quality: "1080p", Seeds: 20, Type: "web"},
+			},
+			want: "b",
+		},
+	}
+	for _, tc := range testData {
+		t.Run(tc.desc, func(t *testing.T) {
+			if got := BestTorrent(tc.torrents); got.Hash != tc.want {
+				t.Errorf("Unexpected torrent, got %q want %q", got.Hash, tc.want)
+			}
+		})
+	}
+	if got := BestTorrent(nil); got != nil {
+		t.Errorf("Unexpected torrent for empty list: %+v", got)
+	}
+}
@@ -0,0 +1,285 @@
+// Package recommend finds similar movies offline, using only metadata of
+// movies stored in a local catalog.
+package recommend
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/qopher/ytsgo"
+)
+
+// Weights sets how much each feature contributes to the similarity score.
+type Weights struct {
+	Genres      float64
+	Cast        float64
+	Year        float64
+	Rating      float64
+	Language    float64
+	Description float64
+}
+
+var (
+	// DefaultWeights are used unless UseWeights option is provided.
+	DefaultWeights = Weights{
+		Genres:      3,
+		Cast:        2,
+		Year:        1,
+		Rating:      1,
+		Language:    1,
+		Description: 2,
+	}
+	// YearScale is a difference in years for which year similarity drops to zero.
+	YearScale = 30.0
+)
+
+// stopWords are skipped when building description term vectors.
+var stopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "his": true, "her": true,
+	"who": true, "that": true, "this": true, "from": true, "they": true, "their": true,
+	"into": true, "are": true, "was": true, "has": true, "have": true, "but": true,
+	"not": true, "when": true, "what": true, "will": true, "its": true, "him": true,
+	"she": true, "out": true, "about": true, "after": true, "one": true, "all": true,
+}
+
+// Option changes the default behavior of the Recommender.
+type Option func(r *Recommender)
+
+// UseWeights overrides DefaultWeights.
+func UseWeights(w Weights) Option {
+	return func(r *Recommender) {
+		r.weights = w
+	}
+}
+
+// Recommender scores similarity between movies.
+type Recommender struct {
+	weights Weights
+	movies  []*ytsgo.Movie
+	byID    map[uint]*ytsgo.Movie
+	terms   map[uint]map[string]float64
+}
+
+// New builds a Recommender for the movies, eg. from catalog.Catalog.Movies.
+// Nil movies are skipped.
+func New(movies []*ytsgo.Movie, opts ...Option) *Recommender {
+	r := &Recommender{
+		weights: DefaultWeights,
+		byID:    make(map[uint]*ytsgo.Movie),
+		terms:   make(map[uint]map[string]float64),
+	}
+	for _, o := range opts {
+		o(r)
+	}
+	for _, m := range movies {
+		if m != nil {
+			r.movies = append(r.movies, m)
+		}
+	}
+	counts := make(map[uint]map[string]int)
+	docFreq := make(map[string]int)
+	for _, m := range r.movies {
+		r.byID[m.ID] = m
+		c := termCounts(description(m))
+		counts[m.ID] = c
+		for t := range c {
+			docFreq[t]++
+		}
+	}
+	for id, c := range counts {
+		v := make(map[string]float64)
+		var norm float64
+		for t, n := range c {
+			w := float64(n) * math.Log(float64(1+len(r.movies))/float64(1+docFreq[t]))
+			v[t] = w
+			norm += w * w
+		}
+		norm = math.Sqrt(norm)
+		for t := range v {
+			if norm > 0 {
+				v[t] /= norm
+			}
+		}
+		r.terms[id] = v
+	}
+	return r
+}
+
+func description(m *ytsgo.Movie) string {
+	if m.DescriptionFull != "" {
+		return m.DescriptionFull
+	}
+	return m.DescriptionIntro
+}
+
+func termCounts(text string) map[string]int {
+	ret := make(map[string]int)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, w := range words {
+		if len([]rune(w)) < 3 || stopWords[w] {
+			continue
+		}
+		ret[w]++
+	}
+	return ret
+}
+
+// Similarity returns a score between 0 and 1 of how similar two movies from
+// the Recommender are. Zero is returned for unknown movies.
+func (r *Recommender) Similarity(a, b uint) float64 {
+	ma, mb := r.byID[a], r.byID[b]
+	if ma == nil || mb == nil {
+		return 0
+	}
+	w := r.weights
+	total := w.Genres + w.Cast + w.Year + w.Rating + w.Language + w.Description
+	if total == 0 {
+		return 0
+	}
+	score := w.Genres*jaccard(lower(ma.Genres), lower(mb.Genres)) +
+		w.Cast*jaccard(castKeys(ma), castKeys(mb)) +
+		w.Year*yearSimilarity(ma.Year, mb.Year) +
+		w.Rating*ratingSimilarity(ma.Rating, mb.Rating) +
+		w.Language*languageSimilarity(ma.Language, mb.Language) +
+		w.Description*cosine(r.terms[a], r.terms[b])
+	return score / total
+}
+
+func lower(s []string) []string {
+	ret := make([]string, 0, len(s))
+	for _, v := range s {
+		ret = append(ret, strings.ToLower(v))
+	}
+	return ret
+}
+
+// castKeys identifies cast members by IMDb code, or by name if the code is missing.
+func castKeys(m *ytsgo.Movie) []string {
+	var ret []string
+	for _, c := range m.Cast {
+		if c == nil {
+			continue
+		}
+		if c.IMDBCode != "" {
+			ret = append(ret, c.IMDBCode)
+			continue
+		}
+		ret = append(ret, strings.ToLower(c.Name))
+	}
+	return ret
+}
+
+func jaccard(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	set := make(map[string]bool)
+	for _, v := range a {
+		set[v] = true
+	}
+	inter := 0
+	union := len(set)
+	seen := make(map[string]bool)
+	for _, v := range b {
+		if seen[v] {
+			continue
+		}
+		seen[v] = true
+		if set[v] {
+			inter++
+		} else {
+			union++
+		}
+	}
+	return float64(inter) / float64(union)
+}
+
+func yearSimilarity(a, b uint) float64 {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	d := math.Abs(float64(a) - float64(b))
+	return math.Max(0, 1-d/YearScale)
+}
+
+func ratingSimilarity(a, b float32) float64 {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return math.Max(0, 1-math.Abs(float64(a)-float64(b))/10)
+}
+
+func languageSimilarity(a, b string) float64 {
+	if a != "" && strings.EqualFold(a, b) {
+		return 1
+	}
+	return 0
+}
+
+func cosine(a, b map[string]float64) float64 {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	var ret float64
+	for t, w := range a {
+		ret += w * b[t]
+	}
+	return ret
+}
+
+// Scored is a recommended movie with its similarity score.
+type Scored struct {
+	Movie *ytsgo.Movie
+	Score float64
+}
+
+// Similar returns up to n movies most similar to the movie with given ID, or
+// all of them if n <= 0.
+func (r *Recommender) Similar(id uint, n int) []Scored {
+	return r.ForHistory([]uint{id}, n)
+}
+
+// ForHistory returns up to n movies most similar to the watched movies, or all
+// of them if n <= 0. Score of a movie is its average similarity to watched
+// movies. Watched movies are never recommended.
+func (r *Recommender) ForHistory(watched []uint, n int) []Scored {
+	seen := make(map[uint]bool)
+	var history []uint
+	for _, id := range watched {
+		if r.byID[id] != nil && !seen[id] {
+			history = append(history, id)
+		}
+		seen[id] = true
+	}
+	if len(history) == 0 {
+		return nil
+	}
+	var ret []Scored
+	for _, m := range r.movies {
+		if seen[m.ID] {
+			continue
+		}
+		var score float64
+		for _, id := range history {
+			score += r.Similarity(id, m.ID)
+		}
+		score /= float64(len(history))
+		if score > 0 {
+			ret = append(ret, Scored{Movie: m, Score: score})
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Score != ret[j].Score {
+			return ret[i].Score > ret[j].Score
+		}
+		return ret[i].Movie.ID < ret[j].Movie.ID
+	})
+	if n > 0 && len(ret) > n {
+		ret = ret[:n]
+	}
+	return ret
+}
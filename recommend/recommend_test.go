@@ -0,0 +1,153 @@
+package recommend
+
+import (
+	"math"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qopher/ytsgo"
+)
+
+var testMovies = []*ytsgo.Movie{
+	{
+		ID:              1,
+		Title:           "The Matrix",
+		Year:            1999,
+		Rating:          8.7,
+		Language:        "English",
+		Genres:          []string{"Action", "Sci-Fi"},
+		Cast:            []*ytsgo.Cast{{Name: "Keanu Reeves", IMDBCode: "0000206"}, {Name: "Carrie-Anne Moss", IMDBCode: "0005251"}},
+		DescriptionFull: "A computer hacker learns from mysterious rebels about the true nature of his reality and the machines that control the simulation.",
+	},
+	{
+		ID:              2,
+		Title:           "The Matrix Reloaded",
+		Year:            2003,
+		Rating:          7.2,
+		Language:        "English",
+		Genres:          []string{"Action", "Sci-Fi"},
+		Cast:            []*ytsgo.Cast{{Name: "Keanu Reeves", IMDBCode: "0000206"}, {Name: "Carrie-Anne Moss", IMDBCode: "0005251"}},
+		DescriptionFull: "Neo and the rebels fight the machines while the simulation hunts the hacker and Zion waits.",
+	},
+	{
+		ID:               3,
+		Title:            "John Wick",
+		Year:             2014,
+		Rating:           7.4,
+		Language:         "English",
+		Genres:           []string{"Action", "Thriller"},
+		Cast:             []*ytsgo.Cast{{Name: "Keanu Reeves"}},
+		DescriptionIntro: "An ex-hit-man comes out of retirement to track down the gangsters that killed his dog.",
+	},
+	{
+		ID:              4,
+		Title:           "The Notebook",
+		Year:            2004,
+		Rating:          7.8,
+		Language:        "English",
+		Genres:          []string{"Drama", "Romance"},
+		DescriptionFull: "A poor yet passionate young man falls in love with a rich young woman during one summer.",
+	},
+	{
+		ID:              5,
+		Title:           "Amelie",
+		Year:            2001,
+		Rating:          8.3,
+		Language:        "French",
+		Genres:          []string{"Comedy", "Romance"},
+		DescriptionFull: "Amelie is an innocent and naive girl in Paris who decides to help those around her and discovers love.",
+	},
+}
+
+func titles(s []Scored) []string {
+	var ret []string
+	for _, m := range s {
+		ret = append(ret, m.Movie.Title)
+	}
+	return ret
+}
+
+func TestSimilar(t *testing.T) {
+	r := New(testMovies)
+	testData := []struct {
+		desc string
+		id   uint
+		n    int
+		want []string
+	}{
+		{
+			desc: "sequel first",
+			id:   1,
+			n:    2,
+			want: []string{"The Matrix Reloaded", "John Wick"},
+		},
+		{
+			desc: "romance",
+			id:   5,
+			n:    1,
+			want: []string{"The Notebook"},
+		},
+		{
+			desc: "unknown movie",
+			id:   100,
+			n:    3,
+		},
+		{
+			desc: "negative n",
+			id:   5,
+			n:    -1,
+			want: []string{"The Notebook", "The Matrix", "The Matrix Reloaded", "John Wick"},
+		},
+	}
+	for _, tc := range testData {
+		t.Run(tc.desc, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, titles(r.Similar(tc.id, tc.n))); diff != "" {
+				t.Errorf("Unexpected recommendations, diff -want +got\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestForHistory(t *testing.T) {
+	r := New(testMovies)
+	got := r.ForHistory([]uint{1, 2, 2, 100}, 10)
+	if diff := cmp.Diff([]string{"John Wick", "The Notebook", "Amelie"}, titles(got)); diff != "" {
+		t.Errorf("Unexpected recommendations, diff -want +got\n%s", diff)
+	}
+	for i := 1; i < len(got); i++ {
+		if got[i].Score > got[i-1].Score {
+			t.Errorf("Recommendations are not sorted by score: %v", got)
+		}
+	}
+	if got := r.ForHistory(nil, 10); got != nil {
+		t.Errorf("Unexpected recommendations for empty history: %v", titles(got))
+	}
+}
+
+func TestNilMovies(t *testing.T) {
+	r := New(append([]*ytsgo.Movie{nil}, testMovies...))
+	if diff := cmp.Diff([]string{"The Notebook"}, titles(r.Similar(5, 1))); diff != "" {
+		t.Errorf("Unexpected recommendations, diff -want +got\n%s", diff)
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	r := New(testMovies)
+	if got := r.Similarity(1, 1); math.Abs(got-1) > 1e-9 {
+		t.Errorf("Movie should be identical to itself, got %v", got)
+	}
+	if a, b := r.Similarity(1, 2), r.Similarity(2, 1); a != b {
+		t.Errorf("Similarity is not symmetric, got %v and %v", a, b)
+	}
+	genresOnly := New(testMovies, UseWeights(Weights{Genres: 1}))
+	if got, want := genresOnly.Similarity(1, 3), 1.0/3; math.Abs(got-want) > 1e-9 {
+		t.Errorf("Unexpected genre similarity, got %v want %v", got, want)
+	}
+	languageOnly := New(testMovies, UseWeights(Weights{Language: 1}))
+	if got := languageOnly.Similarity(4, 5); got != 0 {
+		t.Errorf("Unexpected language similarity, got %v want 0", got)
+	}
+	if got := New(testMovies, UseWeights(Weights{})).Similarity(1, 2); got != 0 {
+		t.Errorf("Unexpected similarity with zero weights, got %v", got)
+	}
+}
@@ -0,0 +1,253 @@
+// Package release parses and formats release names of movie torrents, eg.
+// "The Matrix (1999) [1080p] [BluRay] [YTS.LT]" or
+// "The.Matrix.1999.1080p.BluRay.x264-YIFY".
+package release
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/qopher/ytsgo"
+)
+
+// DefaultGroup is the release group used by FromTorrent.
+const DefaultGroup = "YTS.LT"
+
+// Release is a parsed release name.
+type Release struct {
+	Title string
+	// Year is 0 if unknown.
+	Year uint
+	// Quality is one of ytsgo.Qualities, empty if unknown.
+	Quality string
+	// Type is the source type as used by ytsgo.Torrent, eg. "bluray" or "web".
+	Type string
+	// Codec is the video codec, "x264", "x265" or "xvid".
+	Codec string
+	// Audio is the audio hint, eg. "5.1" or "AAC".
+	Audio string
+	// Group is the release group, eg. "YTS.LT" or "YIFY".
+	Group string
+	// IMDBCode is set if the name contains one, eg. "tt0133093".
+	IMDBCode string
+	// Confidence is between 0 and 1 and grows with the number of recognized parts.
+	Confidence float64
+}
+
+// Confidence weights of recognized parts, they sum up to 1.
+const (
+	titleWeight   = 0.35
+	yearWeight    = 0.3
+	qualityWeight = 0.2
+	groupWeight   = 0.1
+	sourceWeight  = 0.05
+)
+
+var (
+	videoExts = map[string]bool{
+		".mkv": true, ".mp4": true, ".avi": true, ".m4v": true, ".mov": true, ".wmv": true,
+		".mpg": true, ".mpeg": true, ".ts": true,
+	}
+	imdbRe = regexp.MustCompile(`\btt\d{7,8}\b`)
+	// ytsRe matches YTS groups anywhere, they are often bracketed, eg. "x264-[YTS.LT]".
+	ytsRe = regexp.MustCompile(`(?i)\b(yts(\.[a-z]{2,3})?|yify)\b`)
+	// sceneGroupRe matches a scene group at the end, eg. "x264-SPARKS".
+	sceneGroupRe = regexp.MustCompile(`-\s*\[?([A-Za-z0-9]+)\]?\s*$`)
+	audioRe      = regexp.MustCompile(`(?i)(?:^|[\s.\[(_-])(7\.1|5\.1|2\.0|aac|ac3|dts|atmos)(?:$|[\s.\])_-])`)
+	yearRe       = regexp.MustCompile(`^(19|20)\d\d$`)
+
+	qualities = map[string]string{
+		"480p": "480p", "720p": "720p", "1080p": "1080p", "2160p": "2160p", "4k": "2160p", "uhd": "2160p", "3d": "3D",
+	}
+	sources = map[string]string{
+		"bluray": "bluray", "blu-ray": "bluray", "bdrip": "bluray", "brrip": "bluray", "bdremux": "bluray", "remux": "bluray",
+		"web": "web", "webrip": "web", "web-dl": "web", "webdl": "web",
+		"dvdrip": "dvd", "dvd": "dvd", "hdtv": "hdtv", "hdrip": "hdrip",
+	}
+	codecs = map[string]string{
+		"x264": "x264", "h264": "x264", "h.264": "x264", "avc": "x264",
+		"x265": "x265", "h265": "x265", "h.265": "x265", "hevc": "x265",
+		"xvid": "xvid",
+	}
+	// tags are other tokens which end the title.
+	tags = map[string]bool{
+		"10bit": true, "8bit": true, "hdr": true, "proper": true, "repack": true, "extended": true,
+		"unrated": true, "remastered": true, "imax": true, "multi": true, "subs": true,
+	}
+	typeLabels = map[string]string{"bluray": "BluRay", "web": "WEBRip", "dvd": "DVD", "hdtv": "HDTV", "hdrip": "HDRip"}
+)
+
+// IsVideo reports whether the file name has a video extension.
+func IsVideo(name string) bool {
+	return videoExts[strings.ToLower(filepath.Ext(name))]
+}
+
+// IMDBCode returns the first IMDb code found in s, empty if there is none.
+func IMDBCode(s string) string {
+	return imdbRe.FindString(s)
+}
+
+// Parse parses a release name. The extension of video, .torrent and .magnet
+// files is ignored. Parse never fails, unrecognized names result in a low Confidence.
+func Parse(name string) Release {
+	var r Release
+	s := strings.TrimSpace(name)
+	if ext := strings.ToLower(filepath.Ext(s)); videoExts[ext] || ext == ".torrent" || ext == ".magnet" {
+		s = s[:len(s)-len(ext)]
+	}
+	if code := imdbRe.FindString(s); code != "" {
+		r.IMDBCode = code
+		s = imdbRe.ReplaceAllString(s, " ")
+	}
+	if loc := ytsRe.FindStringIndex(s); loc != nil {
+		r.Group = strings.ToUpper(s[loc[0]:loc[1]])
+		s = s[:loc[0]] + " " + s[loc[1]:]
+	} else if m := sceneGroupRe.FindStringSubmatchIndex(s); m != nil && !isTag(s[m[2]:m[3]]) && hasTag(s[:m[0]]) {
+		r.Group = s[m[2]:m[3]]
+		s = s[:m[0]]
+	}
+	if m := audioRe.FindStringSubmatchIndex(s); m != nil {
+		r.Audio = strings.ToUpper(s[m[2]:m[3]])
+		s = s[:m[2]] + " " + s[m[3]:]
+	}
+	if !strings.Contains(s, " ") || strings.Count(s, ".") > 2 {
+		s = strings.NewReplacer(".", " ", "_", " ").Replace(s)
+	}
+	s = strings.NewReplacer("[", " [ ", "]", " ", "(", " ( ", ")", " ").Replace(s)
+	tokens := strings.Fields(s)
+	titleEnd, yearIdx := len(tokens), -1
+	for i, tok := range tokens {
+		l := strings.Trim(strings.ToLower(tok), "-")
+		switch {
+		case l == "[" || l == "(":
+			if i > 0 && titleEnd == len(tokens) && l == "[" {
+				titleEnd = i
+			}
+			continue
+		case i > 0 && yearRe.MatchString(l):
+			// The last year before the tags is the release year, so
+			// "Blade Runner 2049 (2017)" and "1917 (2019)" parse.
+			if i < titleEnd || r.Year == 0 {
+				y, _ := strconv.Atoi(l)
+				r.Year = uint(y)
+			}
+			if i < titleEnd {
+				yearIdx = i
+			}
+			continue
+		case qualities[l] != "":
+			if r.Quality == "" {
+				r.Quality = qualities[l]
+			}
+		case sources[l] != "":
+			if r.Type == "" {
+				r.Type = sources[l]
+			}
+		case codecs[l] != "":
+			if r.Codec == "" {
+				r.Codec = codecs[l]
+			}
+		case tags[l]:
+		default:
+			continue
+		}
+		if i > 0 && i < titleEnd {
+			titleEnd = i
+		}
+	}
+	if yearIdx >= 0 && yearIdx < titleEnd {
+		titleEnd = yearIdx
+	}
+	var title []string
+	for _, tok := range tokens[:titleEnd] {
+		if tok != "(" && tok != "[" {
+			title = append(title, tok)
+		}
+	}
+	r.Title = strings.Trim(strings.Join(title, " "), " -")
+	r.Confidence = r.confidence()
+	return r
+}
+
+// hasTag reports whether s contains a quality, source, codec or other tag,
+// so that a trailing "-Word" is a release group and not a part of the title.
+func hasTag(s string) bool {
+	for _, tok := range strings.FieldsFunc(s, func(r rune) bool { return strings.ContainsRune(" ._[]()", r) }) {
+		if isTag(tok) {
+			return true
+		}
+	}
+	return false
+}
+
+func isTag(s string) bool {
+	l := strings.ToLower(s)
+	return qualities[l] != "" || sources[l] != "" || codecs[l] != "" || tags[l]
+}
+
+func (r *Release) confidence() float64 {
+	var c float64
+	if r.Title != "" {
+		c += titleWeight
+	}
+	if r.Year != 0 {
+		c += yearWeight
+	}
+	if r.Quality != "" {
+		c += qualityWeight
+	}
+	if r.Group != "" {
+		c += groupWeight
+	}
+	if r.Type != "" || r.Codec != "" {
+		c += sourceWeight
+	}
+	// Avoid results like 0.30000000000000004.
+	return float64(int(c*100+0.5)) / 100
+}
+
+// String formats the release in the YTS style, eg.
+// "The Matrix (1999) [1080p] [BluRay] [5.1] [YTS.LT]". Unknown parts are omitted.
+func (r Release) String() string {
+	var b strings.Builder
+	b.WriteString(r.Title)
+	if r.Year > 0 {
+		fmt.Fprintf(&b, " (%d)", r.Year)
+	}
+	for _, p := range []string{r.Quality, typeLabel(r.Type), r.Audio, r.Group} {
+		if p != "" {
+			fmt.Fprintf(&b, " [%s]", p)
+		}
+	}
+	return b.String()
+}
+
+func typeLabel(t string) string {
+	if l, ok := typeLabels[strings.ToLower(t)]; ok {
+		return l
+	}
+	return t
+}
+
+// FromTorrent returns the release of the torrent of the movie with DefaultGroup.
+func FromTorrent(m *ytsgo.Movie, t *ytsgo.Torrent) Release {
+	r := Release{
+		Title:    m.Title,
+		Year:     m.Year,
+		Quality:  t.Quality,
+		Type:     strings.ToLower(t.Type),
+		Group:    DefaultGroup,
+		IMDBCode: m.IMDBCode,
+	}
+	r.Confidence = r.confidence()
+	return r
+}
+
+// Format returns the release name of the torrent of the movie, eg.
+// "The Matrix (1999) [1080p] [BluRay] [YTS.LT]".
+func Format(m *ytsgo.Movie, t *ytsgo.Torrent) string {
+	return FromTorrent(m, t).String()
+}
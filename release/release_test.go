@@ -0,0 +1,259 @@
+package release
+
+import (
+	"bufio"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qopher/ytsgo"
+)
+
+func TestParse(t *testing.T) {
+	testData := []struct {
+		in   string
+		want Release
+	}{
+		// YTS style names.
+		{
+			in:   "The Matrix (1999) [1080p] [YTS.LT]",
+			want: Release{Title: "The Matrix", Year: 1999, Quality: "1080p", Group: "YTS.LT", Confidence: 0.95},
+		},
+		{
+			in:   "The Matrix (1999) [720p] [BluRay] [YTS.MX]",
+			want: Release{Title: "The Matrix", Year: 1999, Quality: "720p", Type: "bluray", Group: "YTS.MX", Confidence: 1},
+		},
+		{
+			in:   "Parasite (2019) [1080p] [BluRay] [5.1] [YTS.MX]",
+			want: Release{Title: "Parasite", Year: 2019, Quality: "1080p", Type: "bluray", Audio: "5.1", Group: "YTS.MX", Confidence: 1},
+		},
+		{
+			in:   "Dune (2021) [2160p] [4K] [WEB] [5.1] [YTS.MX].mkv",
+			want: Release{Title: "Dune", Year: 2021, Quality: "2160p", Type: "web", Audio: "5.1", Group: "YTS.MX", Confidence: 1},
+		},
+		{
+			in:   "Avatar (2009) [3D] [YTS.AG]",
+			want: Release{Title: "Avatar", Year: 2009, Quality: "3D", Group: "YTS.AG", Confidence: 0.95},
+		},
+		{
+			in:   "Joker (2019) [WEBRip] [720p] [YTS.LT]",
+			want: Release{Title: "Joker", Year: 2019, Quality: "720p", Type: "web", Group: "YTS.LT", Confidence: 1},
+		},
+		{
+			in:   "The Lord of the Rings: The Fellowship of the Ring (2001) [1080p] [YTS.LT].torrent",
+			want: Release{Title: "The Lord of the Rings: The Fellowship of the Ring", Year: 2001, Quality: "1080p", Group: "YTS.LT", Confidence: 0.95},
+		},
+		{
+			in:   "Amélie (2001) [480p] [YTS.LT]",
+			want: Release{Title: "Amélie", Year: 2001, Quality: "480p", Group: "YTS.LT", Confidence: 0.95},
+		},
+		{
+			in:   "Mr. & Mrs. Smith (2005) [1080p] [YTS.LT]",
+			want: Release{Title: "Mr. & Mrs. Smith", Year: 2005, Quality: "1080p", Group: "YTS.LT", Confidence: 0.95},
+		},
+		{
+			in:   "Spider-Man (2002) [720p] [YTS.LT]",
+			want: Release{Title: "Spider-Man", Year: 2002, Quality: "720p", Group: "YTS.LT", Confidence: 0.95},
+		},
+		{
+			in:   "The Matrix (1999) [1080p] [YTS.LT].magnet",
+			want: Release{Title: "The Matrix", Year: 1999, Quality: "1080p", Group: "YTS.LT", Confidence: 0.95},
+		},
+		// Titles with numbers.
+		{
+			in:   "Blade Runner 2049 (2017) [1080p] [YTS.LT]",
+			want: Release{Title: "Blade Runner 2049", Year: 2017, Quality: "1080p", Group: "YTS.LT", Confidence: 0.95},
+		},
+		{
+			in:   "1917 (2019) [720p] [BluRay] [YTS.MX]",
+			want: Release{Title: "1917", Year: 2019, Quality: "720p", Type: "bluray", Group: "YTS.MX", Confidence: 1},
+		},
+		{
+			in:   "2001 A Space Odyssey (1968) [1080p]",
+			want: Release{Title: "2001 A Space Odyssey", Year: 1968, Quality: "1080p", Confidence: 0.85},
+		},
+		{
+			in:   "12 Angry Men (1957) [720p] [YTS.AG]",
+			want: Release{Title: "12 Angry Men", Year: 1957, Quality: "720p", Group: "YTS.AG", Confidence: 0.95},
+		},
+		{
+			in:   "Se7en (1995) [1080p] [YTS.LT]",
+			want: Release{Title: "Se7en", Year: 1995, Quality: "1080p", Group: "YTS.LT", Confidence: 0.95},
+		},
+		{
+			in:   "Ocean's Eleven (2001) [2160p] [YTS.LT]",
+			want: Release{Title: "Ocean's Eleven", Year: 2001, Quality: "2160p", Group: "YTS.LT", Confidence: 0.95},
+		},
+		// Scene and YIFY style names.
+		{
+			in:   "The.Matrix.1999.720p.BluRay.x264-YIFY.mp4",
+			want: Release{Title: "The Matrix", Year: 1999, Quality: "720p", Type: "bluray", Codec: "x264", Group: "YIFY", Confidence: 1},
+		},
+		{
+			in:   "The.Matrix.1999.1080p.BrRip.x264.YIFY",
+			want: Release{Title: "The Matrix", Year: 1999, Quality: "1080p", Type: "bluray", Codec: "x264", Group: "YIFY", Confidence: 1},
+		},
+		{
+			in:   "The.Matrix.1999.1080p.BluRay.x264-[YTS.LT].mp4",
+			want: Release{Title: "The Matrix", Year: 1999, Quality: "1080p", Type: "bluray", Codec: "x264", Group: "YTS.LT", Confidence: 1},
+		},
+		{
+			in:   "Inception.2010.1080p.BluRay.x264.DTS-SPARKS.mkv",
+			want: Release{Title: "Inception", Year: 2010, Quality: "1080p", Type: "bluray", Codec: "x264", Audio: "DTS", Group: "SPARKS", Confidence: 1},
+		},
+		{
+			in:   "Heat.1995.REMASTERED.2160p.UHD.BluRay.x265.10bit.HDR-TERMiNAL",
+			want: Release{Title: "Heat", Year: 1995, Quality: "2160p", Type: "bluray", Codec: "x265", Group: "TERMiNAL", Confidence: 1},
+		},
+		{
+			in:   "Tenet.2020.WEB-DL.1080p.H264.AAC-EVO",
+			want: Release{Title: "Tenet", Year: 2020, Quality: "1080p", Type: "web", Codec: "x264", Audio: "AAC", Group: "EVO", Confidence: 1},
+		},
+		{
+			in:   "Alien_1979_DVDRip_XviD",
+			want: Release{Title: "Alien", Year: 1979, Type: "dvd", Codec: "xvid", Confidence: 0.7},
+		},
+		{
+			in:   "Blade.Runner.2049.2017.HEVC.4K",
+			want: Release{Title: "Blade Runner 2049", Year: 2017, Quality: "2160p", Codec: "x265", Confidence: 0.9},
+		},
+		{
+			in:   "Gladiator 2000 EXTENDED 720p",
+			want: Release{Title: "Gladiator", Year: 2000, Quality: "720p", Confidence: 0.85},
+		},
+		{
+			in:   "the_dark_knight_2008_hdrip",
+			want: Release{Title: "the dark knight", Year: 2008, Type: "hdrip", Confidence: 0.7},
+		},
+		// Partial names.
+		{
+			in:   "Amelie [720p]",
+			want: Release{Title: "Amelie", Quality: "720p", Confidence: 0.55},
+		},
+		{
+			in:   "Casablanca [1942] [480p]",
+			want: Release{Title: "Casablanca", Year: 1942, Quality: "480p", Confidence: 0.85},
+		},
+		{
+			in:   "Alien tt0078748.avi",
+			want: Release{Title: "Alien", IMDBCode: "tt0078748", Confidence: 0.35},
+		},
+		{
+			in:   "1917.mkv",
+			want: Release{Title: "1917", Confidence: 0.35},
+		},
+		{
+			in:   "movie.mkv",
+			want: Release{Title: "movie", Confidence: 0.35},
+		},
+		{
+			in:   "",
+			want: Release{},
+		},
+	}
+	for _, tc := range testData {
+		if diff := cmp.Diff(tc.want, Parse(tc.in)); diff != "" {
+			t.Errorf("Parse(%q) unexpected result, diff -want +got\n%s", tc.in, diff)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	m := &ytsgo.Movie{Title: "The Matrix", Year: 1999, IMDBCode: "tt0133093"}
+	testData := []struct {
+		torrent *ytsgo.Torrent
+		want    string
+	}{
+		{torrent: &ytsgo.Torrent{Quality: "1080p"}, want: "The Matrix (1999) [1080p] [YTS.LT]"},
+		{torrent: &ytsgo.Torrent{Quality: "720p", Type: "bluray"}, want: "The Matrix (1999) [720p] [BluRay] [YTS.LT]"},
+		{torrent: &ytsgo.Torrent{Quality: "2160p", Type: "web"}, want: "The Matrix (1999) [2160p] [WEBRip] [YTS.LT]"},
+		{torrent: &ytsgo.Torrent{Quality: "3D", Type: "other"}, want: "The Matrix (1999) [3D] [other] [YTS.LT]"},
+	}
+	for _, tc := range testData {
+		got := Format(m, tc.torrent)
+		if got != tc.want {
+			t.Errorf("Format(%+v) = %q want %q", tc.torrent, got, tc.want)
+		}
+		// Formatted names parse back to the same release.
+		want := FromTorrent(m, tc.torrent)
+		want.IMDBCode = ""
+		if tc.torrent.Type == "other" {
+			// Unknown types are not recognized.
+			want.Type = ""
+		}
+		parsed := Parse(got)
+		parsed.Confidence = want.Confidence
+		if diff := cmp.Diff(want, parsed); diff != "" {
+			t.Errorf("Parse(%q) does not round trip, diff -want +got\n%s", got, diff)
+		}
+	}
+	if got, want := (Release{Title: "Heat"}).String(), "Heat"; got != want {
+		t.Errorf("String() = %q want %q", got, want)
+	}
+}
+
+// TestMagnets parses display names of the magnet links in the magnets file at
+// the repository root.
+func TestMagnets(t *testing.T) {
+	f, err := os.Open("../magnets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var names []string
+	sc := bufio.NewScanner(f)
+	sc.Buffer(nil, 1<<20)
+	for sc.Scan() {
+		if sc.Text() == "" {
+			continue
+		}
+		u, err := url.Parse(sc.Text())
+		if err != nil {
+			t.Fatalf("Invalid magnet %q: %v", sc.Text(), err)
+		}
+		names = append(names, u.Query().Get("dn"))
+	}
+	if err := sc.Err(); err != nil {
+		t.Fatal(err)
+	}
+	want := []Release{
+		{Title: "A Lot Like Love", Year: 2005, Quality: "1080p", Group: "YTS.LT", Confidence: 0.95},
+		// Only the title is known.
+		{Title: "A Lot Like Love", Confidence: 0.35},
+	}
+	if len(names) != len(want) {
+		t.Fatalf("Unexpected number of magnets, got %d want %d", len(names), len(want))
+	}
+	for i, name := range names {
+		got := Parse(name)
+		if diff := cmp.Diff(want[i], got); diff != "" {
+			t.Errorf("Parse(%q) unexpected result, diff -want +got\n%s", name, diff)
+		}
+		if s := got.String(); s != name {
+			t.Errorf("String() of %q = %q, want the display name", name, s)
+		}
+		if got.Group != DefaultGroup {
+			continue
+		}
+		m := &ytsgo.Movie{Title: got.Title, Year: got.Year}
+		if s := Format(m, &ytsgo.Torrent{Quality: got.Quality, Type: got.Type}); s != name {
+			t.Errorf("Format() = %q want %q", s, name)
+		}
+	}
+}
+
+func TestIsVideo(t *testing.T) {
+	testData := map[string]bool{
+		"The.Matrix.1999.1080p.BluRay.x264-[YTS.MX].mkv": true,
+		"movie.MP4":               true,
+		"The.Matrix.1999.torrent": false,
+		"movie.nfo":               false,
+		"movie":                   false,
+	}
+	for name, want := range testData {
+		if got := IsVideo(name); got != want {
+			t.Errorf("IsVideo(%q) = %v want %v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package ytsgo
+
+// File response.go contains validation of raw HTTP responses returned by the API.
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+var (
+	// ErrResponseTooLarge is returned when the response body exceeds the
+	// limit set by MaxResponseSize.
+	ErrResponseTooLarge = errors.New("response too large")
+	// ErrTruncatedResponse is returned when the response body ends prematurely.
+	ErrTruncatedResponse = errors.New("truncated response")
+)
+
+// challengeMarkers are fragments of the Cloudflare challenge pages.
+var challengeMarkers = [][]byte{
+	[]byte("cf-browser-verification"),
+	[]byte("challenge-platform"),
+	[]byte("cf_chl_"),
+	[]byte("Just a moment..."),
+	[]byte("Attention Required! | Cloudflare"),
+}
+
+// ContentTypeError is returned when the server responds with a content type
+// other than JSON, eg. when a mirror serves an HTML page.
+type ContentTypeError struct {
+	// ContentType is a value of the Content-Type response header.
+	ContentType string
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+	// Challenge is true if the response looks like a Cloudflare challenge page.
+	Challenge bool
+}
+
+func (e *ContentTypeError) Error() string {
+	if e.Challenge {
+		return fmt.Sprintf("server returned Cloudflare challenge page (code %v, content type %q) instead of JSON", e.StatusCode, e.ContentType)
+	}
+	return fmt.Sprintf("server returned content type %q (code %v), want JSON", e.ContentType, e.StatusCode)
+}
+
+// StatusError is returned when the server responds with HTTP status code other
+// than 200 OK.
+type StatusError struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+	// Status is the status line, eg. "503 Service Unavailable".
+	Status string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("server returned code %v: %s", e.StatusCode, e.Status)
+}
+
+// checkContentType returns a *ContentTypeError if rsp does not contain JSON.
+func checkContentType(rsp *http.Response) *ContentTypeError {
+	ct := rsp.Header.Get("Content-Type")
+	mt, _, err := mime.ParseMediaType(ct)
+	if err == nil && (mt == "application/json" || mt == "text/json" || strings.HasSuffix(mt, "+json")) {
+		return nil
+	}
+	e := &ContentTypeError{
+		ContentType: ct,
+		StatusCode:  rsp.StatusCode,
+	}
+	if strings.EqualFold(rsp.Header.Get("Server"), "cloudflare") || rsp.Header.Get("Cf-Mitigated") == "challenge" {
+		e.Challenge = true
+		return e
+	}
+	// Only a small prefix of the body is needed to recognize a challenge page.
+	snippet, _ := ioutil.ReadAll(io.LimitReader(rsp.Body, 16<<10))
+	for _, m := range challengeMarkers {
+		if bytes.Contains(snippet, m) {
+			e.Challenge = true
+			break
+		}
+	}
+	return e
+}
+
+// readBody reads the whole body of rsp, but no more than max bytes if max is positive.
+func readBody(rsp *http.Response, max int64) ([]byte, error) {
+	if max > 0 && rsp.ContentLength > max {
+		return nil, fmt.Errorf("%w: content length %d exceeds limit of %d bytes", ErrResponseTooLarge, rsp.ContentLength, max)
+	}
+	r := io.Reader(rsp.Body)
+	if max > 0 {
+		r = io.LimitReader(rsp.Body, max+1)
+	}
+	body, err := ioutil.ReadAll(r)
+	if err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, fmt.Errorf("%w: read %d bytes: %v", ErrTruncatedResponse, len(body), err)
+		}
+		return nil, err
+	}
+	if max > 0 && int64(len(body)) > max {
+		return nil, fmt.Errorf("%w: body exceeds limit of %d bytes", ErrResponseTooLarge, max)
+	}
+	if rsp.ContentLength >= 0 && int64(len(body)) < rsp.ContentLength {
+		return nil, fmt.Errorf("%w: read %d of %d bytes", ErrTruncatedResponse, len(body), rsp.ContentLength)
+	}
+	return body, nil
+}
+
+// decodeJSON decodes body into data reporting unexpected end of input as ErrTruncatedResponse.
+func decodeJSON(body []byte, data interface{}) error {
+	err := json.NewDecoder(bytes.NewReader(body)).Decode(data)
+	if errors.Is(err, io.ErrUnexpectedEOF) || err == io.EOF {
+		return fmt.Errorf("%w: %v", ErrTruncatedResponse, err)
+	}
+	return err
+}
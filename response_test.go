@@ -0,0 +1,155 @@
+package ytsgo
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestResponseValidation(t *testing.T) {
+	matrix := loadTestData("matrix.json", t)
+	testData := []struct {
+		desc          string
+		opts          []ClientOption
+		handler       http.HandlerFunc
+		wantErr       bool
+		wantErrIs     error
+		wantChallenge bool
+		wantCTError   bool
+		wantStatus    int
+	}{
+		{
+			desc: "success",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json; charset=utf-8")
+				w.Write(matrix)
+			},
+		},
+		{
+			desc: "too large",
+			opts: []ClientOption{MaxResponseSize(100)},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write(matrix)
+			},
+			wantErr:   true,
+			wantErrIs: ErrResponseTooLarge,
+		},
+		{
+			desc: "too large without content length",
+			opts: []ClientOption{MaxResponseSize(100)},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.(http.Flusher).Flush()
+				w.Write(matrix)
+			},
+			wantErr:   true,
+			wantErrIs: ErrResponseTooLarge,
+		},
+		{
+			desc: "no limit",
+			opts: []ClientOption{MaxResponseSize(0)},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write(matrix)
+			},
+		},
+		{
+			desc: "truncated body",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("Content-Length", strconv.Itoa(len(matrix)))
+				w.Write(matrix[:len(matrix)/2])
+			},
+			wantErr:   true,
+			wantErrIs: ErrTruncatedResponse,
+		},
+		{
+			desc: "truncated JSON",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write(matrix[:len(matrix)/2])
+			},
+			wantErr:   true,
+			wantErrIs: ErrTruncatedResponse,
+		},
+		{
+			desc: "HTML page",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/html; charset=utf-8")
+				w.Write([]byte("<html><body>Maintenance</body></html>"))
+			},
+			wantErr:     true,
+			wantCTError: true,
+		},
+		{
+			desc: "Cloudflare challenge body",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/html; charset=UTF-8")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				w.Write([]byte("<html><head><title>Just a moment...</title></head><body></body></html>"))
+			},
+			wantErr:       true,
+			wantCTError:   true,
+			wantChallenge: true,
+		},
+		{
+			desc: "Cloudflare challenge header",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/html")
+				w.Header().Set("Server", "cloudflare")
+				w.WriteHeader(http.StatusForbidden)
+			},
+			wantErr:       true,
+			wantCTError:   true,
+			wantChallenge: true,
+		},
+		{
+			desc: "plain error page",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "oops", http.StatusBadGateway)
+			},
+			wantErr:    true,
+			wantStatus: http.StatusBadGateway,
+		},
+		{
+			desc: "not strict",
+			opts: []ClientOption{StrictContentType(false)},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/plain")
+				w.Write(matrix)
+			},
+		},
+	}
+	for _, tc := range testData {
+		t.Run(tc.desc, func(t *testing.T) {
+			ts := httptest.NewServer(tc.handler)
+			defer ts.Close()
+			c, err := New(append([]ClientOption{BaseURL(ts.URL), HTTPTimeout(time.Second * 5)}, tc.opts...)...)
+			if err != nil {
+				t.Fatalf("Failed to connect to test server: %v", err)
+			}
+			_, err = c.Movie(1)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Unexpected error, got %v want %v", err, tc.wantErr)
+			}
+			if tc.wantErrIs != nil && !errors.Is(err, tc.wantErrIs) {
+				t.Errorf("Unexpected error, got %v want %v", err, tc.wantErrIs)
+			}
+			var ctErr *ContentTypeError
+			if got := errors.As(err, &ctErr); got != tc.wantCTError {
+				t.Fatalf("Unexpected error type, got %T want *ContentTypeError: %v", err, tc.wantCTError)
+			}
+			if ctErr != nil && ctErr.Challenge != tc.wantChallenge {
+				t.Errorf("Unexpected challenge detection, got %v want %v", ctErr.Challenge, tc.wantChallenge)
+			}
+			var stErr *StatusError
+			if errors.As(err, &stErr) != (tc.wantStatus != 0) || stErr != nil && stErr.StatusCode != tc.wantStatus {
+				t.Errorf("Unexpected status error, got %v want code %d", err, tc.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package ytsgo
+
+// File revalidate.go contains conditional requests support based on ETag and
+// Last-Modified response headers.
+
+import (
+	"net/http"
+	"sync"
+)
+
+// RevalidationStats contains counters of conditional requests.
+type RevalidationStats struct {
+	// Conditional is a number of requests sent with validators.
+	Conditional uint64
+	// NotModified is a number of responses served from the stored copy after
+	// the server replied with 304 Not Modified.
+	NotModified uint64
+}
+
+// RevalidationStats returns counters of conditional requests. All counters
+// are zero unless Revalidate option is enabled.
+func (c *Client) RevalidationStats() RevalidationStats {
+	if c.validators == nil {
+		return RevalidationStats{}
+	}
+	c.validators.mu.Lock()
+	defer c.validators.mu.Unlock()
+	return c.validators.stats
+}
+
+// maxValidated is a default maximal number of responses kept for
+// revalidation.
+const maxValidated = 256
+
+// validated is a stored response together with its validators.
+type validated struct {
+	etag         string
+	lastModified string
+	body         []byte
+	// used orders entries by their last use.
+	used uint64
+}
+
+// validatorStore keeps the last response with validators for recently used
+// URLs.
+type validatorStore struct {
+	mu      sync.Mutex
+	entries map[string]*validated
+	stats   RevalidationStats
+	// max overrides maxValidated if positive.
+	max   int
+	clock uint64
+}
+
+// touch marks the entry as the most recently used. s.mu must be held.
+func (s *validatorStore) touch(v *validated) {
+	s.clock++
+	v.used = s.clock
+}
+
+// evict removes the least recently used entry. s.mu must be held.
+func (s *validatorStore) evict() {
+	var (
+		oldest string
+		used   uint64
+	)
+	for k, v := range s.entries {
+		if oldest == "" || v.used < used {
+			oldest, used = k, v.used
+		}
+	}
+	delete(s.entries, oldest)
+}
+
+// prepare adds conditional headers to req if there is a stored response for its URL.
+func (s *validatorStore) prepare(req *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.entries[req.URL.String()]
+	if !ok {
+		return
+	}
+	s.touch(v)
+	if v.etag != "" {
+		req.Header.Set("If-None-Match", v.etag)
+	}
+	if v.lastModified != "" {
+		req.Header.Set("If-Modified-Since", v.lastModified)
+	}
+	s.stats.Conditional++
+}
+
+// notModified returns the stored body for the URL of req. It should be called
+// when the server replied with 304 Not Modified.
+func (s *validatorStore) notModified(req *http.Request) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.entries[req.URL.String()]
+	if !ok {
+		return nil, false
+	}
+	s.touch(v)
+	s.stats.NotModified++
+	return v.body, true
+}
+
+// store saves body under the URL of req if the response carries validators.
+func (s *validatorStore) store(req *http.Request, rsp *http.Response, body []byte) {
+	etag := rsp.Header.Get("ETag")
+	lastModified := rsp.Header.Get("Last-Modified")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if etag == "" && lastModified == "" {
+		delete(s.entries, req.URL.String())
+		return
+	}
+	if s.entries == nil {
+		s.entries = make(map[string]*validated)
+	}
+	max := s.max
+	if max <= 0 {
+		max = maxValidated
+	}
+	key := req.URL.String()
+	if _, ok := s.entries[key]; !ok && len(s.entries) >= max {
+		s.evict()
+	}
+	v := &validated{
+		etag:         etag,
+		lastModified: lastModified,
+		body:         body,
+	}
+	s.touch(v)
+	s.entries[key] = v
+}
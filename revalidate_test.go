@@ -0,0 +1,176 @@
+package ytsgo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type conditionalServer struct {
+	data         []byte
+	etag         string
+	lastModified string
+	hits         int
+	notModified  int
+	req          *http.Request
+}
+
+func (f *conditionalServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.hits++
+	f.req = r
+	if f.etag != "" && r.Header.Get("If-None-Match") == f.etag ||
+		f.lastModified != "" && r.Header.Get("If-Modified-Since") == f.lastModified {
+		f.notModified++
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	if f.etag != "" {
+		w.Header().Set("ETag", f.etag)
+	}
+	if f.lastModified != "" {
+		w.Header().Set("Last-Modified", f.lastModified)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(f.data)
+}
+
+func TestRevalidate(t *testing.T) {
+	testData := []struct {
+		desc            string
+		etag            string
+		lastModified    string
+		call            func(c *Client) (string, error)
+		respFile        string
+		wantNotModified int
+	}{
+		{
+			desc: "movie with etag",
+			etag: `"abc"`,
+			call: func(c *Client) (string, error) {
+				m, err := c.Movie(1)
+				if err != nil {
+					return "", err
+				}
+				return m.Title, nil
+			},
+			respFile:        "matrix.json",
+			wantNotModified: 1,
+		},
+		{
+			desc:         "list movies with last modified",
+			lastModified: "Mon, 02 Jan 2006 15:04:05 GMT",
+			call: func(c *Client) (string, error) {
+				m, err := c.ListMovies()
+				if err != nil {
+					return "", err
+				}
+				return m.Movies[0].Title, nil
+			},
+			respFile:        "matrixes.json",
+			wantNotModified: 1,
+		},
+		{
+			desc:         "suggestions with both",
+			etag:         `W/"xyz"`,
+			lastModified: "Mon, 02 Jan 2006 15:04:05 GMT",
+			call: func(c *Client) (string, error) {
+				m, err := c.Suggestions(1)
+				if err != nil {
+					return "", err
+				}
+				return m[0].Title, nil
+			},
+			respFile:        "suggestions.json",
+			wantNotModified: 1,
+		},
+		{
+			desc: "no validators",
+			call: func(c *Client) (string, error) {
+				m, err := c.Movie(1)
+				if err != nil {
+					return "", err
+				}
+				return m.Title, nil
+			},
+			respFile: "matrix.json",
+		},
+	}
+	for _, tc := range testData {
+		t.Run(tc.desc, func(t *testing.T) {
+			f := &conditionalServer{
+				data:         loadTestData(tc.respFile, t),
+				etag:         tc.etag,
+				lastModified: tc.lastModified,
+			}
+			ts := httptest.NewServer(f)
+			defer ts.Close()
+			c, err := New(BaseURL(ts.URL), HTTPTimeout(time.Second*5), Revalidate(true))
+			if err != nil {
+				t.Fatalf("Failed to connect to test server: %v", err)
+			}
+			first, err := tc.call(c)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			second, err := tc.call(c)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if first != second {
+				t.Errorf("Unexpected result of revalidated call, got %q want %q", second, first)
+			}
+			if got, want := f.hits, 2; got != want {
+				t.Errorf("Unexpected number of server hits, got %d want %d", got, want)
+			}
+			if got, want := f.notModified, tc.wantNotModified; got != want {
+				t.Errorf("Unexpected number of 304 responses, got %d want %d", got, want)
+			}
+			if got, want := c.RevalidationStats().NotModified, uint64(tc.wantNotModified); got != want {
+				t.Errorf("Unexpected NotModified stat, got %d want %d", got, want)
+			}
+			if got, want := f.req.Header.Get("If-None-Match"), tc.etag; got != want {
+				t.Errorf("Unexpected If-None-Match header, got %q want %q", got, want)
+			}
+			if got, want := f.req.Header.Get("If-Modified-Since"), tc.lastModified; got != want {
+				t.Errorf("Unexpected If-Modified-Since header, got %q want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestRevalidateNotModifiedWithoutStoredResponse(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotModified)
+	}))
+	defer ts.Close()
+	c, err := New(BaseURL(ts.URL), HTTPTimeout(time.Second*5), Revalidate(true))
+	if err != nil {
+		t.Fatalf("Failed to connect to test server: %v", err)
+	}
+	if _, err := c.Movie(1); err == nil {
+		t.Error("Expected an error for unexpected 304 response")
+	}
+}
+
+func TestRevalidateEviction(t *testing.T) {
+	s := &validatorStore{max: 2}
+	rsp := &http.Response{Header: http.Header{"Etag": {`"abc"`}}}
+	req := func(id string) *http.Request {
+		r, _ := http.NewRequest("GET", "https://yts.lt/api/v2/movie_details.json?movie_id="+id, nil)
+		return r
+	}
+	s.store(req("1"), rsp, []byte("1"))
+	s.store(req("2"), rsp, []byte("2"))
+	// Using 1 makes 2 the least recently used entry.
+	s.prepare(req("1"))
+	s.store(req("3"), rsp, []byte("3"))
+	if len(s.entries) != 2 {
+		t.Errorf("Unexpected number of entries, got %d want 2", len(s.entries))
+	}
+	for id, want := range map[string]bool{"1": true, "2": false, "3": true} {
+		if _, ok := s.notModified(req(id)); ok != want {
+			t.Errorf("Entry of movie %s stored: %v want %v", id, ok, want)
+		}
+	}
+}
@@ -0,0 +1,283 @@
+package ytsgo
+
+// File size.go contains parsing and formatting of human readable sizes.
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ByteSize is a size in bytes. It implements flag.Value so it can be used as a command line flag.
+type ByteSize uint64
+
+// Byte size multipliers.
+const (
+	Byte     ByteSize = 1
+	Kilobyte ByteSize = 1000
+	Megabyte          = Kilobyte * 1000
+	Gigabyte          = Megabyte * 1000
+	Terabyte          = Gigabyte * 1000
+	Kibibyte ByteSize = 1024
+	Mebibyte          = Kibibyte * 1024
+	Gibibyte          = Mebibyte * 1024
+	Tebibyte          = Gibibyte * 1024
+)
+
+// SizeUnits selects how unit prefixes are interpreted and formatted.
+type SizeUnits int
+
+const (
+	// BinaryUnits are powers of 1024 labeled KB, MB, GB and TB. This is what YTS uses in Torrent.Size.
+	BinaryUnits SizeUnits = iota
+	// DecimalUnits are powers of 1000 labeled KB, MB, GB and TB.
+	DecimalUnits
+	// IECUnits are powers of 1024 labeled KiB, MiB, GiB and TiB.
+	IECUnits
+)
+
+var sizeUnitNames = map[SizeUnits]string{
+	BinaryUnits:  "binary",
+	DecimalUnits: "decimal",
+	IECUnits:     "iec",
+}
+
+// String returns the name of the units.
+func (u SizeUnits) String() string {
+	if n, ok := sizeUnitNames[u]; ok {
+		return n
+	}
+	return fmt.Sprintf("SizeUnits(%d)", int(u))
+}
+
+// Set sets units by name (binary, decimal or iec).
+func (u *SizeUnits) Set(s string) error {
+	for k, n := range sizeUnitNames {
+		if strings.EqualFold(s, n) {
+			*u = k
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown size units %q, want binary, decimal or iec", s)
+}
+
+// multipliers returns unit multipliers for KB, MB, GB and TB.
+func (u SizeUnits) multipliers() []ByteSize {
+	if u == DecimalUnits {
+		return []ByteSize{Kilobyte, Megabyte, Gigabyte, Terabyte}
+	}
+	return []ByteSize{Kibibyte, Mebibyte, Gibibyte, Tebibyte}
+}
+
+var sizeSuffixes = []string{"K", "M", "G", "T"}
+
+// ParseByteSize parses sizes like "946.49 MB", "2GB" or "1.5 GiB". Suffixes
+// KB, MB, GB and TB are interpreted according to units, while KiB, MiB, GiB
+// and TiB are always powers of 1024. A number without a suffix is in bytes.
+func ParseByteSize(s string, units SizeUnits) (ByteSize, error) {
+	str := strings.TrimSpace(s)
+	i := strings.IndexFunc(str, func(r rune) bool {
+		return !unicode.IsDigit(r) && r != '.'
+	})
+	num, unit := str, ""
+	if i >= 0 {
+		num, unit = str[:i], strings.TrimSpace(str[i:])
+	}
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q: %v", s, err)
+	}
+	mult, err := unitMultiplier(unit, units)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q: %v", s, err)
+	}
+	b := math.Round(f * float64(mult))
+	if b >= math.MaxUint64 {
+		return 0, fmt.Errorf("invalid size %q: value out of range", s)
+	}
+	return ByteSize(b), nil
+}
+
+func unitMultiplier(unit string, units SizeUnits) (ByteSize, error) {
+	u := strings.ToUpper(unit)
+	switch u {
+	case "", "B":
+		return Byte, nil
+	}
+	iec := IECUnits.multipliers()
+	for i, p := range sizeSuffixes {
+		switch u {
+		case p, p + "B":
+			return units.multipliers()[i], nil
+		case p + "IB":
+			return iec[i], nil
+		}
+	}
+	return 0, fmt.Errorf("unknown unit %q", unit)
+}
+
+// Format formats the size using the largest unit which keeps the value at least 1.
+func (b ByteSize) Format(units SizeUnits) string {
+	mults := units.multipliers()
+	for i := len(mults) - 1; i >= 0; i-- {
+		if b < mults[i] {
+			continue
+		}
+		label := sizeSuffixes[i] + "B"
+		if units == IECUnits {
+			label = sizeSuffixes[i] + "iB"
+		}
+		return fmt.Sprintf("%.2f %s", float64(b)/float64(mults[i]), label)
+	}
+	return fmt.Sprintf("%d B", uint64(b))
+}
+
+// String formats the size in BinaryUnits, the same way YTS does.
+func (b ByteSize) String() string {
+	return b.Format(BinaryUnits)
+}
+
+// Set parses the size in BinaryUnits.
+func (b *ByteSize) Set(s string) error {
+	v, err := ParseByteSize(s, BinaryUnits)
+	if err != nil {
+		return err
+	}
+	*b = v
+	return nil
+}
+
+// Bytes returns the size of the torrent. If SizeBytes is not set the size is
+// parsed from Size. Zero is returned if the size is not known.
+func (t *Torrent) Bytes() ByteSize {
+	if t.SizeBytes > 0 {
+		return ByteSize(t.SizeBytes)
+	}
+	b, err := ParseByteSize(t.Size, BinaryUnits)
+	if err != nil {
+		return 0
+	}
+	return b
+}
+
+// SizeFilter selects torrents by size. Zero Min or Max means no bound.
+// It implements flag.Value so it can be used as a command line flag.
+type SizeFilter struct {
+	Min ByteSize
+	Max ByteSize
+}
+
+// ParseSizeFilter parses filters like "max 2 GB", "min 700 MB", "<= 2GB",
+// "> 1GB" or "700MB-2GB". Multiple conditions can be separated with commas,
+// eg. "min 700 MB, max 2 GB". Sizes are parsed in BinaryUnits.
+func ParseSizeFilter(s string) (SizeFilter, error) {
+	var f SizeFilter
+	for _, cond := range strings.Split(s, ",") {
+		if err := f.parseCondition(strings.TrimSpace(cond)); err != nil {
+			return SizeFilter{}, fmt.Errorf("invalid size filter %q: %v", s, err)
+		}
+	}
+	if f.Max > 0 && f.Min > f.Max {
+		return SizeFilter{}, fmt.Errorf("invalid size filter %q: min %v is larger than max %v", s, f.Min, f.Max)
+	}
+	return f, nil
+}
+
+func (f *SizeFilter) parseCondition(cond string) error {
+	lower := strings.ToLower(cond)
+	ops := []struct {
+		prefix string
+		max    bool
+		strict bool
+	}{
+		{prefix: "max"},
+		{prefix: "<=", max: true},
+		{prefix: "<", max: true, strict: true},
+		{prefix: "min"},
+		{prefix: ">="},
+		{prefix: ">", strict: true},
+	}
+	for _, op := range ops {
+		if !strings.HasPrefix(lower, op.prefix) {
+			continue
+		}
+		b, err := ParseByteSize(cond[len(op.prefix):], BinaryUnits)
+		if err != nil {
+			return err
+		}
+		switch {
+		case op.prefix == "max" || op.max:
+			if op.strict && b > 0 {
+				b--
+			}
+			f.Max = b
+		default:
+			if op.strict {
+				b++
+			}
+			f.Min = b
+		}
+		return nil
+	}
+	if i := strings.Index(cond, "-"); i > 0 {
+		min, err := ParseByteSize(cond[:i], BinaryUnits)
+		if err != nil {
+			return err
+		}
+		max, err := ParseByteSize(cond[i+1:], BinaryUnits)
+		if err != nil {
+			return err
+		}
+		f.Min, f.Max = min, max
+		return nil
+	}
+	return fmt.Errorf("unknown condition %q", cond)
+}
+
+// Match reports whether size of the torrent is within the filter bounds.
+// Torrents of unknown size only match a filter without bounds.
+func (f SizeFilter) Match(t *Torrent) bool {
+	if f.Min == 0 && f.Max == 0 {
+		return true
+	}
+	b := t.Bytes()
+	if b == 0 {
+		return false
+	}
+	return b >= f.Min && (f.Max == 0 || b <= f.Max)
+}
+
+// Filter returns torrents matching the filter.
+func (f SizeFilter) Filter(torrents []*Torrent) []*Torrent {
+	var ret []*Torrent
+	for _, t := range torrents {
+		if f.Match(t) {
+			ret = append(ret, t)
+		}
+	}
+	return ret
+}
+
+// String returns the filter in the format accepted by ParseSizeFilter.
+func (f SizeFilter) String() string {
+	var conds []string
+	if f.Min > 0 {
+		conds = append(conds, "min "+f.Min.String())
+	}
+	if f.Max > 0 {
+		conds = append(conds, "max "+f.Max.String())
+	}
+	return strings.Join(conds, ", ")
+}
+
+// Set parses the filter with ParseSizeFilter.
+func (f *SizeFilter) Set(s string) error {
+	v, err := ParseSizeFilter(s)
+	if err != nil {
+		return err
+	}
+	*f = v
+	return nil
+}
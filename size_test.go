@@ -0,0 +1,186 @@
+package ytsgo
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseByteSize(t *testing.T) {
+	testData := []struct {
+		str     string
+		units   SizeUnits
+		want    ByteSize
+		wantErr bool
+	}{
+		{str: "946.49 MB", units: BinaryUnits, want: 992466698},
+		{str: "1.65 GB", units: BinaryUnits, want: 1771674010},
+		{str: "2GB", units: BinaryUnits, want: 2 * Gibibyte},
+		{str: "2 GB", units: DecimalUnits, want: 2 * Gigabyte},
+		{str: "2 gb", units: DecimalUnits, want: 2 * Gigabyte},
+		{str: "700 kB", units: DecimalUnits, want: 700 * Kilobyte},
+		{str: "1.5 GiB", units: DecimalUnits, want: 1536 * Mebibyte},
+		{str: "1 TB", units: IECUnits, want: Tebibyte},
+		{str: "512", units: BinaryUnits, want: 512},
+		{str: "512 B", units: BinaryUnits, want: 512},
+		{str: "  3M ", units: BinaryUnits, want: 3 * Mebibyte},
+		{str: "", wantErr: true},
+		{str: "MB", wantErr: true},
+		{str: "12 XB", wantErr: true},
+		{str: "1.2.3 MB", wantErr: true},
+	}
+	for _, tc := range testData {
+		t.Run(tc.str, func(t *testing.T) {
+			got, err := ParseByteSize(tc.str, tc.units)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Unexpected error, got %v want %v", err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("Unexpected size, got %d want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestByteSizeFormat(t *testing.T) {
+	testData := []struct {
+		size  ByteSize
+		units SizeUnits
+		want  string
+	}{
+		{size: 992466698, units: BinaryUnits, want: "946.49 MB"},
+		{size: 992466698, units: DecimalUnits, want: "992.47 MB"},
+		{size: 992466698, units: IECUnits, want: "946.49 MiB"},
+		{size: 2 * Gibibyte, units: BinaryUnits, want: "2.00 GB"},
+		{size: 1023, units: BinaryUnits, want: "1023 B"},
+		{size: 0, units: DecimalUnits, want: "0 B"},
+	}
+	for _, tc := range testData {
+		if got := tc.size.Format(tc.units); got != tc.want {
+			t.Errorf("ByteSize(%d).Format(%v) = %q want %q", tc.size, tc.units, got, tc.want)
+		}
+	}
+}
+
+func TestTorrentBytes(t *testing.T) {
+	testData := []struct {
+		desc    string
+		torrent *Torrent
+		want    ByteSize
+	}{
+		{
+			desc:    "size bytes",
+			torrent: &Torrent{Size: "1 GB", SizeBytes: 992466698},
+			want:    992466698,
+		},
+		{
+			desc:    "parsed size",
+			torrent: &Torrent{Size: "946.49 MB"},
+			want:    992466698,
+		},
+		{
+			desc:    "unknown size",
+			torrent: &Torrent{Size: "unknown"},
+			want:    0,
+		},
+	}
+	for _, tc := range testData {
+		t.Run(tc.desc, func(t *testing.T) {
+			if got := tc.torrent.Bytes(); got != tc.want {
+				t.Errorf("Unexpected size, got %d want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSizeFilter(t *testing.T) {
+	torrents := []*Torrent{
+		{Hash: "small", SizeBytes: uint(700 * Mebibyte)},
+		{Hash: "medium", Size: "1.65 GB"},
+		{Hash: "exact", SizeBytes: uint(2 * Gibibyte)},
+		{Hash: "large", SizeBytes: uint(5 * Gibibyte)},
+		{Hash: "unknown"},
+	}
+	testData := []struct {
+		filter  string
+		want    SizeFilter
+		hashes  []string
+		wantErr bool
+	}{
+		{
+			filter: "max 2 GB",
+			want:   SizeFilter{Max: 2 * Gibibyte},
+			hashes: []string{"small", "medium", "exact"},
+		},
+		{
+			filter: "< 2GB",
+			want:   SizeFilter{Max: 2*Gibibyte - 1},
+			hashes: []string{"small", "medium"},
+		},
+		{
+			filter: "min 1GB, max 2GB",
+			want:   SizeFilter{Min: Gibibyte, Max: 2 * Gibibyte},
+			hashes: []string{"medium", "exact"},
+		},
+		{
+			filter: ">= 2 GB",
+			want:   SizeFilter{Min: 2 * Gibibyte},
+			hashes: []string{"exact", "large"},
+		},
+		{
+			filter: "> 2 GB",
+			want:   SizeFilter{Min: 2*Gibibyte + 1},
+			hashes: []string{"large"},
+		},
+		{
+			filter: "700MB-1.7GB",
+			want:   SizeFilter{Min: 700 * Mebibyte, Max: ByteSize(1825361101)},
+			hashes: []string{"small", "medium"},
+		},
+		{filter: "max", wantErr: true},
+		{filter: "about 2 GB", wantErr: true},
+		{filter: "min 3 GB, max 2 GB", wantErr: true},
+	}
+	for _, tc := range testData {
+		t.Run(tc.filter, func(t *testing.T) {
+			var f SizeFilter
+			err := f.Set(tc.filter)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Unexpected error, got %v want %v", err, tc.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if f != tc.want {
+				t.Errorf("Unexpected filter, got %+v want %+v", f, tc.want)
+			}
+			var got []string
+			for _, t := range f.Filter(torrents) {
+				got = append(got, t.Hash)
+			}
+			if diff := cmp.Diff(tc.hashes, got); diff != "" {
+				t.Errorf("Unexpected torrents, diff -want +got\n%s", diff)
+			}
+			if _, err := ParseSizeFilter(f.String()); err != nil {
+				t.Errorf("Failed to parse filter string %q: %v", f.String(), err)
+			}
+		})
+	}
+}
+
+func TestTorrentsBySizeParsed(t *testing.T) {
+	torrents := []*Torrent{
+		{Hash: "b", Size: "1.65 GB"},
+		{Hash: "a", SizeBytes: 992466698},
+		{Hash: "c", Size: "2.5 GB"},
+	}
+	sort.Sort(TorrentsBySize(torrents))
+	var got []string
+	for _, t := range torrents {
+		got = append(got, t.Hash)
+	}
+	if diff := cmp.Diff([]string{"a", "b", "c"}, got); diff != "" {
+		t.Errorf("Unexpected order, diff -want +got\n%s", diff)
+	}
+}
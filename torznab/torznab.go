@@ -0,0 +1,416 @@
+// Package torznab implements a Torznab indexer on top of the YTS.LT API so
+// that it can be added to Radarr and similar tools as a custom indexer.
+//
+// Supported functions are caps, search and movie (movie-search). Requests are
+// served on any path, eg. http://localhost:9117/api?t=movie&imdbid=tt0133093.
+package torznab
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/qopher/ytsgo"
+	"github.com/qopher/ytsgo/release"
+)
+
+// Torznab categories used by YTS torrents.
+const (
+	CategoryMovies   = 2000
+	CategoryMoviesSD = 2030
+	CategoryMoviesHD = 2040
+	CategoryUHD      = 2045
+	Category3D       = 2050
+)
+
+// Torznab error codes.
+const (
+	ErrIncorrectCredentials = 100
+	ErrMissingParameter     = 200
+	ErrIncorrectParameter   = 201
+	ErrNoSuchFunction       = 202
+	ErrUnknown              = 900
+)
+
+const (
+	// DefaultLimit is the number of items returned if the request has no
+	// limit.
+	DefaultLimit = 50
+	// DefaultMaxPages is a default limit of movie pages fetched by a single
+	// search.
+	DefaultMaxPages = 10
+	// pageSize is the number of movies fetched with a single ListMovies call.
+	pageSize = 50
+)
+
+const nsTorznab = "http://torznab.com/schemas/2015/feed"
+
+// Lister lists movies. It is implemented by *ytsgo.Client.
+type Lister interface {
+	ListMovies(opts ...ytsgo.ListMoviesOption) (*ytsgo.Movies, error)
+}
+
+// Server is an http.Handler serving Torznab API requests.
+type Server struct {
+	Client Lister
+	// Title is the indexer title, "YTS" if empty.
+	Title string
+	// APIKey, if set, has to be passed in the apikey parameter of every
+	// request except caps.
+	APIKey string
+	// MaxPages overrides DefaultMaxPages if positive. Items past the pages
+	// are not returned.
+	MaxPages uint
+}
+
+// Error is a Torznab error response.
+type Error struct {
+	XMLName     xml.Name `xml:"error"`
+	Code        int      `xml:"code,attr"`
+	Description string   `xml:"description,attr"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("torznab error %d: %s", e.Code, e.Description)
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	var (
+		doc interface{}
+		err error
+	)
+	switch t := q.Get("t"); t {
+	case "caps":
+		doc = s.caps()
+	case "search", "tvsearch", "movie":
+		if s.APIKey != "" && q.Get("apikey") != s.APIKey {
+			err = &Error{Code: ErrIncorrectCredentials, Description: "Incorrect user credentials"}
+			break
+		}
+		if t == "tvsearch" {
+			// YTS has no TV shows.
+			doc = s.channel(nil)
+			break
+		}
+		doc, err = s.search(q.Get("q"), q.Get("imdbid"), q.Get("cat"), q.Get("offset"), q.Get("limit"))
+	case "":
+		err = &Error{Code: ErrMissingParameter, Description: "Missing parameter (t)"}
+	default:
+		err = &Error{Code: ErrNoSuchFunction, Description: "No such function (" + t + ")"}
+	}
+	if err != nil {
+		e, ok := err.(*Error)
+		if !ok {
+			e = &Error{Code: ErrUnknown, Description: err.Error()}
+		}
+		doc = e
+	}
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.Write([]byte(xml.Header))
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	enc.Encode(doc)
+}
+
+func (s *Server) title() string {
+	if s.Title != "" {
+		return s.Title
+	}
+	return "YTS"
+}
+
+type capsDoc struct {
+	XMLName    xml.Name `xml:"caps"`
+	Server     capsServer
+	Limits     capsLimits     `xml:"limits"`
+	Searching  capsSearching  `xml:"searching"`
+	Categories capsCategories `xml:"categories"`
+}
+
+type capsServer struct {
+	XMLName xml.Name `xml:"server"`
+	Title   string   `xml:"title,attr"`
+}
+
+type capsLimits struct {
+	Max     int `xml:"max,attr"`
+	Default int `xml:"default,attr"`
+}
+
+type capsSearch struct {
+	Available       string `xml:"available,attr"`
+	SupportedParams string `xml:"supportedParams,attr"`
+}
+
+type capsSearching struct {
+	Search      capsSearch `xml:"search"`
+	TVSearch    capsSearch `xml:"tv-search"`
+	MovieSearch capsSearch `xml:"movie-search"`
+}
+
+type capsCategories struct {
+	Categories []capsCategory `xml:"category"`
+}
+
+type capsCategory struct {
+	ID      int            `xml:"id,attr"`
+	Name    string         `xml:"name,attr"`
+	Subcats []capsCategory `xml:"subcat"`
+}
+
+func (s *Server) caps() *capsDoc {
+	return &capsDoc{
+		Server: capsServer{Title: s.title()},
+		Limits: capsLimits{Max: DefaultLimit, Default: DefaultLimit},
+		Searching: capsSearching{
+			Search:      capsSearch{Available: "yes", SupportedParams: "q"},
+			TVSearch:    capsSearch{Available: "no", SupportedParams: "q"},
+			MovieSearch: capsSearch{Available: "yes", SupportedParams: "q,imdbid"},
+		},
+		Categories: capsCategories{Categories: []capsCategory{{
+			ID:   CategoryMovies,
+			Name: "Movies",
+			Subcats: []capsCategory{
+				{ID: CategoryMoviesSD, Name: "Movies/SD"},
+				{ID: CategoryMoviesHD, Name: "Movies/HD"},
+				{ID: CategoryUHD, Name: "Movies/UHD"},
+				{ID: Category3D, Name: "Movies/3D"},
+			},
+		}}},
+	}
+}
+
+// Category returns Torznab category of the torrent quality.
+func Category(quality string) int {
+	switch strings.ToLower(quality) {
+	case "480p":
+		return CategoryMoviesSD
+	case "720p", "1080p":
+		return CategoryMoviesHD
+	case "2160p", "4k":
+		return CategoryUHD
+	case "3d":
+		return Category3D
+	}
+	return CategoryMovies
+}
+
+// search translates the Torznab query to ListMovies calls. Without q and
+// imdbid the latest movies are returned, which is used for RSS sync.
+//
+// Torznab offset and limit count items, ie. torrents, so pages of movies are
+// fetched and flattened until the requested items are found. The total is
+// exact once all the movies are fetched and estimated from the number of
+// torrents per movie otherwise.
+func (s *Server) search(q, imdbID, cats, offset, limit string) (*rss, error) {
+	lim := uint(DefaultLimit)
+	if limit != "" {
+		n, err := strconv.ParseUint(limit, 10, 32)
+		if err != nil {
+			return nil, &Error{Code: ErrIncorrectParameter, Description: "Incorrect parameter (limit)"}
+		}
+		if n > 0 && n < DefaultLimit {
+			lim = uint(n)
+		}
+	}
+	var off uint
+	if offset != "" {
+		n, err := strconv.ParseUint(offset, 10, 32)
+		if err != nil {
+			return nil, &Error{Code: ErrIncorrectParameter, Description: "Incorrect parameter (offset)"}
+		}
+		off = uint(n)
+	}
+	wanted, err := parseCategories(cats)
+	if err != nil {
+		return nil, err
+	}
+	var opts []ytsgo.ListMoviesOption
+	switch {
+	case imdbID != "":
+		// query_term accepts IMDb codes.
+		imdbID = strings.TrimPrefix(strings.ToLower(imdbID), "tt")
+		if _, err := strconv.ParseUint(imdbID, 10, 64); err != nil {
+			return nil, &Error{Code: ErrIncorrectParameter, Description: "Incorrect parameter (imdbid)"}
+		}
+		opts = append(opts, ytsgo.LMSearch("tt"+imdbID))
+	case q != "":
+		opts = append(opts, ytsgo.LMSearch(q))
+	default:
+		opts = append(opts, ytsgo.LMSortBy("date_added"), ytsgo.LMOrderBy("desc"))
+	}
+	maxPages := s.MaxPages
+	if maxPages == 0 {
+		maxPages = DefaultMaxPages
+	}
+	doc := s.channel(nil)
+	var items []item
+	var seen, total uint
+	for page := uint(1); page <= maxPages; page++ {
+		mvs, err := s.Client.ListMovies(append(opts, ytsgo.LMLimit(pageSize), ytsgo.LMPage(page))...)
+		if err != nil {
+			return nil, err
+		}
+		if mvs == nil || len(mvs.Movies) == 0 {
+			total = uint(len(items))
+			break
+		}
+		for _, it := range s.channel(mvs.Movies).Channel.Items {
+			if len(wanted) == 0 || wanted[it.category] || wanted[CategoryMovies] {
+				items = append(items, it)
+			}
+		}
+		seen += uint(len(mvs.Movies))
+		if seen >= mvs.MovieCount {
+			total = uint(len(items))
+			break
+		}
+		// Estimate the total from torrents per movie so far, there is at
+		// least one more item.
+		total = uint(len(items)) * mvs.MovieCount / seen
+		if total <= uint(len(items)) {
+			total = uint(len(items)) + 1
+		}
+		if uint(len(items)) >= off+lim {
+			break
+		}
+	}
+	if off < uint(len(items)) {
+		items = items[off:]
+		if uint(len(items)) > lim {
+			items = items[:lim]
+		}
+		doc.Channel.Items = items
+	}
+	doc.Channel.Response = &response{Offset: off, Total: total}
+	return doc, nil
+}
+
+func parseCategories(s string) (map[int]bool, error) {
+	if s == "" {
+		return nil, nil
+	}
+	ret := make(map[int]bool)
+	for _, c := range strings.Split(s, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(c))
+		if err != nil {
+			return nil, &Error{Code: ErrIncorrectParameter, Description: "Incorrect parameter (cat)"}
+		}
+		ret[n] = true
+	}
+	return ret, nil
+}
+
+type rss struct {
+	XMLName   xml.Name `xml:"rss"`
+	Version   string   `xml:"version,attr"`
+	NSTorznab string   `xml:"xmlns:torznab,attr"`
+	Channel   channel  `xml:"channel"`
+}
+
+type channel struct {
+	Title    string    `xml:"title"`
+	Response *response `xml:"torznab:response,omitempty"`
+	Items    []item    `xml:"item"`
+}
+
+type response struct {
+	Offset uint `xml:"offset,attr"`
+	Total  uint `xml:"total,attr"`
+}
+
+type item struct {
+	Title     string    `xml:"title"`
+	GUID      string    `xml:"guid"`
+	Link      string    `xml:"link"`
+	Comments  string    `xml:"comments,omitempty"`
+	PubDate   string    `xml:"pubDate,omitempty"`
+	Size      int64     `xml:"size"`
+	Category  int       `xml:"category"`
+	Enclosure enclosure `xml:"enclosure"`
+	Attrs     []attr    `xml:"torznab:attr"`
+
+	category int
+}
+
+type enclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int64  `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+type attr struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+func (s *Server) channel(movies []*ytsgo.Movie) *rss {
+	doc := &rss{
+		Version:   "2.0",
+		NSTorznab: nsTorznab,
+		Channel:   channel{Title: s.title()},
+	}
+	for _, m := range movies {
+		if m == nil {
+			continue
+		}
+		for _, t := range m.Torrents {
+			if t != nil {
+				doc.Channel.Items = append(doc.Channel.Items, newItem(m, t))
+			}
+		}
+	}
+	return doc
+}
+
+func newItem(m *ytsgo.Movie, t *ytsgo.Torrent) item {
+	size := int64(t.Bytes())
+	magnet := t.Magnet()
+	link := magnet
+	if t.URL != nil && t.URL.String() != "" {
+		link = t.URL.String()
+	}
+	cat := Category(t.Quality)
+	it := item{
+		Title:     release.Format(m, t),
+		GUID:      strings.ToLower(t.Hash),
+		Link:      link,
+		Size:      size,
+		Category:  cat,
+		Enclosure: enclosure{URL: link, Length: size, Type: "application/x-bittorrent"},
+		category:  cat,
+	}
+	if m.URL != nil {
+		it.Comments = m.URL.String()
+	}
+	date := t.DateUploaded
+	if t.DateUploadedUnix <= 0 {
+		date = m.DateUploaded
+	}
+	if date.Unix() > 0 {
+		it.PubDate = date.UTC().Format(time.RFC1123Z)
+	}
+	it.Attrs = []attr{
+		{Name: "category", Value: strconv.Itoa(CategoryMovies)},
+		{Name: "category", Value: strconv.Itoa(cat)},
+		{Name: "size", Value: strconv.FormatInt(size, 10)},
+		{Name: "seeders", Value: strconv.FormatUint(uint64(t.Seeds), 10)},
+		// Torznab peers include seeders, YTS peers are leechers only.
+		{Name: "peers", Value: strconv.FormatUint(uint64(t.Seeds+t.Peers), 10)},
+		{Name: "infohash", Value: strings.ToLower(t.Hash)},
+		{Name: "magneturl", Value: magnet},
+		{Name: "downloadvolumefactor", Value: "1"},
+		{Name: "uploadvolumefactor", Value: "1"},
+	}
+	if imdb := strings.TrimPrefix(m.IMDBCode, "tt"); imdb != "" {
+		it.Attrs = append(it.Attrs, attr{Name: "imdb", Value: imdb})
+	}
+	if len(m.Genres) > 0 {
+		it.Attrs = append(it.Attrs, attr{Name: "genre", Value: strings.Join(m.Genres, ", ")})
+	}
+	return it
+}
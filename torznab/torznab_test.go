@@ -0,0 +1,373 @@
+package torznab
+
+import (
+	"encoding/xml"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qopher/ytsgo"
+)
+
+// fakeLister pages movies, testMovie if nil.
+type fakeLister struct {
+	movies []*ytsgo.Movie
+	query  url.Values
+	calls  int
+	err    error
+}
+
+func (f *fakeLister) ListMovies(opts ...ytsgo.ListMoviesOption) (*ytsgo.Movies, error) {
+	f.query = url.Values{}
+	for _, o := range opts {
+		o(f.query)
+	}
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	movies := f.movies
+	if movies == nil {
+		movies = []*ytsgo.Movie{testMovie()}
+	}
+	limit, _ := strconv.Atoi(f.query.Get("limit"))
+	page, _ := strconv.Atoi(f.query.Get("page"))
+	ret := &ytsgo.Movies{MovieCount: uint(len(movies))}
+	if start := (page - 1) * limit; start < len(movies) {
+		end := start + limit
+		if end > len(movies) {
+			end = len(movies)
+		}
+		ret.Movies = movies[start:end]
+	}
+	return ret, nil
+}
+
+func testMovie() *ytsgo.Movie {
+	u, _ := url.Parse("https://yts.lt/torrent/download/ABCD")
+	return &ytsgo.Movie{
+		Title:    "The Matrix",
+		Year:     1999,
+		IMDBCode: "tt0133093",
+		Genres:   []string{"Action", "Sci-Fi"},
+		Torrents: []*ytsgo.Torrent{
+			{
+				URL:              u,
+				Hash:             "ABCD",
+				Quality:          "1080p",
+				Type:             "bluray",
+				SizeBytes:        1000,
+				Seeds:            10,
+				Peers:            5,
+				DateUploaded:     time.Unix(1500000000, 0),
+				DateUploadedUnix: 1500000000,
+			},
+			{Hash: "EFGH", Quality: "2160p", Type: "web", Size: "1.00 KB", Seeds: 1},
+		},
+	}
+}
+
+type gotItem struct {
+	Title     string `xml:"title"`
+	GUID      string `xml:"guid"`
+	Link      string `xml:"link"`
+	PubDate   string `xml:"pubDate"`
+	Size      int64  `xml:"size"`
+	Category  int    `xml:"category"`
+	Enclosure struct {
+		URL string `xml:"url,attr"`
+	} `xml:"enclosure"`
+	Attrs []attr `xml:"http://torznab.com/schemas/2015/feed attr"`
+}
+
+type gotFeed struct {
+	XMLName xml.Name `xml:"rss"`
+	Channel struct {
+		Response struct {
+			Offset uint `xml:"offset,attr"`
+			Total  uint `xml:"total,attr"`
+		} `xml:"http://torznab.com/schemas/2015/feed response"`
+		Items []gotItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+func get(t *testing.T, ts *httptest.Server, query string, v interface{}) {
+	t.Helper()
+	rsp, err := http.Get(ts.URL + "/api?" + query)
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	defer rsp.Body.Close()
+	body, err := ioutil.ReadAll(rsp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read body: %v", err)
+	}
+	if err := xml.Unmarshal(body, v); err != nil {
+		t.Fatalf("Failed to unmarshal %T: %v\n%s", v, err, body)
+	}
+}
+
+func TestCaps(t *testing.T) {
+	ts := httptest.NewServer(&Server{Client: &fakeLister{}, APIKey: "key"})
+	defer ts.Close()
+	var got struct {
+		Server struct {
+			Title string `xml:"title,attr"`
+		} `xml:"server"`
+		Searching struct {
+			MovieSearch struct {
+				Available       string `xml:"available,attr"`
+				SupportedParams string `xml:"supportedParams,attr"`
+			} `xml:"movie-search"`
+		} `xml:"searching"`
+		Categories []struct {
+			ID      int `xml:"id,attr"`
+			Subcats []struct {
+				ID int `xml:"id,attr"`
+			} `xml:"subcat"`
+		} `xml:"categories>category"`
+	}
+	get(t, ts, "t=caps", &got)
+	if got.Server.Title != "YTS" {
+		t.Errorf("Unexpected title, got %q want YTS", got.Server.Title)
+	}
+	if got.Searching.MovieSearch.Available != "yes" || got.Searching.MovieSearch.SupportedParams != "q,imdbid" {
+		t.Errorf("Unexpected movie-search %+v", got.Searching.MovieSearch)
+	}
+	if len(got.Categories) != 1 || got.Categories[0].ID != CategoryMovies || len(got.Categories[0].Subcats) != 4 {
+		t.Errorf("Unexpected categories %+v", got.Categories)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	testData := []struct {
+		desc      string
+		query     string
+		wantQuery url.Values
+		wantItems int
+		wantTotal uint
+	}{
+		{
+			desc:      "movie by imdbid",
+			query:     "t=movie&imdbid=0133093&apikey=key",
+			wantQuery: url.Values{"limit": {"50"}, "page": {"1"}, "query_term": {"tt0133093"}},
+			wantItems: 2,
+			wantTotal: 2,
+		},
+		{
+			desc:      "search",
+			query:     "t=search&q=matrix&offset=20&limit=10&apikey=key",
+			wantQuery: url.Values{"limit": {"50"}, "page": {"1"}, "query_term": {"matrix"}},
+			wantItems: 0,
+			wantTotal: 2,
+		},
+		{
+			desc:      "rss sync",
+			query:     "t=search&apikey=key",
+			wantQuery: url.Values{"limit": {"50"}, "page": {"1"}, "sort_by": {"date_added"}, "order_by": {"desc"}},
+			wantItems: 2,
+			wantTotal: 2,
+		},
+		{
+			desc:      "category filter",
+			query:     "t=movie&q=matrix&cat=2045&apikey=key",
+			wantQuery: url.Values{"limit": {"50"}, "page": {"1"}, "query_term": {"matrix"}},
+			wantItems: 1,
+			wantTotal: 1,
+		},
+	}
+	l := &fakeLister{}
+	ts := httptest.NewServer(&Server{Client: l, APIKey: "key"})
+	defer ts.Close()
+	for _, tc := range testData {
+		t.Run(tc.desc, func(t *testing.T) {
+			var got gotFeed
+			get(t, ts, tc.query, &got)
+			if diff := cmp.Diff(tc.wantQuery, l.query); diff != "" {
+				t.Errorf("Unexpected ListMovies query, diff -want +got\n%s", diff)
+			}
+			if len(got.Channel.Items) != tc.wantItems {
+				t.Errorf("Unexpected number of items, got %d want %d", len(got.Channel.Items), tc.wantItems)
+			}
+			if got.Channel.Response.Total != tc.wantTotal {
+				t.Errorf("Unexpected total, got %d want %d", got.Channel.Response.Total, tc.wantTotal)
+			}
+		})
+	}
+}
+
+func TestSearchPaging(t *testing.T) {
+	l := &fakeLister{}
+	for i := 0; i < 120; i++ {
+		l.movies = append(l.movies, &ytsgo.Movie{
+			ID:       uint(i),
+			Title:    "Movie " + strconv.Itoa(i),
+			Torrents: []*ytsgo.Torrent{{Hash: "A" + strconv.Itoa(i), Quality: "720p"}, {Hash: "B" + strconv.Itoa(i), Quality: "2160p"}},
+		})
+	}
+	testData := []struct {
+		desc      string
+		query     string
+		maxPages  uint
+		wantFirst string
+		wantItems int
+		wantTotal uint
+		wantCalls int
+	}{
+		{
+			desc:      "first page",
+			query:     "t=search&limit=10",
+			wantFirst: "a0",
+			wantItems: 10,
+			wantTotal: 240,
+			wantCalls: 1,
+		},
+		{
+			desc:      "across movie pages",
+			query:     "t=search&offset=95&limit=10",
+			wantFirst: "b47",
+			wantItems: 10,
+			wantTotal: 240,
+			wantCalls: 2,
+		},
+		{
+			desc:      "last page",
+			query:     "t=search&offset=235&limit=10",
+			wantFirst: "b117",
+			wantItems: 5,
+			wantTotal: 240,
+			wantCalls: 3,
+		},
+		{
+			desc:      "past the end",
+			query:     "t=search&offset=300",
+			wantItems: 0,
+			wantTotal: 240,
+			wantCalls: 3,
+		},
+		{
+			desc:      "max pages",
+			query:     "t=search&offset=100&limit=10",
+			maxPages:  1,
+			wantItems: 0,
+			wantTotal: 240,
+			wantCalls: 1,
+		},
+		{
+			desc:      "category filter",
+			query:     "t=search&cat=2045&offset=10&limit=5",
+			wantFirst: "b10",
+			wantItems: 5,
+			wantTotal: 120,
+			wantCalls: 1,
+		},
+	}
+	for _, tc := range testData {
+		t.Run(tc.desc, func(t *testing.T) {
+			l.calls = 0
+			ts := httptest.NewServer(&Server{Client: l, MaxPages: tc.maxPages})
+			defer ts.Close()
+			var got gotFeed
+			get(t, ts, tc.query, &got)
+			if len(got.Channel.Items) != tc.wantItems {
+				t.Fatalf("Unexpected number of items, got %d want %d", len(got.Channel.Items), tc.wantItems)
+			}
+			if tc.wantItems > 0 && got.Channel.Items[0].GUID != tc.wantFirst {
+				t.Errorf("Unexpected first item, got %s want %s", got.Channel.Items[0].GUID, tc.wantFirst)
+			}
+			if got.Channel.Response.Total != tc.wantTotal {
+				t.Errorf("Unexpected total, got %d want %d", got.Channel.Response.Total, tc.wantTotal)
+			}
+			if l.calls != tc.wantCalls {
+				t.Errorf("Unexpected number of ListMovies calls, got %d want %d", l.calls, tc.wantCalls)
+			}
+		})
+	}
+}
+
+func TestItem(t *testing.T) {
+	ts := httptest.NewServer(&Server{Client: &fakeLister{}})
+	defer ts.Close()
+	var got gotFeed
+	get(t, ts, "t=movie&imdbid=tt0133093", &got)
+	if len(got.Channel.Items) != 2 {
+		t.Fatalf("Unexpected number of items, got %d want 2", len(got.Channel.Items))
+	}
+	it := got.Channel.Items[0]
+	magnet := (&ytsgo.Torrent{Hash: "ABCD", Quality: "1080p"}).Magnet()
+	want := gotItem{
+		Title:    "The Matrix (1999) [1080p] [BluRay] [YTS.LT]",
+		GUID:     "abcd",
+		Link:     "https://yts.lt/torrent/download/ABCD",
+		PubDate:  time.Unix(1500000000, 0).UTC().Format(time.RFC1123Z),
+		Size:     1000,
+		Category: CategoryMoviesHD,
+		Attrs: []attr{
+			{Name: "category", Value: "2000"},
+			{Name: "category", Value: "2040"},
+			{Name: "size", Value: "1000"},
+			{Name: "seeders", Value: "10"},
+			{Name: "peers", Value: "15"},
+			{Name: "infohash", Value: "abcd"},
+			{Name: "magneturl", Value: magnet},
+			{Name: "downloadvolumefactor", Value: "1"},
+			{Name: "uploadvolumefactor", Value: "1"},
+			{Name: "imdb", Value: "0133093"},
+			{Name: "genre", Value: "Action, Sci-Fi"},
+		},
+	}
+	want.Enclosure.URL = want.Link
+	if diff := cmp.Diff(want, it); diff != "" {
+		t.Errorf("Unexpected item, diff -want +got\n%s", diff)
+	}
+	// Torrent without URL links to the magnet and falls back to parsed size.
+	it = got.Channel.Items[1]
+	if it.Link != it.Attrs[6].Value || it.Size != 1024 || it.Category != CategoryUHD {
+		t.Errorf("Unexpected item %+v", it)
+	}
+	if it.Title != "The Matrix (1999) [2160p] [WEBRip] [YTS.LT]" {
+		t.Errorf("Unexpected title %q", it.Title)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	testData := []struct {
+		desc     string
+		query    string
+		err      error
+		wantCode int
+	}{
+		{desc: "no function", query: "", wantCode: ErrMissingParameter},
+		{desc: "unknown function", query: "t=music&apikey=key", wantCode: ErrNoSuchFunction},
+		{desc: "bad api key", query: "t=search&apikey=bad", wantCode: ErrIncorrectCredentials},
+		{desc: "bad imdbid", query: "t=movie&imdbid=abc&apikey=key", wantCode: ErrIncorrectParameter},
+		{desc: "bad limit", query: "t=search&limit=x&apikey=key", wantCode: ErrIncorrectParameter},
+		{desc: "bad category", query: "t=search&cat=x&apikey=key", wantCode: ErrIncorrectParameter},
+		{desc: "api error", query: "t=search&apikey=key", err: errors.New("down"), wantCode: ErrUnknown},
+	}
+	for _, tc := range testData {
+		t.Run(tc.desc, func(t *testing.T) {
+			ts := httptest.NewServer(&Server{Client: &fakeLister{err: tc.err}, APIKey: "key"})
+			defer ts.Close()
+			var got Error
+			get(t, ts, tc.query, &got)
+			if got.Code != tc.wantCode {
+				t.Errorf("Unexpected error code, got %d want %d (%s)", got.Code, tc.wantCode, got.Description)
+			}
+		})
+	}
+}
+
+func TestCategory(t *testing.T) {
+	for q, want := range map[string]int{"480p": CategoryMoviesSD, "720p": CategoryMoviesHD, "1080p": CategoryMoviesHD, "2160p": CategoryUHD, "3D": Category3D, "": CategoryMovies} {
+		if got := Category(q); got != want {
+			t.Errorf("Category(%q) = %d want %d", q, got, want)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package ytsgo
+
+// File trace.go contains tracing hooks used by the Client.
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Tracer starts spans for Client calls. It is deliberately small so that
+// OpenTelemetry or any other tracing library can be adapted to it.
+type Tracer interface {
+	// Start starts a new span with the given name.
+	Start(name string) Span
+}
+
+// Span is a single traced Client call.
+type Span interface {
+	// SetAttribute records a key/value pair on the span.
+	SetAttribute(key string, value interface{})
+	// Inject writes trace context headers (eg. traceparent) to h.
+	Inject(h http.Header)
+	// End finishes the span. err is nil if the call succeeded.
+	End(err error)
+}
+
+// startSpan starts a span for the op call of the endpoint. Attributes are
+// taken from the query params. If the Client has no Tracer a no-op span is returned.
+func (c *Client) startSpan(op, endpoint string, params url.Values) Span {
+	if c.tracer == nil {
+		return noopSpan{}
+	}
+	s := c.tracer.Start("ytsgo." + op)
+	s.SetAttribute("endpoint", endpoint)
+	if id := params.Get("movie_id"); id != "" {
+		if n, err := strconv.Atoi(id); err == nil {
+			s.SetAttribute("movie_id", n)
+		}
+	}
+	if p := params.Get("page"); p != "" {
+		if n, err := strconv.Atoi(p); err == nil {
+			s.SetAttribute("page", n)
+		}
+	}
+	if q := params.Get("quality"); q != "" {
+		s.SetAttribute("quality", q)
+	}
+	return s
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttribute(string, interface{}) {}
+func (noopSpan) Inject(http.Header)               {}
+func (noopSpan) End(error)                        {}
@@ -0,0 +1,156 @@
+package ytsgo
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type memSpan struct {
+	name  string
+	attrs map[string]interface{}
+	ended bool
+	err   error
+}
+
+func (s *memSpan) SetAttribute(key string, value interface{}) {
+	s.attrs[key] = value
+}
+
+func (s *memSpan) Inject(h http.Header) {
+	h.Set("traceparent", "00-"+s.name)
+}
+
+func (s *memSpan) End(err error) {
+	s.ended = true
+	s.err = err
+}
+
+type memTracer struct {
+	mu    sync.Mutex
+	spans []*memSpan
+}
+
+func (t *memTracer) Start(name string) Span {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s := &memSpan{name: name, attrs: make(map[string]interface{})}
+	t.spans = append(t.spans, s)
+	return s
+}
+
+func TestTracing(t *testing.T) {
+	testData := []struct {
+		desc      string
+		call      func(c *Client) error
+		respFile  string
+		err       error
+		wantName  string
+		wantAttrs map[string]interface{}
+		wantErr   bool
+	}{
+		{
+			desc: "movie",
+			call: func(c *Client) error {
+				_, err := c.Movie(10)
+				return err
+			},
+			respFile: "matrix.json",
+			wantName: "ytsgo.Movie",
+			wantAttrs: map[string]interface{}{
+				"endpoint": "movie_details.json",
+				"movie_id": 10,
+				"status":   http.StatusOK,
+			},
+		},
+		{
+			desc: "list movies",
+			call: func(c *Client) error {
+				_, err := c.ListMovies(LMPage(3), LMQuality("1080p"))
+				return err
+			},
+			respFile: "matrixes.json",
+			wantName: "ytsgo.ListMovies",
+			wantAttrs: map[string]interface{}{
+				"endpoint": "list_movies.json",
+				"page":     3,
+				"quality":  "1080p",
+				"status":   http.StatusOK,
+			},
+		},
+		{
+			desc: "suggestions",
+			call: func(c *Client) error {
+				_, err := c.Suggestions(7)
+				return err
+			},
+			respFile: "suggestions.json",
+			wantName: "ytsgo.Suggestions",
+			wantAttrs: map[string]interface{}{
+				"endpoint": "movie_suggestions.json",
+				"movie_id": 7,
+				"status":   http.StatusOK,
+			},
+		},
+		{
+			desc: "server error",
+			call: func(c *Client) error {
+				_, err := c.Movie(10)
+				return err
+			},
+			respFile: "matrix.json",
+			err:      errors.New("some error"),
+			wantName: "ytsgo.Movie",
+			wantAttrs: map[string]interface{}{
+				"endpoint": "movie_details.json",
+				"movie_id": 10,
+				"status":   http.StatusInternalServerError,
+			},
+			wantErr: true,
+		},
+	}
+	f := &fakeYTSServer{}
+	ts := httptest.NewServer(f)
+	defer ts.Close()
+	for _, tc := range testData {
+		t.Run(tc.desc, func(t *testing.T) {
+			f.err = tc.err
+			f.data = loadTestData(tc.respFile, t)
+			tr := &memTracer{}
+			c, err := New(BaseURL(ts.URL), HTTPTimeout(time.Second*5), TraceWith(tr))
+			if err != nil {
+				t.Fatalf("Failed to connect to test server: %v", err)
+			}
+			if err := tc.call(c); (err != nil) != tc.wantErr {
+				t.Errorf("Unexpected error, got %v want %v", err, tc.wantErr)
+			}
+			if len(tr.spans) != 1 {
+				t.Fatalf("Unexpected number of spans, got %d want 1", len(tr.spans))
+			}
+			s := tr.spans[0]
+			if s.name != tc.wantName {
+				t.Errorf("Unexpected span name, got %q want %q", s.name, tc.wantName)
+			}
+			if diff := cmp.Diff(tc.wantAttrs, s.attrs); diff != "" {
+				t.Errorf("Unexpected attributes, diff -want +got\n%s", diff)
+			}
+			if !s.ended {
+				t.Error("Span was not ended")
+			}
+			if (s.err != nil) != tc.wantErr {
+				t.Errorf("Unexpected span error, got %v want %v", s.err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+			if got, want := f.req.Header.Get("traceparent"), "00-"+tc.wantName; got != want {
+				t.Errorf("Unexpected traceparent header, got %q want %q", got, want)
+			}
+		})
+	}
+}
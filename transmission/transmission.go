@@ -0,0 +1,225 @@
+// Package transmission is a client of the Transmission RPC protocol used to
+// enqueue YTS torrents. The protocol is described at
+// https://github.com/transmission/transmission/blob/main/docs/rpc-spec.md
+package transmission
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+
+	"github.com/qopher/ytsgo"
+)
+
+const (
+	// DefaultURL is a default RPC endpoint of a local Transmission daemon.
+	DefaultURL = "http://localhost:9091/transmission/rpc"
+	// SessionIDHeader carries the CSRF token required by Transmission.
+	SessionIDHeader = "X-Transmission-Session-Id"
+)
+
+// maxResponseSize limits the size of RPC responses.
+const maxResponseSize = 16 << 20
+
+// Fields are requested by Torrents.
+var Fields = []string{"id", "name", "hashString", "status", "percentDone", "totalSize", "downloadDir", "labels", "error", "errorString"}
+
+// Torrent statuses.
+const (
+	StatusStopped = iota
+	StatusCheckWait
+	StatusCheck
+	StatusDownloadWait
+	StatusDownload
+	StatusSeedWait
+	StatusSeed
+)
+
+// Client sends RPC requests to Transmission.
+type Client struct {
+	// URL overrides DefaultURL if not empty.
+	URL string
+	// Username and Password are used for basic authentication if Username is not empty.
+	Username string
+	Password string
+	// HTTPClient is used to send requests, http.DefaultClient if nil.
+	HTTPClient *http.Client
+
+	mu        sync.Mutex
+	sessionID string
+}
+
+// Torrent is a torrent known to Transmission. Only Fields are set.
+type Torrent struct {
+	ID          int      `json:"id"`
+	Name        string   `json:"name"`
+	HashString  string   `json:"hashString"`
+	Status      int      `json:"status"`
+	PercentDone float64  `json:"percentDone"`
+	TotalSize   int64    `json:"totalSize"`
+	DownloadDir string   `json:"downloadDir"`
+	Labels      []string `json:"labels"`
+	Error       int      `json:"error"`
+	ErrorString string   `json:"errorString"`
+	// Duplicate is true if Add found the torrent already added.
+	Duplicate bool `json:"-"`
+}
+
+// AddOptions configure added torrents.
+type AddOptions struct {
+	// DownloadDir overrides the default download directory of Transmission.
+	DownloadDir string
+	// Labels are set on the torrent, they require Transmission 3.0 or newer.
+	Labels []string
+	// Paused adds the torrent without starting it.
+	Paused bool
+}
+
+// Error is returned if Transmission replies with a result other than "success".
+type Error struct {
+	Method string
+	Result string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("transmission %s failed: %s", e.Method, e.Result)
+}
+
+type request struct {
+	Method    string      `json:"method"`
+	Arguments interface{} `json:"arguments,omitempty"`
+}
+
+type response struct {
+	Result    string          `json:"result"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+// call executes the RPC method and decodes its arguments into ret. The
+// session id is obtained on the first call and refreshed when it expires.
+func (c *Client) call(ctx context.Context, method string, args, ret interface{}) error {
+	body, err := json.Marshal(&request{Method: method, Arguments: args})
+	if err != nil {
+		return err
+	}
+	rsp, err := c.post(ctx, body)
+	if err != nil {
+		return err
+	}
+	if rsp.StatusCode == http.StatusConflict {
+		rsp.Body.Close()
+		c.mu.Lock()
+		c.sessionID = rsp.Header.Get(SessionIDHeader)
+		c.mu.Unlock()
+		if rsp, err = c.post(ctx, body); err != nil {
+			return err
+		}
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		return fmt.Errorf("transmission returned code %v: %s", rsp.StatusCode, rsp.Status)
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(rsp.Body, maxResponseSize+1))
+	if err != nil {
+		return err
+	}
+	if len(data) > maxResponseSize {
+		return fmt.Errorf("transmission response is larger than %d bytes", maxResponseSize)
+	}
+	var r response
+	if err := json.Unmarshal(data, &r); err != nil {
+		return fmt.Errorf("failed to decode transmission response: %v", err)
+	}
+	if r.Result != "success" {
+		return &Error{Method: method, Result: r.Result}
+	}
+	if ret == nil || len(r.Arguments) == 0 {
+		return nil
+	}
+	return json.Unmarshal(r.Arguments, ret)
+}
+
+func (c *Client) post(ctx context.Context, body []byte) (*http.Response, error) {
+	u := c.URL
+	if u == "" {
+		u = DefaultURL
+	}
+	req, err := http.NewRequest("POST", u, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	c.mu.Lock()
+	if c.sessionID != "" {
+		req.Header.Set(SessionIDHeader, c.sessionID)
+	}
+	c.mu.Unlock()
+	if c.Username != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+	hc := c.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	return hc.Do(req)
+}
+
+// Add adds the torrent using its magnet link.
+func (c *Client) Add(ctx context.Context, t *ytsgo.Torrent, opts AddOptions) (*Torrent, error) {
+	return c.AddMagnet(ctx, t.Magnet(), opts)
+}
+
+// AddMagnet adds the torrent from a magnet link or a .torrent URL.
+func (c *Client) AddMagnet(ctx context.Context, magnet string, opts AddOptions) (*Torrent, error) {
+	args := map[string]interface{}{"filename": magnet}
+	if opts.DownloadDir != "" {
+		args["download-dir"] = opts.DownloadDir
+	}
+	if opts.Paused {
+		args["paused"] = true
+	}
+	var ret struct {
+		Added     *Torrent `json:"torrent-added"`
+		Duplicate *Torrent `json:"torrent-duplicate"`
+	}
+	if err := c.call(ctx, "torrent-add", args, &ret); err != nil {
+		return nil, err
+	}
+	t := ret.Added
+	if t == nil {
+		if t = ret.Duplicate; t == nil {
+			return nil, fmt.Errorf("transmission torrent-add returned no torrent")
+		}
+		t.Duplicate = true
+	}
+	if len(opts.Labels) > 0 {
+		// torrent-add accepts labels only since Transmission 4.0, torrent-set since 3.0.
+		set := map[string]interface{}{"ids": []int{t.ID}, "labels": opts.Labels}
+		if err := c.call(ctx, "torrent-set", set, nil); err != nil {
+			return t, err
+		}
+		t.Labels = opts.Labels
+	}
+	return t, nil
+}
+
+// Torrents returns torrents with given ids, or all torrents if no ids are passed.
+func (c *Client) Torrents(ctx context.Context, ids ...int) ([]*Torrent, error) {
+	args := map[string]interface{}{"fields": Fields}
+	if len(ids) > 0 {
+		args["ids"] = ids
+	}
+	var ret struct {
+		Torrents []*Torrent `json:"torrents"`
+	}
+	if err := c.call(ctx, "torrent-get", args, &ret); err != nil {
+		return nil, err
+	}
+	return ret.Torrents, nil
+}
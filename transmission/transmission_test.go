@@ -0,0 +1,204 @@
+package transmission
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qopher/ytsgo"
+)
+
+// fakeTransmission implements a subset of the Transmission RPC.
+type fakeTransmission struct {
+	mu        sync.Mutex
+	sessionID string
+	user      string
+	password  string
+	conflicts int
+	methods   []string
+	torrents  []*Torrent
+	args      []map[string]interface{}
+	fail      string
+}
+
+func (f *fakeTransmission) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.user != "" {
+		if u, p, ok := r.BasicAuth(); !ok || u != f.user || p != f.password {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+	}
+	if r.Header.Get(SessionIDHeader) != f.sessionID {
+		f.conflicts++
+		w.Header().Set(SessionIDHeader, f.sessionID)
+		w.WriteHeader(http.StatusConflict)
+		return
+	}
+	var req struct {
+		Method    string                 `json:"method"`
+		Arguments map[string]interface{} `json:"arguments"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	f.methods = append(f.methods, req.Method)
+	f.args = append(f.args, req.Arguments)
+	rsp := map[string]interface{}{"result": "success"}
+	args := map[string]interface{}{}
+	switch {
+	case f.fail != "":
+		rsp["result"] = f.fail
+	case req.Method == "torrent-add":
+		name := req.Arguments["filename"].(string)
+		for _, t := range f.torrents {
+			if t.Name == name {
+				args["torrent-duplicate"] = t
+			}
+		}
+		if len(args) == 0 {
+			t := &Torrent{ID: len(f.torrents) + 1, Name: name, HashString: "hash"}
+			f.torrents = append(f.torrents, t)
+			args["torrent-added"] = t
+		}
+	case req.Method == "torrent-set":
+		for _, id := range req.Arguments["ids"].([]interface{}) {
+			for _, l := range req.Arguments["labels"].([]interface{}) {
+				f.torrents[int(id.(float64))-1].Labels = append(f.torrents[int(id.(float64))-1].Labels, l.(string))
+			}
+		}
+	case req.Method == "torrent-get":
+		args["torrents"] = f.torrents
+	default:
+		rsp["result"] = "method name not recognized"
+	}
+	rsp["arguments"] = args
+	json.NewEncoder(w).Encode(rsp)
+}
+
+func TestAdd(t *testing.T) {
+	f := &fakeTransmission{sessionID: "abc", user: "user", password: "pass"}
+	ts := httptest.NewServer(f)
+	defer ts.Close()
+	c := &Client{URL: ts.URL, Username: "user", Password: "pass"}
+	ctx := context.Background()
+	trt := &ytsgo.Torrent{Hash: "ABCD", Quality: "1080p"}
+	got, err := c.Add(ctx, trt, AddOptions{DownloadDir: "/movies", Labels: []string{"yts", "1080p"}, Paused: true})
+	if err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+	want := &Torrent{ID: 1, Name: trt.Magnet(), HashString: "hash", Labels: []string{"yts", "1080p"}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Unexpected torrent, diff -want +got\n%s", diff)
+	}
+	wantArgs := map[string]interface{}{"filename": trt.Magnet(), "download-dir": "/movies", "paused": true}
+	if diff := cmp.Diff(wantArgs, f.args[0]); diff != "" {
+		t.Errorf("Unexpected torrent-add arguments, diff -want +got\n%s", diff)
+	}
+	// Adding the same torrent again reports a duplicate.
+	got, err = c.Add(ctx, trt, AddOptions{})
+	if err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+	if !got.Duplicate || got.ID != 1 {
+		t.Errorf("Unexpected duplicate torrent %+v", got)
+	}
+	if diff := cmp.Diff([]string{"torrent-add", "torrent-set", "torrent-add"}, f.methods); diff != "" {
+		t.Errorf("Unexpected methods, diff -want +got\n%s", diff)
+	}
+	if f.conflicts != 1 {
+		t.Errorf("Unexpected number of session id handshakes, got %d want 1", f.conflicts)
+	}
+}
+
+func TestSessionExpired(t *testing.T) {
+	f := &fakeTransmission{sessionID: "first"}
+	ts := httptest.NewServer(f)
+	defer ts.Close()
+	c := &Client{URL: ts.URL}
+	ctx := context.Background()
+	if _, err := c.Torrents(ctx); err != nil {
+		t.Fatalf("Torrents() failed: %v", err)
+	}
+	f.mu.Lock()
+	f.sessionID = "second"
+	f.mu.Unlock()
+	if _, err := c.Torrents(ctx); err != nil {
+		t.Fatalf("Torrents() after session change failed: %v", err)
+	}
+	if f.conflicts != 2 {
+		t.Errorf("Unexpected number of session id handshakes, got %d want 2", f.conflicts)
+	}
+}
+
+func TestTorrents(t *testing.T) {
+	f := &fakeTransmission{torrents: []*Torrent{
+		{ID: 1, Name: "The Matrix", Status: StatusDownload, PercentDone: 0.5},
+		{ID: 2, Name: "Heat", Status: StatusSeed, PercentDone: 1},
+	}}
+	ts := httptest.NewServer(f)
+	defer ts.Close()
+	c := &Client{URL: ts.URL}
+	got, err := c.Torrents(context.Background(), 1, 2)
+	if err != nil {
+		t.Fatalf("Torrents() failed: %v", err)
+	}
+	if diff := cmp.Diff(f.torrents, got); diff != "" {
+		t.Errorf("Unexpected torrents, diff -want +got\n%s", diff)
+	}
+	wantArgs := map[string]interface{}{"ids": []interface{}{1.0, 2.0}, "fields": func() []interface{} {
+		var ret []interface{}
+		for _, f := range Fields {
+			ret = append(ret, f)
+		}
+		return ret
+	}()}
+	if diff := cmp.Diff(wantArgs, f.args[0]); diff != "" {
+		t.Errorf("Unexpected torrent-get arguments, diff -want +got\n%s", diff)
+	}
+	f.torrents = []*Torrent{{ID: 1, Name: strings.Repeat("x", maxResponseSize)}}
+	if _, err := c.Torrents(context.Background(), 1); err == nil {
+		t.Error("Expected an error for too large response")
+	}
+}
+
+func TestErrors(t *testing.T) {
+	testData := []struct {
+		desc    string
+		fake    *fakeTransmission
+		user    string
+		pass    string
+		wantErr string
+	}{
+		{
+			desc:    "rpc error",
+			fake:    &fakeTransmission{fail: "invalid or corrupt torrent file"},
+			wantErr: "transmission torrent-add failed: invalid or corrupt torrent file",
+		},
+		{
+			desc:    "unauthorized",
+			fake:    &fakeTransmission{user: "user", password: "pass"},
+			user:    "user",
+			pass:    "bad",
+			wantErr: "transmission returned code 401",
+		},
+	}
+	for _, tc := range testData {
+		t.Run(tc.desc, func(t *testing.T) {
+			ts := httptest.NewServer(tc.fake)
+			defer ts.Close()
+			c := &Client{URL: ts.URL, Username: tc.user, Password: tc.pass}
+			_, err := c.AddMagnet(context.Background(), "magnet:?xt=urn:btih:ABCD", AddOptions{})
+			if err == nil || !strings.HasPrefix(err.Error(), tc.wantErr) {
+				t.Errorf("Unexpected error, got %v want %q", err, tc.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,216 @@
+// Package watchfolder exports YTS torrents to a directory watched by a
+// torrent client. Torrents are saved as .torrent files downloaded from
+// Torrent.URL or as .magnet files containing the magnet link.
+package watchfolder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/qopher/ytsgo"
+	"github.com/qopher/ytsgo/internal/fsutil"
+)
+
+const (
+	// DefaultTemplate is a default name of exported files.
+	DefaultTemplate = "{title} ({year}) [{quality}] [{type}].torrent"
+	// IndexFile is a name of the file in the directory which records
+	// exported info hashes, so that torrents already picked up and removed
+	// by the client are not exported again.
+	IndexFile = ".ytsgo-exported.json"
+	// DefaultMaxSize is a default limit of the downloaded .torrent file size.
+	DefaultMaxSize = 10 << 20
+)
+
+// ErrDuplicate is returned by Export if the torrent was already exported.
+var ErrDuplicate = errors.New("torrent already exported")
+
+// Exporter writes torrents to Dir.
+type Exporter struct {
+	Dir string
+	// Template overrides DefaultTemplate. It supports {title}, {year},
+	// {quality}, {type}, {hash}, {imdb} and {id} placeholders. The extension
+	// is replaced by .magnet for magnet files.
+	Template string
+	// Magnet makes the Exporter write .magnet files instead of downloading
+	// .torrent files. Torrents without URL are always exported as magnets.
+	Magnet bool
+	// MaxSize overrides DefaultMaxSize if positive.
+	MaxSize int64
+	// HTTPClient is used to download .torrent files, http.DefaultClient if nil.
+	HTTPClient *http.Client
+
+	mu    sync.Mutex
+	index map[string]string
+}
+
+// emptyBrackets removes brackets left by empty placeholders.
+var emptyBrackets = strings.NewReplacer(" []", "", " ()", "", "[]", "", "()", "")
+
+// Name returns the file name of the torrent based on the template. Characters
+// not allowed in file names are replaced and brackets left empty by missing
+// values are removed.
+func Name(template string, m *ytsgo.Movie, t *ytsgo.Torrent) string {
+	if template == "" {
+		template = DefaultTemplate
+	}
+	year := ""
+	if m.Year != 0 {
+		year = strconv.FormatUint(uint64(m.Year), 10)
+	}
+	r := strings.NewReplacer(
+		"{title}", m.Title,
+		"{year}", year,
+		"{quality}", t.Quality,
+		"{type}", t.Type,
+		"{hash}", strings.ToLower(t.Hash),
+		"{imdb}", m.IMDBCode,
+		"{id}", strconv.FormatUint(uint64(m.ID), 10),
+	)
+	return fsutil.SanitizeName(emptyBrackets.Replace(r.Replace(template)))
+}
+
+// Export writes the torrent of the movie to the directory and returns the
+// path of the written file. ErrDuplicate is returned if a torrent with the
+// same info hash was exported before. Existing files are never replaced, if
+// the name is taken the start of the info hash is added to it.
+func (e *Exporter) Export(ctx context.Context, m *ytsgo.Movie, t *ytsgo.Torrent) (string, error) {
+	hash := strings.ToLower(t.Hash)
+	if hash == "" {
+		return "", fmt.Errorf("torrent of %q has no info hash", m.Title)
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err := e.loadIndex(); err != nil {
+		return "", err
+	}
+	if _, ok := e.index[hash]; ok {
+		return "", ErrDuplicate
+	}
+	name := Name(e.Template, m, t)
+	var data []byte
+	if e.Magnet || t.URL == nil {
+		name = strings.TrimSuffix(name, filepath.Ext(name)) + ".magnet"
+		data = []byte(t.Magnet() + "\n")
+	} else {
+		var err error
+		if data, err = e.download(ctx, t); err != nil {
+			return "", err
+		}
+	}
+	path, err := e.freePath(name, hash)
+	if err != nil {
+		return "", err
+	}
+	if err := fsutil.WriteFile(path, data); err != nil {
+		return "", err
+	}
+	e.index[hash] = name
+	if err := e.saveIndex(); err != nil {
+		return path, err
+	}
+	return path, nil
+}
+
+// freePath returns a path of a file in the directory which does not exist,
+// adding up to 8 characters of the hash to the name if needed.
+func (e *Exporter) freePath(name, hash string) (string, error) {
+	path := filepath.Join(e.Dir, name)
+	if _, err := os.Lstat(path); os.IsNotExist(err) {
+		return path, nil
+	}
+	if len(hash) > 8 {
+		hash = hash[:8]
+	}
+	ext := filepath.Ext(name)
+	path = filepath.Join(e.Dir, strings.TrimSuffix(name, ext)+" ["+hash+"]"+ext)
+	if _, err := os.Lstat(path); os.IsNotExist(err) {
+		return path, nil
+	}
+	return "", fmt.Errorf("%s already exists", path)
+}
+
+// Exported reports whether the torrent with given info hash was exported.
+func (e *Exporter) Exported(hash string) (bool, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err := e.loadIndex(); err != nil {
+		return false, err
+	}
+	_, ok := e.index[strings.ToLower(hash)]
+	return ok, nil
+}
+
+func (e *Exporter) download(ctx context.Context, t *ytsgo.Torrent) ([]byte, error) {
+	req, err := http.NewRequest("GET", t.URL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	hc := e.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	rsp, err := hc.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("torrent download returned code %v: %s", rsp.StatusCode, rsp.Status)
+	}
+	max := e.MaxSize
+	if max <= 0 {
+		max = DefaultMaxSize
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(rsp.Body, max+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > max {
+		return nil, fmt.Errorf("torrent file %s is larger than %d bytes", t.URL, max)
+	}
+	// A .torrent file is a bencoded dictionary, anything else is likely an error page.
+	if !bytes.HasPrefix(data, []byte("d")) {
+		return nil, fmt.Errorf("%s is not a torrent file", t.URL)
+	}
+	return data, nil
+}
+
+// loadIndex reads the index on first use. e.mu must be held.
+func (e *Exporter) loadIndex() error {
+	if e.index != nil {
+		return nil
+	}
+	e.index = make(map[string]string)
+	data, err := ioutil.ReadFile(filepath.Join(e.Dir, IndexFile))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &e.index); err != nil {
+		return fmt.Errorf("invalid index %s: %v", IndexFile, err)
+	}
+	return nil
+}
+
+// saveIndex writes the index. e.mu must be held.
+func (e *Exporter) saveIndex() error {
+	data, err := json.MarshalIndent(e.index, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fsutil.WriteFile(filepath.Join(e.Dir, IndexFile), data)
+}
@@ -0,0 +1,158 @@
+package watchfolder
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qopher/ytsgo"
+)
+
+func TestName(t *testing.T) {
+	m := &ytsgo.Movie{ID: 10, Title: "Mission: Impossible / Fallout?", Year: 2018, IMDBCode: "tt4912910"}
+	trt := &ytsgo.Torrent{Hash: "ABCD", Quality: "1080p", Type: "bluray"}
+	testData := []struct {
+		template string
+		movie    *ytsgo.Movie
+		torrent  *ytsgo.Torrent
+		want     string
+	}{
+		{template: "", want: "Mission_ Impossible _ Fallout_ (2018) [1080p] [bluray].torrent"},
+		{template: "", torrent: &ytsgo.Torrent{Quality: "720p"}, want: "Mission_ Impossible _ Fallout_ (2018) [720p].torrent"},
+		{template: "", movie: &ytsgo.Movie{Title: "Heat"}, want: "Heat [1080p] [bluray].torrent"},
+		{template: "{imdb}-{id}-{quality}-{type}-{hash}.torrent", want: "tt4912910-10-1080p-bluray-abcd.torrent"},
+		{template: "../{title}.torrent", want: "_Mission_ Impossible _ Fallout_.torrent"},
+	}
+	for _, tc := range testData {
+		mm, tt := tc.movie, tc.torrent
+		if mm == nil {
+			mm = m
+		}
+		if tt == nil {
+			tt = trt
+		}
+		if got := Name(tc.template, mm, tt); got != tc.want {
+			t.Errorf("Name(%q) = %q want %q", tc.template, got, tc.want)
+		}
+	}
+}
+
+func files(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ret := make(map[string]string)
+	for _, fi := range infos {
+		data, err := ioutil.ReadFile(filepath.Join(dir, fi.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ret[fi.Name()] = string(data)
+	}
+	return ret
+}
+
+func TestExport(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/torrent/AAAA":
+			w.Write([]byte("d8:announce4:teste"))
+		case "/torrent/HTML":
+			w.Write([]byte("<html>Just a moment...</html>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+	u := func(p string) *url.URL {
+		ret, _ := url.Parse(ts.URL + p)
+		return ret
+	}
+	m := &ytsgo.Movie{Title: "The Matrix", Year: 1999}
+	magnetOnly := &ytsgo.Torrent{Hash: "BBBB", Quality: "720p"}
+	testData := []struct {
+		desc    string
+		magnet  bool
+		torrent *ytsgo.Torrent
+		want    string
+		wantErr error
+		anyErr  bool
+	}{
+		{desc: "download", torrent: &ytsgo.Torrent{URL: u("/torrent/AAAA"), Hash: "AAAA", Quality: "1080p", Type: "bluray"}, want: "The Matrix (1999) [1080p] [bluray].torrent"},
+		{desc: "duplicate", torrent: &ytsgo.Torrent{URL: u("/torrent/AAAA"), Hash: "aaaa", Quality: "1080p", Type: "bluray"}, wantErr: ErrDuplicate},
+		{desc: "name taken", torrent: &ytsgo.Torrent{URL: u("/torrent/AAAA"), Hash: "FFFFFFFFFF", Quality: "1080p", Type: "bluray"}, want: "The Matrix (1999) [1080p] [bluray] [ffffffff].torrent"},
+		{desc: "no url", torrent: magnetOnly, want: "The Matrix (1999) [720p].magnet"},
+		{desc: "magnet mode", magnet: true, torrent: &ytsgo.Torrent{URL: u("/torrent/CCCC"), Hash: "CCCC", Quality: "2160p"}, want: "The Matrix (1999) [2160p].magnet"},
+		{desc: "not found", torrent: &ytsgo.Torrent{URL: u("/torrent/NONE"), Hash: "DDDD", Quality: "3D"}, anyErr: true},
+		{desc: "not a torrent", torrent: &ytsgo.Torrent{URL: u("/torrent/HTML"), Hash: "EEEE", Quality: "3D"}, anyErr: true},
+		{desc: "no hash", torrent: &ytsgo.Torrent{Quality: "3D"}, anyErr: true},
+	}
+	dir := t.TempDir()
+	e := &Exporter{Dir: dir}
+	for _, tc := range testData {
+		t.Run(tc.desc, func(t *testing.T) {
+			e.Magnet = tc.magnet
+			path, err := e.Export(context.Background(), m, tc.torrent)
+			if tc.anyErr {
+				if err == nil {
+					t.Errorf("Export() succeeded, want error")
+				}
+				return
+			}
+			if err != tc.wantErr {
+				t.Fatalf("Export() unexpected error, got %v want %v", err, tc.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if want := filepath.Join(dir, tc.want); path != want {
+				t.Errorf("Unexpected path, got %q want %q", path, want)
+			}
+		})
+	}
+	got := files(t, dir)
+	var names []string
+	for n := range got {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	wantNames := []string{IndexFile, "The Matrix (1999) [1080p] [bluray] [ffffffff].torrent", "The Matrix (1999) [1080p] [bluray].torrent", "The Matrix (1999) [2160p].magnet", "The Matrix (1999) [720p].magnet"}
+	if diff := cmp.Diff(wantNames, names); diff != "" {
+		t.Errorf("Unexpected files, diff -want +got\n%s", diff)
+	}
+	if got, want := got["The Matrix (1999) [1080p] [bluray].torrent"], "d8:announce4:teste"; got != want {
+		t.Errorf("Unexpected torrent file, got %q want %q", got, want)
+	}
+	if got, want := got["The Matrix (1999) [720p].magnet"], magnetOnly.Magnet()+"\n"; got != want {
+		t.Errorf("Unexpected magnet file, got %q want %q", got, want)
+	}
+
+	// A new Exporter reads the index, so removed files are not exported again.
+	e = &Exporter{Dir: dir}
+	if ok, err := e.Exported("AAAA"); err != nil || !ok {
+		t.Errorf("Exported(AAAA) = %v, %v want true", ok, err)
+	}
+	if _, err := e.Export(context.Background(), m, magnetOnly); err != ErrDuplicate {
+		t.Errorf("Unexpected error, got %v want %v", err, ErrDuplicate)
+	}
+
+	// Files of other torrents are never replaced.
+	taken := &ytsgo.Torrent{Hash: "FFFFFFFF00", Quality: "1080p", Type: "bluray"}
+	if err := ioutil.WriteFile(filepath.Join(dir, "The Matrix (1999) [1080p] [bluray].magnet"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "The Matrix (1999) [1080p] [bluray] [ffffffff].magnet"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.Export(context.Background(), m, taken); err == nil {
+		t.Error("Export() succeeded, want error for taken name")
+	}
+}
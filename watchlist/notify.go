@@ -0,0 +1,135 @@
+package watchlist
+
+// File notify.go contains notifiers delivering watchlist events.
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/qopher/ytsgo/webhook"
+)
+
+// Notifier delivers events.
+type Notifier interface {
+	Notify(e *Event) error
+}
+
+// Notifiers delivers events to all notifiers. Delivery continues after an
+// error, a *NotifyError is returned.
+type Notifiers []Notifier
+
+// Notify implements Notifier.
+func (ns Notifiers) Notify(e *Event) error {
+	var ne NotifyError
+	for _, n := range ns {
+		if err := n.Notify(e); err != nil {
+			ne.Failed = append(ne.Failed, n)
+			if ne.Err == nil {
+				ne.Err = err
+			}
+			continue
+		}
+		ne.Delivered++
+	}
+	if len(ne.Failed) == 0 {
+		return nil
+	}
+	return &ne
+}
+
+// NotifyError is returned by Notifiers if some of the notifiers failed.
+type NotifyError struct {
+	// Failed are the notifiers which failed to deliver the event.
+	Failed Notifiers
+	// Delivered is the number of notifiers which delivered the event.
+	Delivered int
+	// Err is the first error.
+	Err error
+}
+
+func (e *NotifyError) Error() string {
+	return fmt.Sprintf("%d of %d notifiers failed: %v", len(e.Failed), len(e.Failed)+e.Delivered, e.Err)
+}
+
+// Unwrap returns the first error.
+func (e *NotifyError) Unwrap() error {
+	return e.Err
+}
+
+// WriterNotifier writes a line per event, eg. to os.Stdout.
+type WriterNotifier struct {
+	W io.Writer
+}
+
+// Notify implements Notifier.
+func (n *WriterNotifier) Notify(e *Event) error {
+	_, err := fmt.Fprintf(n.W, "%s\n\t%s\n", e, e.Torrent.Magnet())
+	return err
+}
+
+// Payload is a JSON representation of an Event sent by WebhookNotifier.
+type Payload struct {
+	Kind     EventKind `json:"kind"`
+	IMDBCode string    `json:"imdb_code"`
+	MovieID  uint      `json:"movie_id"`
+	Title    string    `json:"title"`
+	Year     uint      `json:"year"`
+	Quality  string    `json:"quality"`
+	Size     string    `json:"size"`
+	Hash     string    `json:"hash"`
+	Magnet   string    `json:"magnet"`
+}
+
+// NewPayload builds a payload for the event.
+func NewPayload(e *Event) *Payload {
+	return &Payload{
+		Kind:     e.Kind,
+		IMDBCode: e.Item.IMDBCode,
+		MovieID:  e.Movie.ID,
+		Title:    e.Movie.Title,
+		Year:     e.Movie.Year,
+		Quality:  e.Torrent.Quality,
+		Size:     e.Torrent.Size,
+		Hash:     e.Torrent.Hash,
+		Magnet:   e.Torrent.Magnet(),
+	}
+}
+
+// WebhookNotifier POSTs a JSON Payload using the Sender.
+type WebhookNotifier struct {
+	Sender *webhook.Sender
+}
+
+// Notify implements Notifier.
+func (n *WebhookNotifier) Notify(e *Event) error {
+	return n.Sender.Send(context.Background(), NewPayload(e))
+}
+
+// EmailNotifier sends an email per event through an SMTP server, eg. a local relay.
+type EmailNotifier struct {
+	// Addr is the host:port of the SMTP server.
+	Addr string
+	From string
+	To   []string
+	// Auth is used if not nil.
+	Auth smtp.Auth
+}
+
+// Notify implements Notifier.
+func (n *EmailNotifier) Notify(e *Event) error {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", n.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(n.To, ", "))
+	// Q encoding keeps line breaks of titles out of the header.
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", "[ytsgo] "+e.String()))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	fmt.Fprintf(&msg, "%s\r\n\r\nSize: %s\r\nMagnet: %s\r\n", e, e.Torrent.Size, e.Torrent.Magnet())
+	return smtp.SendMail(n.Addr, n.Auth, n.From, n.To, msg.Bytes())
+}
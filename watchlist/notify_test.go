@@ -0,0 +1,183 @@
+package watchlist
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qopher/ytsgo"
+	"github.com/qopher/ytsgo/webhook"
+)
+
+func testEvent() *Event {
+	return &Event{
+		Kind:    BetterQuality,
+		Item:    &Item{IMDBCode: "tt0133093"},
+		Movie:   &ytsgo.Movie{ID: 3525, Title: "The Matrix", Year: 1999},
+		Torrent: &ytsgo.Torrent{Quality: "2160p", Size: "5.12 GB", Hash: "HASH"},
+	}
+}
+
+func TestWriterNotifier(t *testing.T) {
+	var buf bytes.Buffer
+	n := &WriterNotifier{W: &buf}
+	if err := n.Notify(testEvent()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got, want := buf.String(), "The Matrix (1999) [tt0133093] is available in better quality: 2160p\n\tmagnet:?xt=urn:btih:HASH"; !strings.HasPrefix(got, want) {
+		t.Errorf("Unexpected output, got %q want prefix %q", got, want)
+	}
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	var got Payload
+	status := http.StatusOK
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Unexpected request %s with content type %q", r.Method, r.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("Failed to decode payload: %v", err)
+		}
+		w.WriteHeader(status)
+	}))
+	defer ts.Close()
+	n := &WebhookNotifier{Sender: &webhook.Sender{URL: ts.URL}}
+	e := testEvent()
+	if err := n.Notify(e); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(*NewPayload(e), got); diff != "" {
+		t.Errorf("Unexpected payload, diff -want +got\n%s", diff)
+	}
+	status = http.StatusInternalServerError
+	if err := n.Notify(e); err == nil {
+		t.Error("Expected an error for failed webhook")
+	}
+}
+
+// fakeSMTP accepts a single message and sends its data to the returned channel.
+func fakeSMTP(t *testing.T) (string, <-chan string) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	msgs := make(chan string, 1)
+	go func() {
+		defer l.Close()
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		reply := func(s string) { conn.Write([]byte(s + "\r\n")) }
+		reply("220 localhost ESMTP")
+		var data strings.Builder
+		inData := false
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			if inData {
+				if line == ".\r\n" {
+					inData = false
+					msgs <- data.String()
+					reply("250 OK")
+					continue
+				}
+				data.WriteString(line)
+				continue
+			}
+			switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250 localhost")
+			case cmd == "DATA":
+				inData = true
+				reply("354 go ahead")
+			case cmd == "QUIT":
+				reply("221 bye")
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+	return l.Addr().String(), msgs
+}
+
+func TestEmailNotifier(t *testing.T) {
+	addr, msgs := fakeSMTP(t)
+	n := &EmailNotifier{
+		Addr: addr,
+		From: "ytsgo@localhost",
+		To:   []string{"team@localhost"},
+	}
+	if err := n.Notify(testEvent()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	msg := <-msgs
+	for _, want := range []string{
+		"To: team@localhost",
+		"Subject: [ytsgo] The Matrix (1999) [tt0133093] is available in better quality: 2160p",
+		"Magnet: magnet:?xt=urn:btih:HASH",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("Message does not contain %q:\n%s", want, msg)
+		}
+	}
+}
+
+func TestEmailNotifierHeaderInjection(t *testing.T) {
+	addr, msgs := fakeSMTP(t)
+	n := &EmailNotifier{
+		Addr: addr,
+		From: "ytsgo@localhost",
+		To:   []string{"team@localhost"},
+	}
+	e := testEvent()
+	e.Movie.Title = "The Matrix\r\nBcc: victim@localhost"
+	if err := n.Notify(e); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	msg := <-msgs
+	header := msg[:strings.Index(msg, "Content-Type:")]
+	for _, l := range strings.Split(header, "\n") {
+		if strings.HasPrefix(l, "Bcc:") {
+			t.Errorf("Title injected a header:\n%s", msg)
+		}
+	}
+	if !strings.Contains(msg, "Subject: =?utf-8?q?") {
+		t.Errorf("Subject is not encoded:\n%s", msg)
+	}
+}
+
+func TestNotifiers(t *testing.T) {
+	a, b := &recorder{}, &recorder{}
+	failing := &recorder{err: errTest}
+	err := (Notifiers{a, failing, b}).Notify(testEvent())
+	var ne *NotifyError
+	if !errors.As(err, &ne) {
+		t.Fatalf("Unexpected error, got %v want *NotifyError", err)
+	}
+	if ne.Delivered != 2 || len(ne.Failed) != 1 || ne.Failed[0] != failing || !errors.Is(err, errTest) {
+		t.Errorf("Unexpected error: %+v", ne)
+	}
+	if len(a.events) != 1 || len(b.events) != 1 {
+		t.Errorf("Event was not delivered to all notifiers")
+	}
+	if err := (Notifiers{a, b}).Notify(testEvent()); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+var errTest = errors.New("test error")
@@ -0,0 +1,298 @@
+// Package watchlist tracks wanted movies and sends notifications when they,
+// or better quality torrents of them, become available on YTS.LT.
+package watchlist
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/qopher/ytsgo"
+	"github.com/qopher/ytsgo/internal/fsutil"
+)
+
+// Client queries YTS.LT. It is implemented by *ytsgo.Client.
+type Client interface {
+	ListMovies(opts ...ytsgo.ListMoviesOption) (*ytsgo.Movies, error)
+	Movie(id int, opts ...ytsgo.MovieOption) (*ytsgo.Movie, error)
+}
+
+// Item is a single wanted movie.
+type Item struct {
+	IMDBCode string `json:"imdb_code"`
+	Title    string `json:"title,omitempty"`
+	// Quality is the lowest wanted quality, eg. 1080p. Any quality is wanted if empty.
+	Quality string `json:"quality,omitempty"`
+	// MovieID is the YTS ID of the movie, set once the movie is found.
+	MovieID uint `json:"movie_id,omitempty"`
+	// Notified is the best quality notified so far.
+	Notified string `json:"notified,omitempty"`
+}
+
+// wants reports whether the torrent satisfies the wanted quality.
+func (i *Item) wants(t *ytsgo.Torrent) bool {
+	if i.Quality == "" {
+		return true
+	}
+	if strings.EqualFold(i.Quality, t.Quality) {
+		return true
+	}
+	r := ytsgo.QualityRank(i.Quality)
+	return r > 0 && ytsgo.QualityRank(t.Quality) >= r
+}
+
+// EventKind tells why an Event was sent.
+type EventKind string
+
+const (
+	// Available means that the wanted movie was found in wanted quality.
+	Available EventKind = "available"
+	// BetterQuality means that a torrent better than the previously notified one appeared.
+	BetterQuality EventKind = "better_quality"
+)
+
+// Event is sent to Notifiers.
+type Event struct {
+	Kind    EventKind
+	Item    *Item
+	Movie   *ytsgo.Movie
+	Torrent *ytsgo.Torrent
+}
+
+// String returns a human readable description of the event.
+func (e *Event) String() string {
+	what := "is available"
+	if e.Kind == BetterQuality {
+		what = "is available in better quality"
+	}
+	return fmt.Sprintf("%s (%d) [%s] %s: %s", e.Movie.Title, e.Movie.Year, e.Item.IMDBCode, what, e.Torrent.Quality)
+}
+
+// Watchlist is a list of wanted movies. It is safe for concurrent use.
+type Watchlist struct {
+	mu    sync.Mutex
+	items []*Item
+	// retries are events delivered by some of the notifiers only.
+	retries []retry
+}
+
+// retry is an event to deliver again to the notifiers which failed.
+type retry struct {
+	e *Event
+	n Notifier
+}
+
+// Add adds the item unless an item with the same IMDb code is already on the list.
+func (w *Watchlist) Add(it *Item) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, i := range w.items {
+		if strings.EqualFold(i.IMDBCode, it.IMDBCode) {
+			return false
+		}
+	}
+	w.items = append(w.items, it)
+	return true
+}
+
+// Remove removes the item with given IMDb code.
+func (w *Watchlist) Remove(imdbCode string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for i, it := range w.items {
+		if strings.EqualFold(it.IMDBCode, imdbCode) {
+			w.items = append(w.items[:i], w.items[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Items returns a copy of all items.
+func (w *Watchlist) Items() []Item {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	ret := make([]Item, 0, len(w.items))
+	for _, it := range w.items {
+		ret = append(ret, *it)
+	}
+	return ret
+}
+
+// Check queries c for every item and notifies n about new movies and better
+// torrents. Items are updated so that every event is sent only once. Errors
+// of single items do not stop the check, the first one is returned.
+//
+// If n is Notifiers and only some of them fail, the item is still marked as
+// notified and the event is delivered again to the failed notifiers only on
+// the following checks of this Watchlist.
+func (w *Watchlist) Check(c Client, n Notifier) ([]*Event, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var events []*Event
+	var firstErr error
+	report := func(it *Item, err error) {
+		if firstErr == nil {
+			firstErr = fmt.Errorf("failed to check %s: %w", it.IMDBCode, err)
+		}
+	}
+	retries := w.retries
+	w.retries = nil
+	for _, r := range retries {
+		if err := r.n.Notify(r.e); err != nil {
+			var ne *NotifyError
+			if errors.As(err, &ne) {
+				r.n = ne.Failed
+			}
+			w.retries = append(w.retries, r)
+			report(r.e.Item, err)
+		}
+	}
+	for _, it := range w.items {
+		e, err := check(c, it)
+		if err == nil && e != nil {
+			err = n.Notify(e)
+			var ne *NotifyError
+			if errors.As(err, &ne) && ne.Delivered > 0 {
+				w.retries = append(w.retries, retry{e: e, n: ne.Failed})
+			}
+			if err == nil || ne != nil && ne.Delivered > 0 {
+				it.Notified = e.Torrent.Quality
+				events = append(events, e)
+			}
+		}
+		if err != nil {
+			report(it, err)
+		}
+	}
+	return events, firstErr
+}
+
+// check returns an event for the item or nil if there is nothing new.
+func check(c Client, it *Item) (*Event, error) {
+	if it.MovieID == 0 {
+		mvs, err := c.ListMovies(ytsgo.LMSearch(it.IMDBCode))
+		if err != nil {
+			return nil, err
+		}
+		if mvs == nil {
+			return nil, nil
+		}
+		for _, m := range mvs.Movies {
+			if m != nil && strings.EqualFold(m.IMDBCode, it.IMDBCode) {
+				it.MovieID = m.ID
+				if it.Title == "" {
+					it.Title = m.Title
+				}
+				break
+			}
+		}
+		if it.MovieID == 0 {
+			return nil, nil
+		}
+	}
+	m, err := c.Movie(int(it.MovieID))
+	if err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return nil, nil
+	}
+	var wanted []*ytsgo.Torrent
+	for _, t := range m.Torrents {
+		if t != nil && it.wants(t) {
+			wanted = append(wanted, t)
+		}
+	}
+	best := ytsgo.BestTorrent(wanted)
+	if best == nil {
+		return nil, nil
+	}
+	e := &Event{
+		Kind:    Available,
+		Item:    it,
+		Movie:   m,
+		Torrent: best,
+	}
+	if it.Notified == "" {
+		return e, nil
+	}
+	if ytsgo.QualityRank(best.Quality) > ytsgo.QualityRank(it.Notified) {
+		e.Kind = BetterQuality
+		return e, nil
+	}
+	return nil, nil
+}
+
+// Run checks the watchlist every interval until ctx is done. After every
+// check with events save is called, eg. to persist the list with SaveFile.
+// Errors are passed to onErr which may be nil.
+func (w *Watchlist) Run(ctx context.Context, c Client, n Notifier, interval time.Duration, save func(*Watchlist) error, onErr func(error)) error {
+	report := func(err error) {
+		if err != nil && onErr != nil {
+			onErr(err)
+		}
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		events, err := w.Check(c, n)
+		report(err)
+		if len(events) > 0 && save != nil {
+			report(save(w))
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// MarshalJSON encodes the list of items.
+func (w *Watchlist) MarshalJSON() ([]byte, error) {
+	return json.Marshal(w.Items())
+}
+
+// UnmarshalJSON decodes the list of items.
+func (w *Watchlist) UnmarshalJSON(data []byte) error {
+	var items []*Item
+	if err := json.Unmarshal(data, &items); err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.items = items
+	return nil
+}
+
+// SaveFile writes the watchlist as JSON. The file is replaced atomically.
+func (w *Watchlist) SaveFile(path string) error {
+	data, err := json.MarshalIndent(w, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fsutil.WriteFile(path, data)
+}
+
+// LoadFile reads the watchlist written by SaveFile. A missing file results in an empty watchlist.
+func LoadFile(path string) (*Watchlist, error) {
+	w := &Watchlist{}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return w, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, w); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
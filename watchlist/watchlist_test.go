@@ -0,0 +1,240 @@
+package watchlist
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qopher/ytsgo"
+)
+
+type fakeClient struct {
+	movies  map[string]*ytsgo.Movie
+	err     error
+	queries []string
+}
+
+func (f *fakeClient) ListMovies(opts ...ytsgo.ListMoviesOption) (*ytsgo.Movies, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	v := url.Values{}
+	for _, o := range opts {
+		o(v)
+	}
+	q := v.Get("query_term")
+	f.queries = append(f.queries, q)
+	ret := &ytsgo.Movies{}
+	if m, ok := f.movies[q]; ok {
+		ret.Movies = append(ret.Movies, m)
+	}
+	return ret, nil
+}
+
+func (f *fakeClient) Movie(id int, opts ...ytsgo.MovieOption) (*ytsgo.Movie, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	for _, m := range f.movies {
+		if m != nil && m.ID == uint(id) {
+			return m, nil
+		}
+	}
+	return nil, nil
+}
+
+type recorder struct {
+	events []string
+	err    error
+}
+
+func (r *recorder) Notify(e *Event) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.events = append(r.events, string(e.Kind)+" "+e.Item.IMDBCode+" "+e.Torrent.Quality)
+	return nil
+}
+
+func TestCheck(t *testing.T) {
+	matrix := &ytsgo.Movie{
+		ID:       3525,
+		IMDBCode: "tt0133093",
+		Title:    "The Matrix",
+		Year:     1999,
+		Torrents: []*ytsgo.Torrent{{Quality: "720p", Hash: "A"}},
+	}
+	c := &fakeClient{movies: map[string]*ytsgo.Movie{"tt0133093": matrix}}
+	w := &Watchlist{}
+	w.Add(&Item{IMDBCode: "tt0133093"})
+	w.Add(&Item{IMDBCode: "tt0133093", Quality: "2160p"})
+	w.Add(&Item{IMDBCode: "tt9999999", Title: "Not yet"})
+	r := &recorder{}
+
+	steps := []struct {
+		desc     string
+		update   func()
+		want     []string
+		wantItem Item
+	}{
+		{
+			desc:     "first available",
+			want:     []string{"available tt0133093 720p"},
+			wantItem: Item{IMDBCode: "tt0133093", Title: "The Matrix", MovieID: 3525, Notified: "720p"},
+		},
+		{
+			desc:     "nothing new",
+			wantItem: Item{IMDBCode: "tt0133093", Title: "The Matrix", MovieID: 3525, Notified: "720p"},
+		},
+		{
+			desc: "better quality",
+			update: func() {
+				matrix.Torrents = append(matrix.Torrents, &ytsgo.Torrent{Quality: "1080p", Hash: "B"}, &ytsgo.Torrent{Quality: "3D", Hash: "C"})
+			},
+			want:     []string{"better_quality tt0133093 1080p"},
+			wantItem: Item{IMDBCode: "tt0133093", Title: "The Matrix", MovieID: 3525, Notified: "1080p"},
+		},
+	}
+	for _, s := range steps {
+		t.Run(s.desc, func(t *testing.T) {
+			if s.update != nil {
+				s.update()
+			}
+			r.events = nil
+			if _, err := w.Check(c, r); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(s.want, r.events); diff != "" {
+				t.Errorf("Unexpected events, diff -want +got\n%s", diff)
+			}
+			if diff := cmp.Diff(s.wantItem, w.Items()[0]); diff != "" {
+				t.Errorf("Unexpected item, diff -want +got\n%s", diff)
+			}
+		})
+	}
+	if w.Add(&Item{IMDBCode: "TT0133093"}) {
+		t.Error("Duplicate item was added")
+	}
+}
+
+func TestCheckWantedQuality(t *testing.T) {
+	movie := &ytsgo.Movie{
+		ID:       1,
+		IMDBCode: "tt1",
+		Torrents: []*ytsgo.Torrent{{Quality: "1080p"}},
+	}
+	c := &fakeClient{movies: map[string]*ytsgo.Movie{"tt1": movie}}
+	w := &Watchlist{}
+	w.Add(&Item{IMDBCode: "tt1", Quality: "2160p"})
+	r := &recorder{}
+	if _, err := w.Check(c, r); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(r.events) != 0 {
+		t.Errorf("Unexpected events: %v", r.events)
+	}
+	movie.Torrents = append(movie.Torrents, &ytsgo.Torrent{Quality: "2160p"})
+	if _, err := w.Check(c, r); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]string{"available tt1 2160p"}, r.events); diff != "" {
+		t.Errorf("Unexpected events, diff -want +got\n%s", diff)
+	}
+}
+
+func TestCheckErrors(t *testing.T) {
+	c := &fakeClient{err: errors.New("some error")}
+	w := &Watchlist{}
+	w.Add(&Item{IMDBCode: "tt1"})
+	if _, err := w.Check(c, &recorder{}); err == nil {
+		t.Error("Expected client error")
+	}
+	c = &fakeClient{movies: map[string]*ytsgo.Movie{"tt1": {ID: 1, IMDBCode: "tt1", Torrents: []*ytsgo.Torrent{{Quality: "720p"}}}}}
+	if _, err := w.Check(c, &recorder{err: errors.New("some error")}); err == nil {
+		t.Error("Expected notifier error")
+	}
+	if got := w.Items()[0].Notified; got != "" {
+		t.Errorf("Item should not be marked as notified after failed notification, got %q", got)
+	}
+}
+
+func TestCheckNilMovie(t *testing.T) {
+	c := &fakeClient{movies: map[string]*ytsgo.Movie{"tt1": nil}}
+	w := &Watchlist{}
+	w.Add(&Item{IMDBCode: "tt1"})
+	events, err := w.Check(c, &recorder{})
+	if err != nil || len(events) != 0 {
+		t.Errorf("Unexpected result, got %v, %v want no events", events, err)
+	}
+}
+
+func TestCheckPartialDelivery(t *testing.T) {
+	c := &fakeClient{movies: map[string]*ytsgo.Movie{"tt1": {ID: 1, IMDBCode: "tt1", Torrents: []*ytsgo.Torrent{{Quality: "720p"}}}}}
+	w := &Watchlist{}
+	w.Add(&Item{IMDBCode: "tt1"})
+	ok, failing := &recorder{}, &recorder{err: errors.New("some error")}
+	n := Notifiers{ok, failing}
+	events, err := w.Check(c, n)
+	if err == nil {
+		t.Error("Expected notifier error")
+	}
+	if len(events) != 1 {
+		t.Errorf("Unexpected events: %v", events)
+	}
+	if got := w.Items()[0].Notified; got != "720p" {
+		t.Errorf("Item should be marked as notified after partial delivery, got %q", got)
+	}
+	// The failing notifier is retried until it succeeds.
+	if _, err := w.Check(c, n); err == nil {
+		t.Error("Expected notifier error on retry")
+	}
+	failing.err = nil
+	if _, err := w.Check(c, n); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := w.Check(c, n); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := []string{"available tt1 720p"}
+	if diff := cmp.Diff(want, ok.events); diff != "" {
+		t.Errorf("Unexpected events of working notifier, diff -want +got\n%s", diff)
+	}
+	if diff := cmp.Diff(want, failing.events); diff != "" {
+		t.Errorf("Unexpected events of failing notifier, diff -want +got\n%s", diff)
+	}
+}
+
+func TestRun(t *testing.T) {
+	c := &fakeClient{movies: map[string]*ytsgo.Movie{"tt1": {ID: 1, IMDBCode: "tt1", Torrents: []*ytsgo.Torrent{{Quality: "720p"}}}}}
+	w := &Watchlist{}
+	w.Add(&Item{IMDBCode: "tt1"})
+	path := filepath.Join(t.TempDir(), "watchlist.json")
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &recorder{}
+	saves := 0
+	save := func(w *Watchlist) error {
+		saves++
+		cancel()
+		return w.SaveFile(path)
+	}
+	if err := w.Run(ctx, c, r, time.Millisecond, save, nil); err != context.Canceled {
+		t.Errorf("Unexpected error, got %v want %v", err, context.Canceled)
+	}
+	if saves != 1 || len(r.events) != 1 {
+		t.Errorf("Unexpected number of saves %d and events %d, want 1", saves, len(r.events))
+	}
+	got, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(w.Items(), got.Items()); diff != "" {
+		t.Errorf("Unexpected loaded items, diff -want +got\n%s", diff)
+	}
+	if !got.Remove("tt1") || len(got.Items()) != 0 {
+		t.Errorf("Failed to remove item")
+	}
+}
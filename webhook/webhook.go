@@ -0,0 +1,123 @@
+// Package webhook delivers JSON payloads to HTTP endpoints with retries and HMAC signatures.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// SignatureHeader contains the HMAC-SHA256 signature of the body in format "sha256=<hex>".
+	SignatureHeader = "X-Ytsgo-Signature"
+	// DefaultRetries is a default number of retries after a failed delivery.
+	DefaultRetries = 3
+	// DefaultBackoff is a default delay before the first retry. It doubles with every retry.
+	DefaultBackoff = time.Second
+)
+
+// Sign returns the signature of the body sent in SignatureHeader.
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether sig is a valid signature of the body.
+func Verify(secret, body []byte, sig string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(sig))
+}
+
+// Sender POSTs JSON payloads to the URL.
+type Sender struct {
+	URL string
+	// Secret is used to sign payloads. Payloads are not signed if empty.
+	Secret []byte
+	// Retries is the number of retries after a failed delivery, eg.
+	// DefaultRetries. Failed deliveries are not retried if zero or negative.
+	Retries int
+	// Backoff overrides DefaultBackoff if positive.
+	Backoff time.Duration
+	// Client is used to send requests, http.DefaultClient if nil.
+	Client *http.Client
+}
+
+// StatusError is returned when the endpoint responds with a non 2xx code.
+type StatusError struct {
+	Code   int
+	Status string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("webhook returned code %v: %s", e.Code, e.Status)
+}
+
+// temporary reports whether the request may succeed when retried.
+func (e *StatusError) temporary() bool {
+	return e.Code >= 500 || e.Code == http.StatusTooManyRequests || e.Code == http.StatusRequestTimeout
+}
+
+// Send encodes payload as JSON and delivers it, retrying network errors and
+// 5xx responses with exponential backoff.
+func (s *Sender) Send(ctx context.Context, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	backoff := s.Backoff
+	if backoff <= 0 {
+		backoff = DefaultBackoff
+	}
+	for attempt := 0; ; attempt++ {
+		err = s.post(ctx, body)
+		if err == nil {
+			return nil
+		}
+		if se, ok := err.(*StatusError); ok && !se.temporary() {
+			return err
+		}
+		if attempt >= s.Retries {
+			return fmt.Errorf("delivery to %s failed after %d attempts: %w", s.URL, attempt+1, err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff << uint(attempt)):
+		}
+	}
+}
+
+func (s *Sender) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequest("POST", s.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	if len(s.Secret) > 0 {
+		req.Header.Set(SignatureHeader, Sign(s.Secret, body))
+	}
+	c := s.Client
+	if c == nil {
+		c = http.DefaultClient
+	}
+	rsp, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+	io.Copy(ioutil.Discard, io.LimitReader(rsp.Body, 64<<10))
+	if rsp.StatusCode < 200 || rsp.StatusCode > 299 {
+		return &StatusError{Code: rsp.StatusCode, Status: strings.TrimSpace(rsp.Status)}
+	}
+	return nil
+}
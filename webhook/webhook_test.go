@@ -0,0 +1,125 @@
+package webhook
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSignVerify(t *testing.T) {
+	secret, body := []byte("secret"), []byte(`{"a":1}`)
+	sig := Sign(secret, body)
+	if got, want := sig, "sha256=aa9e2e3575f5d7098b6caccd790888c36d5fdb63342a73bada2d6a51747a8494"; got != want {
+		t.Errorf("Unexpected signature, got %q want %q", got, want)
+	}
+	if !Verify(secret, body, sig) {
+		t.Error("Valid signature was rejected")
+	}
+	if Verify([]byte("other"), body, sig) || Verify(secret, []byte(`{"a":2}`), sig) {
+		t.Error("Invalid signature was accepted")
+	}
+}
+
+func TestSend(t *testing.T) {
+	testData := []struct {
+		desc      string
+		codes     []int
+		retries   int
+		secret    string
+		wantCalls int
+		wantErr   bool
+	}{
+		{
+			desc:      "success",
+			codes:     []int{http.StatusOK},
+			secret:    "secret",
+			wantCalls: 1,
+		},
+		{
+			desc:      "retried",
+			codes:     []int{http.StatusBadGateway, http.StatusTooManyRequests, http.StatusNoContent},
+			retries:   DefaultRetries,
+			wantCalls: 3,
+		},
+		{
+			desc:      "retries exhausted",
+			codes:     []int{http.StatusInternalServerError},
+			retries:   2,
+			wantCalls: 3,
+			wantErr:   true,
+		},
+		{
+			desc:      "permanent error",
+			codes:     []int{http.StatusBadRequest},
+			wantCalls: 1,
+			wantErr:   true,
+		},
+		{
+			desc:      "zero retries",
+			codes:     []int{http.StatusServiceUnavailable},
+			wantCalls: 1,
+			wantErr:   true,
+		},
+		{
+			desc:      "negative retries",
+			codes:     []int{http.StatusServiceUnavailable},
+			retries:   -1,
+			wantCalls: 1,
+			wantErr:   true,
+		},
+	}
+	for _, tc := range testData {
+		t.Run(tc.desc, func(t *testing.T) {
+			calls := 0
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := ioutil.ReadAll(r.Body)
+				sig := r.Header.Get(SignatureHeader)
+				if tc.secret == "" && sig != "" {
+					t.Errorf("Unexpected signature %q", sig)
+				}
+				if tc.secret != "" && !Verify([]byte(tc.secret), body, sig) {
+					t.Errorf("Invalid signature %q", sig)
+				}
+				if got, want := string(body), `{"title":"The Matrix"}`; got != want {
+					t.Errorf("Unexpected body, got %s want %s", got, want)
+				}
+				code := tc.codes[len(tc.codes)-1]
+				if calls < len(tc.codes) {
+					code = tc.codes[calls]
+				}
+				calls++
+				w.WriteHeader(code)
+			}))
+			defer ts.Close()
+			s := &Sender{
+				URL:     ts.URL,
+				Secret:  []byte(tc.secret),
+				Retries: tc.retries,
+				Backoff: time.Millisecond,
+			}
+			err := s.Send(context.Background(), map[string]string{"title": "The Matrix"})
+			if (err != nil) != tc.wantErr {
+				t.Errorf("Unexpected error, got %v want %v", err, tc.wantErr)
+			}
+			if calls != tc.wantCalls {
+				t.Errorf("Unexpected number of calls, got %d want %d", calls, tc.wantCalls)
+			}
+		})
+	}
+}
+
+func TestSendCanceled(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s := &Sender{URL: ts.URL, Retries: DefaultRetries, Backoff: time.Hour}
+	if err := s.Send(ctx, nil); err == nil {
+		t.Error("Expected an error for canceled context")
+	}
+}
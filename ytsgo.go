@@ -4,9 +4,12 @@ package ytsgo
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -19,7 +22,9 @@ const (
 var (
 	// DefaultTimeout is a default timeout used for queries.
 	DefaultTimeout = time.Second * 10
-	urls           = map[string]string{
+	// DefaultMaxResponseSize is a default limit of the response body size in bytes.
+	DefaultMaxResponseSize int64 = 10 << 20
+	urls                         = map[string]string{
 		"movieURL":       "movie_details.json",
 		"listMoviesURL":  "list_movies.json",
 		"suggestionsURL": "movie_suggestions.json",
@@ -50,13 +55,84 @@ func UserAgent(ua string) ClientOption {
 	}
 }
 
+// TraceWith makes the Client create a span using t for every API call.
+// By default calls are not traced.
+func TraceWith(t Tracer) ClientOption {
+	return func(c *Client) {
+		c.tracer = t
+	}
+}
+
+// CoalesceRequests if true makes concurrent identical calls share a single
+// HTTP request. Calls are identical if they resolve to the same URL.
+func CoalesceRequests(b bool) ClientOption {
+	return func(c *Client) {
+		if b {
+			c.flights = &flightGroup{}
+		} else {
+			c.flights = nil
+		}
+	}
+}
+
+// Revalidate if true makes the Client remember ETag and Last-Modified headers
+// of responses and send conditional requests for the same URLs. If the server
+// replies with 304 Not Modified the previously received response is used.
+// Responses of up to 256 most recently used URLs are kept.
+func Revalidate(b bool) ClientOption {
+	return func(c *Client) {
+		if b {
+			c.validators = &validatorStore{}
+		} else {
+			c.validators = nil
+		}
+	}
+}
+
+// MaxResponseSize overrides DefaultMaxResponseSize. Responses larger than n
+// bytes fail with ErrResponseTooLarge. If n is not positive the size is not limited.
+func MaxResponseSize(n int64) ClientOption {
+	return func(c *Client) {
+		c.maxResponseSize = n
+	}
+}
+
+// StrictContentType if true (default) makes the Client reject responses
+// which are not JSON with a *ContentTypeError.
+func StrictContentType(b bool) ClientOption {
+	return func(c *Client) {
+		c.strictContentType = b
+	}
+}
+
+// LenientDecoding if true makes the Client accept movies with inconsistent
+// field types, eg. numbers sent as strings, null lists or empty image URLs.
+// Coerced values are listed in Movie.Warnings. By default such movies fail to decode.
+func LenientDecoding(b bool) ClientOption {
+	return func(c *Client) {
+		c.lenient = b
+	}
+}
+
 // Client implements yts.lt API client.
 type Client struct {
 	baseURLStr string
-	baseURL    *url.URL
+	mirrorStrs []string
+	mirrors    *mirrorSet
 	userAgent  string
 	httpClient *http.Client
 	urls       map[string]*url.URL
+	tracer     Tracer
+	flights    *flightGroup
+	validators *validatorStore
+
+	maxResponseSize   int64
+	strictContentType bool
+	lenient           bool
+
+	imageCacheDir    string
+	imageConcurrency int
+	imageSem         chan struct{}
 }
 
 // New creates a new Client.
@@ -66,16 +142,25 @@ func New(opts ...ClientOption) (*Client, error) {
 		httpClient: &http.Client{
 			Timeout: DefaultTimeout,
 		},
-		urls: make(map[string]*url.URL),
+		urls:              make(map[string]*url.URL),
+		maxResponseSize:   DefaultMaxResponseSize,
+		strictContentType: true,
+		imageConcurrency:  DefaultImageConcurrency,
 	}
 	for _, o := range opts {
 		o(c)
 	}
-	var err error
-	c.baseURL, err = url.Parse(c.baseURLStr)
+	baseURL, err := url.Parse(c.baseURLStr)
 	if err != nil {
 		return nil, err
 	}
+	if c.mirrors, err = newMirrorSet(baseURL, c.mirrorStrs); err != nil {
+		return nil, err
+	}
+	if c.imageConcurrency < 1 {
+		c.imageConcurrency = 1
+	}
+	c.imageSem = make(chan struct{}, c.imageConcurrency)
 	for k, u := range urls {
 		ur, err := url.Parse(u)
 		if err != nil {
@@ -103,34 +188,69 @@ func MovieWithCast(b bool) MovieOption {
 	}
 }
 
+// ErrNotFound is returned if a movie looked up by IMDb code or slug does not
+// exist.
+var ErrNotFound = errors.New("movie not found")
+
 // Movie returns movie details based on provided ID and options.
 func (c *Client) Movie(id int, opts ...MovieOption) (*Movie, error) {
-	u := c.baseURL.ResolveReference(c.urls["movieURL"])
-	params := u.Query()
+	params := url.Values{}
 	params.Set("movie_id", fmt.Sprintf("%v", id))
-	for _, o := range opts {
-		o(params)
-	}
-	req, err := c.newRequest(u, params)
+	return c.movieDetails("Movie", params, opts)
+}
+
+// MovieByIMDBCode returns movie details based on IMDb code, eg. tt0133093.
+func (c *Client) MovieByIMDBCode(code string, opts ...MovieOption) (*Movie, error) {
+	params := url.Values{}
+	params.Set("imdb_id", code)
+	m, err := c.movieDetails("MovieByIMDBCode", params, opts)
 	if err != nil {
 		return nil, err
 	}
-	rsp, err := c.httpClient.Do(req)
+	// Unknown codes are answered with an empty movie.
+	if m == nil || m.ID == 0 {
+		return nil, ErrNotFound
+	}
+	return m, nil
+}
+
+// MovieBySlug returns movie details based on the slug, eg. the-matrix-1999.
+// The API has no slug lookup, so the movie is searched by the words of the
+// slug first.
+func (c *Client) MovieBySlug(slug string, opts ...MovieOption) (*Movie, error) {
+	words := strings.Split(slug, "-")
+	if n := len(words); n > 1 {
+		if _, err := strconv.Atoi(words[n-1]); err == nil {
+			words = words[:n-1]
+		}
+	}
+	mvs, err := c.ListMovies(LMSearch(strings.Join(words, " ")), LMLimit(50))
 	if err != nil {
 		return nil, err
 	}
-	defer rsp.Body.Close()
-	if rsp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("server returned code %v: %s", rsp.StatusCode, rsp.Status)
+	if mvs == nil {
+		return nil, ErrNotFound
+	}
+	for _, m := range mvs.Movies {
+		if m != nil && m.Slug == slug {
+			if len(opts) == 0 {
+				return m, nil
+			}
+			return c.Movie(int(m.ID), opts...)
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (c *Client) movieDetails(op string, params url.Values, opts []MovieOption) (*Movie, error) {
+	for _, o := range opts {
+		o(params)
 	}
 	var data movieDetailsResponse
-	if err := json.NewDecoder(rsp.Body).Decode(&data); err != nil {
+	if err := c.get(op, "movieURL", params, &data); err != nil {
 		return nil, err
 	}
-	if data.status.Status != statusOK {
-		return nil, fmt.Errorf("api returned incorrect status %s: %s", data.status.Status, data.status.StatusMessage)
-	}
-	return data.Data.Movie, nil
+	return c.decodeMovie(data.Data.Movie)
 }
 
 // ListMoviesOption configures behavior of ListMovies. Limits, pages, quality and more can be set.
@@ -198,6 +318,75 @@ func LMOrderBy(s string) ListMoviesOption {
 	}
 }
 
+// LMWithRTRatings includes Rotten Tomatoes ratings in the results.
+func LMWithRTRatings(b bool) ListMoviesOption {
+	return func(v url.Values) {
+		v.Set("with_rt_ratings", strconv.FormatBool(b))
+	}
+}
+
+// SortByValues lists values accepted by LMSortBy.
+var SortByValues = []string{"title", "year", "rating", "peers", "seeds", "download_count", "like_count", "date_added"}
+
+// OrderByValues lists values accepted by LMOrderBy.
+var OrderByValues = []string{"desc", "asc"}
+
+// Genres lists IMDb genres accepted by LMGenre.
+var Genres = []string{
+	"Action", "Adventure", "Animation", "Biography", "Comedy", "Crime",
+	"Documentary", "Drama", "Family", "Fantasy", "Film-Noir", "Game-Show",
+	"History", "Horror", "Music", "Musical", "Mystery", "News", "Reality-TV",
+	"Romance", "Sci-Fi", "Sport", "Talk-Show", "Thriller", "War", "Western",
+}
+
+// ListMoviesQuery converts list_movies.json query parameters, eg. received by
+// an HTTP handler, to ListMoviesOptions. Unknown parameters are ignored.
+func ListMoviesQuery(v url.Values) ([]ListMoviesOption, error) {
+	var opts []ListMoviesOption
+	uints := []struct {
+		name string
+		opt  func(uint) ListMoviesOption
+	}{
+		{name: "limit", opt: LMLimit},
+		{name: "page", opt: LMPage},
+		{name: "minimum_rating", opt: LMMinimumRating},
+	}
+	for _, u := range uints {
+		s := v.Get(u.name)
+		if s == "" {
+			continue
+		}
+		n, err := strconv.ParseUint(s, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %v", u.name, s, err)
+		}
+		opts = append(opts, u.opt(uint(n)))
+	}
+	if s := v.Get("with_rt_ratings"); s != "" {
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid with_rt_ratings %q: %v", s, err)
+		}
+		opts = append(opts, LMWithRTRatings(b))
+	}
+	strs := []struct {
+		name string
+		opt  func(string) ListMoviesOption
+	}{
+		{name: "quality", opt: LMQuality},
+		{name: "query_term", opt: LMSearch},
+		{name: "genre", opt: LMGenre},
+		{name: "sort_by", opt: LMSortBy},
+		{name: "order_by", opt: LMOrderBy},
+	}
+	for _, s := range strs {
+		if val := v.Get(s.name); val != "" {
+			opts = append(opts, s.opt(val))
+		}
+	}
+	return opts, nil
+}
+
 // Movies contain data returned by ListMovies and MovieSuggestions.
 type Movies struct {
 	// MovieCount is a total movie count results for your query.
@@ -211,58 +400,165 @@ type Movies struct {
 
 // ListMovies is used to list and search through out all the available movies. Can sort, filter, search and order the results.
 func (c *Client) ListMovies(opts ...ListMoviesOption) (*Movies, error) {
-	u := c.baseURL.ResolveReference(c.urls["listMoviesURL"])
-	params := u.Query()
+	params := url.Values{}
 	for _, o := range opts {
 		o(params)
 	}
-	req, err := c.newRequest(u, params)
-	if err != nil {
-		return nil, err
-	}
-	rsp, err := c.httpClient.Do(req)
-	if err != nil {
+	var data listMoviesResponse
+	if err := c.get("ListMovies", "listMoviesURL", params, &data); err != nil {
 		return nil, err
 	}
-	defer rsp.Body.Close()
-	if rsp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("server returned code %v: %s", rsp.StatusCode, rsp.Status)
+	if data.Data == nil {
+		return nil, nil
 	}
-	var data listMoviesResponse
-	if err := json.NewDecoder(rsp.Body).Decode(&data); err != nil {
+	movies, err := c.decodeMovies(data.Data.Movies)
+	if err != nil {
 		return nil, err
 	}
-	if data.status.Status != statusOK {
-		return nil, fmt.Errorf("api returned incorrect status %s: %s", data.status.Status, data.status.StatusMessage)
-	}
-	return data.Data, nil
+	return &Movies{
+		MovieCount: data.Data.MovieCount,
+		Page:       data.Data.Page,
+		Limit:      data.Data.Limit,
+		Movies:     movies,
+	}, nil
 }
 
 // Suggestions returns 4 related movies as suggestions for the user.
 func (c *Client) Suggestions(id int) ([]*Movie, error) {
-	u := c.baseURL.ResolveReference(c.urls["suggestionsURL"])
-	params := u.Query()
+	params := url.Values{}
 	params.Set("movie_id", fmt.Sprintf("%v", id))
+	var data suggestionsResponse
+	if err := c.get("Suggestions", "suggestionsURL", params, &data); err != nil {
+		return nil, err
+	}
+	return c.decodeMovies(data.Data.Movies)
+}
+
+// get queries the endpoint with params and decodes the response into data.
+// op names the Client method for tracing purposes.
+func (c *Client) get(op, endpoint string, params url.Values, data response) (err error) {
+	span := c.startSpan(op, urls[endpoint], params)
+	defer func() { span.End(err) }()
+	var res *result
+	order := c.mirrors.order()
+	for n, i := range order {
+		u := c.mirrors.urls[i].ResolveReference(c.urls[endpoint])
+		res, err = c.do(u, params, span)
+		if n == len(order)-1 || !failover(res, err) {
+			if n > 0 && !failover(res, err) {
+				c.mirrors.use(i)
+			}
+			break
+		}
+	}
+	if res != nil {
+		span.SetAttribute("status", res.code)
+	}
+	if err != nil {
+		return err
+	}
+	if res.code != http.StatusOK && !res.cached {
+		return &StatusError{StatusCode: res.code, Status: res.status}
+	}
+	if err := decodeJSON(res.body, data); err != nil {
+		return err
+	}
+	return data.apiStatus().err()
+}
+
+// do sends a single request for u with params.
+func (c *Client) do(u *url.URL, params url.Values, span Span) (*result, error) {
 	req, err := c.newRequest(u, params)
 	if err != nil {
 		return nil, err
 	}
+	span.Inject(req.Header)
+	if c.flights != nil {
+		return c.flights.do(req.URL.String(), func() (*result, error) {
+			return c.fetch(req)
+		})
+	}
+	return c.fetch(req)
+}
+
+// decodeMovie decodes a single movie using the decoding mode of the Client.
+func (c *Client) decodeMovie(raw json.RawMessage) (*Movie, error) {
+	if len(raw) == 0 || isNull(raw) {
+		return nil, nil
+	}
+	m := &Movie{}
+	if err := m.unmarshal(raw, &decoder{lenient: c.lenient}); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// decodeMovies decodes a list of movies using the decoding mode of the Client.
+func (c *Client) decodeMovies(raws []json.RawMessage) ([]*Movie, error) {
+	if raws == nil {
+		return nil, nil
+	}
+	movies := make([]*Movie, 0, len(raws))
+	for _, raw := range raws {
+		m, err := c.decodeMovie(raw)
+		if err != nil {
+			return nil, err
+		}
+		if m == nil && c.lenient {
+			continue
+		}
+		movies = append(movies, m)
+	}
+	return movies, nil
+}
+
+// result is a raw HTTP response read by fetch.
+type result struct {
+	code   int
+	status string
+	body   []byte
+	// cached is true if body comes from a stored response revalidated by the server.
+	cached bool
+}
+
+// fetch executes req and reads the whole response.
+func (c *Client) fetch(req *http.Request) (*result, error) {
+	if c.validators != nil {
+		c.validators.prepare(req)
+	}
 	rsp, err := c.httpClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer rsp.Body.Close()
+	res := &result{
+		code:   rsp.StatusCode,
+		status: rsp.Status,
+	}
+	if rsp.StatusCode == http.StatusNotModified && c.validators != nil {
+		if body, ok := c.validators.notModified(req); ok {
+			res.body = body
+			res.cached = true
+			return res, nil
+		}
+	}
+	if c.strictContentType {
+		// Error pages are reported by their status code unless they are challenges.
+		if e := checkContentType(rsp); e != nil && (e.Challenge || rsp.StatusCode == http.StatusOK) {
+			return res, e
+		}
+	}
 	if rsp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("server returned code %v: %s", rsp.StatusCode, rsp.Status)
+		return res, nil
 	}
-	var data suggestionsResponse
-	if err := json.NewDecoder(rsp.Body).Decode(&data); err != nil {
-		return nil, err
+	res.body, err = readBody(rsp, c.maxResponseSize)
+	if err != nil {
+		return res, err
 	}
-	if data.status.Status != statusOK {
-		return nil, fmt.Errorf("api returned incorrect status %s: %s", data.status.Status, data.status.StatusMessage)
+	if c.validators != nil {
+		c.validators.store(req, rsp, res.body)
 	}
-	return data.Data.Movies, nil
+	return res, nil
 }
 
 func (c *Client) newRequest(u *url.URL, params url.Values) (*http.Request, error) {
@@ -278,13 +574,29 @@ func (c *Client) newRequest(u *url.URL, params url.Values) (*http.Request, error
 	return req, nil
 }
 
+// response is implemented by all API responses through the embedded status.
+type response interface {
+	apiStatus() *status
+}
+
 type status struct {
 	Status        string `json:"status"`
 	StatusMessage string `json:"status_message"`
 }
 
+func (s *status) apiStatus() *status {
+	return s
+}
+
+func (s *status) err() error {
+	if s.Status != statusOK {
+		return fmt.Errorf("api returned incorrect status %s: %s", s.Status, s.StatusMessage)
+	}
+	return nil
+}
+
 type movieDetailsData struct {
-	Movie *Movie `json:"movie"`
+	Movie json.RawMessage `json:"movie"`
 }
 
 type movieDetailsResponse struct {
@@ -292,14 +604,22 @@ type movieDetailsResponse struct {
 	Data movieDetailsData `json:"data"`
 }
 
+// moviesData mirrors Movies, movies are decoded separately by the Client.
+type moviesData struct {
+	MovieCount uint              `json:"movie_count"`
+	Page       uint              `json:"page_number"`
+	Limit      uint              `json:"limit"`
+	Movies     []json.RawMessage `json:"movies"`
+}
+
 type listMoviesResponse struct {
 	status
-	Data *Movies `json:"data"`
+	Data *moviesData `json:"data"`
 }
 
 type suggestionsData struct {
-	MovieCount uint     `json:"movie_count"`
-	Movies     []*Movie `json:"movies"`
+	MovieCount uint              `json:"movie_count"`
+	Movies     []json.RawMessage `json:"movies"`
 }
 
 type suggestionsResponse struct {
@@ -44,6 +44,7 @@ func (f *fakeYTSServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	f.req = r
+	w.Header().Set("Content-Type", "application/json")
 	w.Write(f.data)
 }
 
@@ -142,6 +143,116 @@ func TestMovie(t *testing.T) {
 	}
 }
 
+func TestMovieByIMDBCode(t *testing.T) {
+	testData := []struct {
+		desc    string
+		data    []byte
+		wantID  uint
+		wantErr error
+	}{
+		{
+			desc:   "found",
+			data:   loadTestData("matrix.json", t),
+			wantID: 3525,
+		},
+		{
+			desc:    "not found",
+			data:    []byte(`{"status":"ok","status_message":"Query was successful","data":{"movie":{"id":0,"url":"","title":null}}}`),
+			wantErr: ErrNotFound,
+		},
+	}
+	f := &fakeYTSServer{}
+	ts := httptest.NewServer(f)
+	defer ts.Close()
+	c, err := New(BaseURL(ts.URL))
+	if err != nil {
+		t.Fatalf("Failed to connect to test server: %v", err)
+	}
+	for _, tc := range testData {
+		t.Run(tc.desc, func(t *testing.T) {
+			f.data = tc.data
+			m, err := c.MovieByIMDBCode("tt0133093", MovieWithCast(true))
+			if err != tc.wantErr {
+				t.Fatalf("Unexpected error, got %v want %v", err, tc.wantErr)
+			}
+			want := url.Values{"imdb_id": {"tt0133093"}, "with_cast": {"true"}}
+			if diff := cmp.Diff(want, f.req.URL.Query()); diff != "" {
+				t.Errorf("Unexpected query, diff -want +got\n%s", diff)
+			}
+			if err == nil && m.ID != tc.wantID {
+				t.Errorf("Unexpected movie ID, got %d want %d", m.ID, tc.wantID)
+			}
+		})
+	}
+}
+
+func TestMovieBySlug(t *testing.T) {
+	var paths []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path+"?"+r.URL.RawQuery)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/list_movies.json":
+			w.Write(loadTestData("matrixes.json", t))
+		case "/movie_details.json":
+			w.Write(loadTestData("matrix.json", t))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+	c, err := New(BaseURL(ts.URL))
+	if err != nil {
+		t.Fatalf("Failed to connect to test server: %v", err)
+	}
+	testData := []struct {
+		desc      string
+		slug      string
+		opts      []MovieOption
+		wantID    uint
+		wantPaths []string
+		wantErr   error
+	}{
+		{
+			desc:      "from list",
+			slug:      "the-matrix-reloaded-2003",
+			wantID:    3526,
+			wantPaths: []string{"/list_movies.json?limit=50&query_term=the+matrix+reloaded"},
+		},
+		{
+			desc:   "with details",
+			slug:   "the-matrix-1999",
+			opts:   []MovieOption{MovieWithCast(true)},
+			wantID: 3525,
+			wantPaths: []string{
+				"/list_movies.json?limit=50&query_term=the+matrix",
+				"/movie_details.json?movie_id=3525&with_cast=true",
+			},
+		},
+		{
+			desc:      "not found",
+			slug:      "the-matrix-2021",
+			wantPaths: []string{"/list_movies.json?limit=50&query_term=the+matrix"},
+			wantErr:   ErrNotFound,
+		},
+	}
+	for _, tc := range testData {
+		t.Run(tc.desc, func(t *testing.T) {
+			paths = nil
+			m, err := c.MovieBySlug(tc.slug, tc.opts...)
+			if err != tc.wantErr {
+				t.Fatalf("Unexpected error, got %v want %v", err, tc.wantErr)
+			}
+			if diff := cmp.Diff(tc.wantPaths, paths); diff != "" {
+				t.Errorf("Unexpected requests, diff -want +got\n%s", diff)
+			}
+			if err == nil && m.ID != tc.wantID {
+				t.Errorf("Unexpected movie ID, got %d want %d", m.ID, tc.wantID)
+			}
+		})
+	}
+}
+
 func TestListMovies(t *testing.T) {
 	testData := []struct {
 		desc      string
@@ -256,6 +367,16 @@ func TestListMovies(t *testing.T) {
 				return v
 			}(),
 		},
+		{
+			desc:     "with rt ratings",
+			opts:     []ListMoviesOption{LMWithRTRatings(true)},
+			respFile: "matrixes.json",
+			wantQuery: func() url.Values {
+				v := url.Values{}
+				v.Set("with_rt_ratings", "true")
+				return v
+			}(),
+		},
 		{
 			desc:     "unmarshal error",
 			respFile: "bad_json.json",
@@ -373,3 +494,70 @@ func TestSuggestions(t *testing.T) {
 		})
 	}
 }
+
+func TestListMoviesQuery(t *testing.T) {
+	testData := []struct {
+		desc    string
+		query   string
+		want    url.Values
+		wantErr bool
+	}{
+		{
+			desc:  "all parameters",
+			query: "limit=80&page=2&minimum_rating=7&quality=1080p&query_term=matrix&genre=action&sort_by=year&order_by=asc&with_rt_ratings=1&unknown=1",
+			want: url.Values{
+				"limit":           {"50"},
+				"page":            {"2"},
+				"minimum_rating":  {"7"},
+				"quality":         {"1080p"},
+				"query_term":      {"matrix"},
+				"genre":           {"action"},
+				"sort_by":         {"year"},
+				"order_by":        {"asc"},
+				"with_rt_ratings": {"true"},
+			},
+		},
+		{
+			desc:  "empty",
+			query: "",
+			want:  url.Values{},
+		},
+		{
+			desc:    "invalid number",
+			query:   "page=two",
+			wantErr: true,
+		},
+		{
+			desc:    "negative number",
+			query:   "limit=-1",
+			wantErr: true,
+		},
+		{
+			desc:    "invalid bool",
+			query:   "with_rt_ratings=maybe",
+			wantErr: true,
+		},
+	}
+	for _, tc := range testData {
+		t.Run(tc.desc, func(t *testing.T) {
+			q, err := url.ParseQuery(tc.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			opts, err := ListMoviesQuery(q)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Unexpected error, got %v want %v", err, tc.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			got := url.Values{}
+			for _, o := range opts {
+				o(got)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Unexpected query, diff -want +got\n%s", diff)
+			}
+		})
+	}
+}